@@ -104,6 +104,37 @@ func (api *Bytes32API) FromBinary(vs ...Uint248) Bytes32 {
 	return res
 }
 
+// ToBytes decomposes the input v to a list (size 32) of big-endian bytes, i.e.
+// the same byte order as abi.encodePacked(v)
+func (api *Bytes32API) ToBytes(v Bytes32) List[Uint248] {
+	bits := v.toBinaryVars(api.g)
+	ret := make(List[Uint248], 32)
+	for i := range ret {
+		ret[i] = newU248(api.g.FromBinary(bits[8*(31-i) : 8*(32-i)]...))
+	}
+	return ret
+}
+
+// FromBytes recomposes a list of at most 32 big-endian bytes to a Bytes32. The
+// input is padded on the MSB end with 0s. The user must make sure each element
+// of vs is a byte.
+func (api *Bytes32API) FromBytes(vs ...Uint248) Bytes32 {
+	if len(vs) > 32 {
+		panic(fmt.Sprintf("cannot construct Bytes32 from %d bytes", len(vs)))
+	}
+	res := Bytes32{Val: [2]frontend.Variable{0, 0}}
+	for i, v := range vs {
+		// the i-th byte from the LSB end
+		pos := len(vs) - 1 - i
+		if pos < numBitsPerVar/8 {
+			res.Val[0] = api.g.Add(res.Val[0], api.g.Mul(v.Val, new(big.Int).Lsh(big.NewInt(1), uint(8*pos))))
+		} else {
+			res.Val[1] = v.Val
+		}
+	}
+	return res
+}
+
 func (api *Bytes32API) FromFV(v frontend.Variable) Bytes32 {
 	res := Bytes32{}
 	values := api.g.ToBinary(v, 256)
@@ -130,6 +161,77 @@ func (api *Bytes32API) Select(s Uint248, a, b Bytes32) Bytes32 {
 	return res
 }
 
+// BitwiseAnd returns the bitwise AND of a and b
+func (api *Bytes32API) BitwiseAnd(a, b Bytes32) Bytes32 {
+	return api.bitwise(a, b, api.g.And)
+}
+
+// BitwiseOr returns the bitwise OR of a and b
+func (api *Bytes32API) BitwiseOr(a, b Bytes32) Bytes32 {
+	return api.bitwise(a, b, api.g.Or)
+}
+
+// BitwiseXor returns the bitwise XOR of a and b
+func (api *Bytes32API) BitwiseXor(a, b Bytes32) Bytes32 {
+	return api.bitwise(a, b, api.g.Xor)
+}
+
+// BitwiseNot returns the bitwise NOT of a
+func (api *Bytes32API) BitwiseNot(a Bytes32) Bytes32 {
+	bits := api.ToBinary(a)
+	for i, bit := range bits {
+		bits[i] = newU248(api.g.Sub(1, bit.Val))
+	}
+	return api.FromBinary(bits...)
+}
+
+// Lsh returns a << n. Bits shifted beyond 256 bits are discarded
+func (api *Bytes32API) Lsh(a Bytes32, n int) Bytes32 {
+	if n <= 0 {
+		return a
+	}
+	bits := api.ToBinary(a)
+	if n >= len(bits) {
+		return api.FromBinary()
+	}
+	shifted := make([]Uint248, len(bits))
+	for i := range shifted {
+		shifted[i] = newU248(0)
+	}
+	copy(shifted[n:], bits[:len(bits)-n])
+	return api.FromBinary(shifted...)
+}
+
+// Rsh returns a >> n
+func (api *Bytes32API) Rsh(a Bytes32, n int) Bytes32 {
+	if n <= 0 {
+		return a
+	}
+	bits := api.ToBinary(a)
+	if n >= len(bits) {
+		return api.FromBinary()
+	}
+	return api.FromBinary(bits[n:]...)
+}
+
+// Mask returns the lowest n bits of a, i.e. a & (2^n - 1)
+func (api *Bytes32API) Mask(a Bytes32, n int) Bytes32 {
+	if n >= 256 {
+		return a
+	}
+	bits := api.ToBinary(a)
+	return api.FromBinary(bits[:max(n, 0)]...)
+}
+
+func (api *Bytes32API) bitwise(a, b Bytes32, op func(x, y frontend.Variable) frontend.Variable) Bytes32 {
+	aBits, bBits := api.ToBinary(a), api.ToBinary(b)
+	res := make([]Uint248, len(aBits))
+	for i := range res {
+		res[i] = newU248(op(aBits[i].Val, bBits[i].Val))
+	}
+	return api.FromBinary(res...)
+}
+
 // IsZero returns 1 if a == 0, and 0 otherwise
 func (api *Bytes32API) IsZero(a Bytes32) Uint248 {
 	return newU248(api.g.And(api.g.IsZero(a.Val[0]), api.g.IsZero(a.Val[1])))
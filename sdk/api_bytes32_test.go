@@ -30,6 +30,7 @@ func (c *TestBytes32APICircuit) Define(g frontend.API) error {
 	c.testSelect()
 	c.testIsZero()
 	c.testConvertFV()
+	c.testBitwise()
 	return nil
 }
 
@@ -95,3 +96,17 @@ func (c *TestBytes32APICircuit) testConvertFV() {
 	c.g.AssertIsEqual(data1.Val[0], data2.Val[0])
 	c.g.AssertIsEqual(data1.Val[1], data2.Val[1])
 }
+
+func (c *TestBytes32APICircuit) testBitwise() {
+	a, b := testBytes.Big(), testBytes2.Big()
+	b32 := func(v *big.Int) Bytes32 { return ConstFromBigEndianBytes(common.BigToHash(v).Bytes()) }
+	ones := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	c.b32.AssertIsEqual(c.b32.BitwiseAnd(b32(a), b32(b)), b32(new(big.Int).And(a, b)))
+	c.b32.AssertIsEqual(c.b32.BitwiseOr(b32(a), b32(b)), b32(new(big.Int).Or(a, b)))
+	c.b32.AssertIsEqual(c.b32.BitwiseXor(b32(a), b32(b)), b32(new(big.Int).Xor(a, b)))
+	c.b32.AssertIsEqual(c.b32.BitwiseNot(b32(a)), b32(new(big.Int).Xor(a, ones)))
+	c.b32.AssertIsEqual(c.b32.Lsh(b32(a), 20), b32(new(big.Int).And(new(big.Int).Lsh(a, 20), ones)))
+	c.b32.AssertIsEqual(c.b32.Rsh(b32(a), 20), b32(new(big.Int).Rsh(a, 20)))
+	c.b32.AssertIsEqual(c.b32.Rsh(b32(a), 300), b32(big.NewInt(0)))
+	c.b32.AssertIsEqual(c.b32.Mask(b32(a), 160), b32(new(big.Int).And(a, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1)))))
+}
@@ -0,0 +1,249 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// FixedScale defines the scaling factor of a fixed-point number: a Fixed with
+// raw integer value v represents v / Scale(). Custom scales can be defined by
+// implementing this interface on an empty struct.
+type FixedScale interface {
+	Scale() *big.Int
+}
+
+// Decimal18 is the scale of 18 decimals used by most ERC-20 tokens and WAD math
+type Decimal18 struct{}
+
+func (Decimal18) Scale() *big.Int { return new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil) }
+
+// Q64x96 is the binary scale of Uniswap V3 sqrtPriceX96 values
+type Q64x96 struct{}
+
+func (Q64x96) Scale() *big.Int { return new(big.Int).Lsh(big.NewInt(1), 96) }
+
+// Q112x112 is the binary scale of Uniswap V2 UQ112x112 price accumulators
+type Q112x112 struct{}
+
+func (Q112x112) Scale() *big.Int { return new(big.Int).Lsh(big.NewInt(1), 112) }
+
+// RoundingMode determines how FixedAPI rounds results that cannot be
+// represented exactly
+type RoundingMode int
+
+const (
+	// RoundDown rounds towards negative infinity
+	RoundDown RoundingMode = iota
+	// RoundUp rounds towards positive infinity
+	RoundUp
+	// RoundTowardZero truncates the result like integer division in Go and
+	// Solidity
+	RoundTowardZero
+	// RoundHalfUp rounds to the nearest representable value, and away from zero
+	// on ties
+	RoundHalfUp
+)
+
+// Fixed is a signed fixed-point number with the scale S. The raw integer value
+// is stored in the same two's complement encoding as Int248, so the range of a
+// Fixed is [-2^247, 2^247) / S.Scale().
+type Fixed[S FixedScale] struct {
+	Val frontend.Variable
+}
+
+var _ CircuitVariable = Fixed[Decimal18]{}
+
+func newFixed[S FixedScale](v Int248) Fixed[S] {
+	return Fixed[S]{Val: v.Val}
+}
+
+// ConstFixed initializes a constant Fixed. This function does not generate
+// circuit wires and should only be used outside of circuit. Supports all int
+// and uint variants, *big.Int, *big.Rat, and decimal or fractional strings such
+// as "1.5" and "-3/8". Values that cannot be represented exactly with the scale
+// S are rounded to the nearest representable value.
+func ConstFixed[S FixedScale](i interface{}) Fixed[S] {
+	ensureNotCircuitVariable(i)
+	var v *big.Rat
+	switch i := i.(type) {
+	case string:
+		var ok bool
+		v, ok = new(big.Rat).SetString(i)
+		if !ok {
+			panic(fmt.Errorf("cannot parse %s as a fixed-point number", i))
+		}
+	case *big.Rat:
+		v = i
+	default:
+		v = new(big.Rat).SetInt(fromInterface(i))
+	}
+	scaled := new(big.Rat).Mul(v, new(big.Rat).SetInt((*new(S)).Scale()))
+	return newFixed[S](ConstInt248(roundRatHalfUp(scaled)))
+}
+
+// roundRatHalfUp rounds v to the nearest integer, and away from zero on ties
+func roundRatHalfUp(v *big.Rat) *big.Int {
+	q, r := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if new(big.Int).Lsh(new(big.Int).Abs(r), 1).Cmp(v.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(v.Sign())))
+	}
+	return q
+}
+
+func (v Fixed[S]) Values() []frontend.Variable {
+	return []frontend.Variable{v.Val}
+}
+
+func (v Fixed[S]) FromValues(vs ...frontend.Variable) CircuitVariable {
+	if len(vs) != 1 {
+		panic("Fixed.FromValues only takes 1 param")
+	}
+	v.Val = vs[0]
+	return v
+}
+
+func (v Fixed[S]) NumVars() uint32 { return 1 }
+
+func (v Fixed[S]) String() string {
+	b, ok := v.Val.(*big.Int)
+	if !ok {
+		return ""
+	}
+	raw := new(big.Int).Set(b)
+	if raw.Bit(247) == 1 {
+		raw.Sub(raw, new(big.Int).Lsh(big.NewInt(1), 248))
+	}
+	scale := (*new(S)).Scale()
+	digits := len(scale.String()) - 1
+	return new(big.Rat).SetFrac(raw, scale).FloatString(digits)
+}
+
+// FixedAPI provides the arithmetic of fixed-point numbers with the scale S.
+// Unlike the other APIs, it is created on demand by NewFixedAPI since there is
+// one FixedAPI per scale.
+type FixedAPI[S FixedScale] struct {
+	api *CircuitAPI
+}
+
+// NewFixedAPI returns the API for fixed-point numbers with the scale S
+// Example: fx := NewFixedAPI[Decimal18](api); fx.Mul(a, b, RoundDown)
+func NewFixedAPI[S FixedScale](api *CircuitAPI) *FixedAPI[S] {
+	return &FixedAPI[S]{api: api}
+}
+
+func (fx *FixedAPI[S]) scale() *big.Int {
+	return (*new(S)).Scale()
+}
+
+func (fx *FixedAPI[S]) raw(v Fixed[S]) Int248 {
+	return newI248(v.Val)
+}
+
+// FromInt248 converts the integer v to a Fixed. Proving fails if the result
+// overflows
+func (fx *FixedAPI[S]) FromInt248(v Int248) Fixed[S] {
+	return newFixed[S](fx.api.Int248.Mul(v, ConstInt248(fx.scale())))
+}
+
+// ToInt248 converts v to an integer, rounding with the given mode
+func (fx *FixedAPI[S]) ToInt248(v Fixed[S], mode RoundingMode) Int248 {
+	i248 := fx.api.Int248
+	g := fx.api.g
+	raw := i248.ensureSignBit(fx.raw(v))
+	abs := i248.ABS(raw).Val
+	out, err := g.Compiler().NewHint(QuoRemHint, 2, abs, fx.scale())
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+	}
+	q, r := out[0], out[1]
+	rangeChecker := rangecheck.New(g)
+	rangeChecker.Check(q, 248)
+	rangeChecker.Check(r, fx.scale().BitLen())
+	rangeChecker.Check(g.Sub(fx.scale(), r, 1), fx.scale().BitLen()) // r < scale
+	g.AssertIsEqual(g.Add(i248.mulAbs(q, fx.scale()), r), abs)
+	return fx.round(q, r, fx.scale(), raw.SignBit, mode)
+}
+
+// Add returns a + b. Proving fails if the result overflows
+func (fx *FixedAPI[S]) Add(a, b Fixed[S]) Fixed[S] {
+	return newFixed[S](fx.api.Int248.Add(fx.raw(a), fx.raw(b)))
+}
+
+// Sub returns a - b. Proving fails if the result overflows
+func (fx *FixedAPI[S]) Sub(a, b Fixed[S]) Fixed[S] {
+	return newFixed[S](fx.api.Int248.Sub(fx.raw(a), fx.raw(b)))
+}
+
+// Mul returns a * b rounded with the given mode. Proving fails if the result
+// overflows
+func (fx *FixedAPI[S]) Mul(a, b Fixed[S], mode RoundingMode) Fixed[S] {
+	i248 := fx.api.Int248
+	rawA, rawB := i248.ensureSignBit(fx.raw(a)), i248.ensureSignBit(fx.raw(b))
+	// |a| * |b| / scale, the product needs up to 494 bits
+	prod := fx.api.Uint521.Mul(fx.api.ToUint521(i248.ABS(rawA)), fx.api.ToUint521(i248.ABS(rawB)))
+	q, r := fx.api.Uint521.quoRem(prod, ConstUint521(fx.scale()), numBitsPerVar)
+	isNeg := fx.api.g.Xor(rawA.SignBit, rawB.SignBit)
+	res := fx.round(fx.api.ToUint248(q).Val, fx.api.ToUint248(r).Val, fx.scale(), isNeg, mode)
+	return newFixed[S](res)
+}
+
+// Div returns a / b rounded with the given mode. Proving fails if b is 0 or the
+// result overflows
+func (fx *FixedAPI[S]) Div(a, b Fixed[S], mode RoundingMode) Fixed[S] {
+	i248 := fx.api.Int248
+	rawA, rawB := i248.ensureSignBit(fx.raw(a)), i248.ensureSignBit(fx.raw(b))
+	absB := i248.ABS(rawB)
+	// |a| * scale / |b|
+	num := fx.api.Uint521.Mul(fx.api.ToUint521(i248.ABS(rawA)), ConstUint521(fx.scale()))
+	q, r := fx.api.Uint521.quoRem(num, fx.api.ToUint521(absB), numBitsPerVar)
+	isNeg := fx.api.g.Xor(rawA.SignBit, rawB.SignBit)
+	res := fx.round(fx.api.ToUint248(q).Val, fx.api.ToUint248(r).Val, absB.Val, isNeg, mode)
+	return newFixed[S](res)
+}
+
+// IsLessThan returns 1 if a < b, and 0 otherwise
+func (fx *FixedAPI[S]) IsLessThan(a, b Fixed[S]) Uint248 {
+	return fx.api.Int248.IsLessThan(fx.raw(a), fx.raw(b))
+}
+
+// IsEqual returns 1 if a == b, and 0 otherwise
+func (fx *FixedAPI[S]) IsEqual(a, b Fixed[S]) Uint248 {
+	return fx.api.Int248.IsEqual(fx.raw(a), fx.raw(b))
+}
+
+// Select returns a if s == 1, and b if s == 0
+func (fx *FixedAPI[S]) Select(s Uint248, a, b Fixed[S]) Fixed[S] {
+	return newFixed[S](fx.api.Int248.Select(s, fx.raw(a), fx.raw(b)))
+}
+
+// AssertIsEqual asserts a == b
+func (fx *FixedAPI[S]) AssertIsEqual(a, b Fixed[S]) {
+	fx.api.Int248.AssertIsEqual(fx.raw(a), fx.raw(b))
+}
+
+// round returns the signed result of a division given the quotient q and
+// remainder r of |numerator| / den, and whether the result is negative
+func (fx *FixedAPI[S]) round(q, r, den, isNeg frontend.Variable, mode RoundingMode) Int248 {
+	g := fx.api.g
+	isInexact := g.Sub(1, g.IsZero(r))
+	var inc frontend.Variable
+	switch mode {
+	case RoundDown:
+		inc = g.Mul(isInexact, isNeg)
+	case RoundUp:
+		inc = g.Mul(isInexact, g.Sub(1, isNeg))
+	case RoundTowardZero:
+		inc = 0
+	case RoundHalfUp:
+		// 2r >= den
+		isLess := g.IsZero(g.Add(Cmp(g, g.Mul(r, 2), den, 249), 1))
+		inc = g.Sub(1, isLess)
+	default:
+		panic(fmt.Errorf("unsupported rounding mode %d", mode))
+	}
+	abs := g.Add(q, inc)
+	return fx.api.Int248.fromSigned(g.Select(isNeg, g.Neg(abs), abs))
+}
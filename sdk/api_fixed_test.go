@@ -0,0 +1,74 @@
+package sdk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestConstFixed(t *testing.T) {
+	if s := ConstFixed[Decimal18]("-1.25").String(); s != "-1.250000000000000000" {
+		t.Errorf("unexpected string %s", s)
+	}
+	if v := fromInterface(ConstFixed[Q64x96]("0.5").Val); v.Cmp(new(big.Int).Lsh(big.NewInt(1), 95)) != 0 {
+		t.Errorf("unexpected raw value %d", v)
+	}
+	// 1/3 * 10^18 rounded to the nearest
+	if v := fromInterface(ConstFixed[Decimal18]("2/3").Val); v.String() != "666666666666666667" {
+		t.Errorf("unexpected raw value %d", v)
+	}
+}
+
+func TestFixedAPI(t *testing.T) {
+	c := &TestFixedAPICircuit{}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+}
+
+type TestFixedAPICircuit struct {
+	api *CircuitAPI
+}
+
+func (c *TestFixedAPICircuit) Define(g frontend.API) error {
+	c.api = NewCircuitAPI(g)
+	fx := NewFixedAPI[Decimal18](c.api)
+	d := func(s string) Fixed[Decimal18] { return ConstFixed[Decimal18](s) }
+
+	fx.AssertIsEqual(fx.Add(d("1.5"), d("-2.25")), d("-0.75"))
+	fx.AssertIsEqual(fx.Sub(d("1.5"), d("-2.25")), d("3.75"))
+	fx.AssertIsEqual(fx.Mul(d("1.5"), d("-2.25"), RoundDown), d("-3.375"))
+	fx.AssertIsEqual(fx.Div(d("-3.375"), d("1.5"), RoundDown), d("-2.25"))
+
+	// 2/3 = 0.666...
+	fx.AssertIsEqual(fx.Div(d("2"), d("3"), RoundDown), d("0.666666666666666666"))
+	fx.AssertIsEqual(fx.Div(d("2"), d("3"), RoundUp), d("0.666666666666666667"))
+	fx.AssertIsEqual(fx.Div(d("2"), d("3"), RoundTowardZero), d("0.666666666666666666"))
+	fx.AssertIsEqual(fx.Div(d("2"), d("3"), RoundHalfUp), d("0.666666666666666667"))
+	fx.AssertIsEqual(fx.Div(d("-2"), d("3"), RoundDown), d("-0.666666666666666667"))
+	fx.AssertIsEqual(fx.Div(d("-2"), d("3"), RoundUp), d("-0.666666666666666666"))
+	fx.AssertIsEqual(fx.Div(d("-2"), d("3"), RoundTowardZero), d("-0.666666666666666666"))
+	fx.AssertIsEqual(fx.Div(d("-2"), d("3"), RoundHalfUp), d("-0.666666666666666667"))
+	// 0.000000000000000001 * 0.5 is exactly half way between 0 and 1e-18
+	fx.AssertIsEqual(fx.Mul(d("0.000000000000000001"), d("0.5"), RoundHalfUp), d("0.000000000000000001"))
+	fx.AssertIsEqual(fx.Mul(d("0.000000000000000001"), d("0.5"), RoundTowardZero), d("0"))
+
+	i := func(v int64) Int248 { return ConstInt248(big.NewInt(v)) }
+	fx.AssertIsEqual(fx.FromInt248(i(-3)), d("-3"))
+	c.api.Int248.AssertIsEqual(fx.ToInt248(d("-2.5"), RoundDown), i(-3))
+	c.api.Int248.AssertIsEqual(fx.ToInt248(d("-2.5"), RoundUp), i(-2))
+	c.api.Int248.AssertIsEqual(fx.ToInt248(d("-2.5"), RoundHalfUp), i(-3))
+	c.api.Int248.AssertIsEqual(fx.ToInt248(d("2.4"), RoundHalfUp), i(2))
+
+	c.api.Uint248.AssertIsEqual(fx.IsLessThan(d("-0.1"), d("0")), ConstUint248(1))
+	fx.AssertIsEqual(fx.Select(ConstUint248(0), d("1"), d("2")), d("2"))
+
+	// sqrtPriceX96 of 2^96 is a price of 1, squaring keeps it
+	q := NewFixedAPI[Q64x96](c.api)
+	one := ConstFixed[Q64x96](1)
+	q.AssertIsEqual(q.Mul(one, one, RoundDown), one)
+	q.AssertIsEqual(q.Div(ConstFixed[Q64x96]("3"), ConstFixed[Q64x96]("4"), RoundDown), ConstFixed[Q64x96]("0.75"))
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
 )
 
 type Int248 struct {
@@ -180,25 +181,51 @@ func (api *Int248API) ABS(a Int248) Uint248 {
 	return newU248(result)
 }
 
-//func (api *Int248API) Add(a, b Int248) Int248 {
-//	panic("unimplemented")
-//	return Int248{}
-//}
-//
-//func (api *Int248API) Sub(a, b Int248) Int248 {
-//	panic("unimplemented")
-//	return Int248{}
-//}
-//
-//func (api *Int248API) Mul(a, b Int248) Int248 {
-//	panic("unimplemented")
-//	return Int248{}
-//}
-//
-//func (api *Int248API) Div(a, b Int248) Int248 {
-//	panic("unimplemented")
-//	return Int248{}
-//}
+// Add returns a + b. Proving fails if the result overflows int248
+func (api *Int248API) Add(a, b Int248) Int248 {
+	// (ka - 2^247) + (kb - 2^247) = a + b
+	signed := api.g.Add(api.toSigned(a), api.toSigned(b))
+	return api.fromSigned(signed)
+}
+
+// Sub returns a - b. Proving fails if the result overflows int248
+func (api *Int248API) Sub(a, b Int248) Int248 {
+	signed := api.g.Sub(api.toSigned(a), api.toSigned(b))
+	return api.fromSigned(signed)
+}
+
+// Mul returns a * b. Proving fails if the result overflows int248
+func (api *Int248API) Mul(a, b Int248) Int248 {
+	a = api.ensureSignBit(a)
+	b = api.ensureSignBit(b)
+	abs := api.mulAbs(api.ABS(a).Val, api.ABS(b).Val)
+	isNeg := api.g.Xor(a.SignBit, b.SignBit)
+	return api.fromSigned(api.g.Select(isNeg, api.g.Neg(abs), abs))
+}
+
+// Div computes the signed integer division like Go does, i.e. the quotient is
+// truncated towards zero and the remainder has the same sign as a. Uses
+// QuoRemHint. Proving fails if the result overflows int248 (-2^247 / -1).
+func (api *Int248API) Div(a, b Int248) (quotient, remainder Int248) {
+	a = api.ensureSignBit(a)
+	b = api.ensureSignBit(b)
+	absA, absB := api.ABS(a).Val, api.ABS(b).Val
+	out, err := api.g.Compiler().NewHint(QuoRemHint, 2, absA, absB)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+	}
+	q, r := out[0], out[1]
+	rangeChecker := rangecheck.New(api.g)
+	rangeChecker.Check(q, 248)
+	rangeChecker.Check(r, 248)
+	rangeChecker.Check(api.g.Sub(absB, r, 1), 248) // r < |b|
+	api.g.AssertIsEqual(api.g.Add(api.mulAbs(q, absB), r), absA)
+
+	isQuoNeg := api.g.Xor(a.SignBit, b.SignBit)
+	quotient = api.fromSigned(api.g.Select(isQuoNeg, api.g.Neg(q), q))
+	remainder = api.fromSigned(api.g.Select(a.SignBit, api.g.Neg(r), r))
+	return
+}
 
 // Select returns a if s == 1, and b if s == 0
 func (api *Int248API) Select(s Uint248, a, b Int248) Int248 {
@@ -222,6 +249,57 @@ func (api *Int248API) AssertIsDifferent(a, b Int248) {
 	api.g.AssertIsDifferent(a.Val, b.Val)
 }
 
+// toSigned returns the signed value of v as a native field element, i.e.
+// negative values are represented as r - |v| where r is the field modulus
+func (api *Int248API) toSigned(v Int248) frontend.Variable {
+	half := new(big.Int).Lsh(big.NewInt(1), 247)
+	return api.g.Sub(flipSignBit(api.g, v.Val), half)
+}
+
+// fromSigned is the reverse of toSigned. Proving fails if v is not in the range
+// of int248
+func (api *Int248API) fromSigned(v frontend.Variable) Int248 {
+	half := new(big.Int).Lsh(big.NewInt(1), 247)
+	key := api.g.Add(v, half)
+	rangecheck.New(api.g).Check(key, 248)
+	return newI248(flipSignBit(api.g, key))
+}
+
+// mulAbs returns a * b for non-negative a and b that fit in 248 bits. Proving
+// fails if the product does not fit in 249 bits. The product is computed on
+// 124-bit limbs so that it cannot wrap around the field modulus.
+func (api *Int248API) mulAbs(a, b frontend.Variable) frontend.Variable {
+	a1, a0 := splitLimbs(api.g, a, 124)
+	b1, b0 := splitLimbs(api.g, b, 124)
+	// the high limbs would contribute at least 2^248
+	api.g.AssertIsEqual(api.g.Mul(a1, b1), 0)
+	mid := api.g.Add(api.g.Mul(a1, b0), api.g.Mul(a0, b1))
+	rangecheck.New(api.g).Check(mid, 124)
+	return api.g.Add(api.g.Mul(mid, new(big.Int).Lsh(big.NewInt(1), 124)), api.g.Mul(a0, b0))
+}
+
+// splitLimbs splits a 2*limbBits-bit v into v = hi * 2^limbBits + lo
+func splitLimbs(g frontend.API, v frontend.Variable, limbBits int) (hi, lo frontend.Variable) {
+	return splitBits(g, v, limbBits, 2*limbBits)
+}
+
+// flipSignBit computes (v + 2^247) mod 2^248 for a 248-bit v. It maps the two's
+// complement encoding of an int248 to an order preserving uint248 and vice versa.
+func flipSignBit(g frontend.API, v frontend.Variable) frontend.Variable {
+	half := new(big.Int).Lsh(big.NewInt(1), 247)
+	full := new(big.Int).Lsh(big.NewInt(1), 248)
+	shifted := g.Add(v, half)
+	out, err := g.Compiler().NewHint(QuoRemHint, 2, shifted, full)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+	}
+	q, r := out[0], out[1]
+	g.AssertIsBoolean(q)
+	rangecheck.New(g).Check(r, 248)
+	g.AssertIsEqual(g.Add(g.Mul(q, full), r), shifted)
+	return r
+}
+
 func (api *Int248API) ensureSignBit(v Int248) Int248 {
 	if v.signBitSet {
 		return v
@@ -109,4 +109,57 @@ func (c *TestInt248APICircuit) testArithmetics() {
 
 	abs = c.i248.ABS(ConstInt248(big.NewInt(0)))
 	c.g.AssertIsEqual(big.NewInt(0), abs.Val)
+
+	i := func(v int64) Int248 { return ConstInt248(big.NewInt(v)) }
+	c.i248.AssertIsEqual(c.i248.Add(testI248Neg, testI248Pos3), i(-1))
+	c.i248.AssertIsEqual(c.i248.Add(i(-5), i(-7)), i(-12))
+	c.i248.AssertIsEqual(c.i248.Sub(testI248Pos, testI248Pos2), i(-1))
+	c.i248.AssertIsEqual(c.i248.Sub(i(-5), i(-7)), i(2))
+	c.i248.AssertIsEqual(c.i248.Mul(i(-6), i(7)), i(-42))
+	c.i248.AssertIsEqual(c.i248.Mul(i(-6), i(-7)), i(42))
+	c.i248.AssertIsEqual(c.i248.Mul(i(0), i(-7)), i(0))
+	c.i248.AssertIsEqual(c.i248.Mul(testI248Neg2, testI248Pos), ConstInt248(new(big.Int).Mul(testInt248Neg2, testInt248Pos)))
+
+	// truncated division like Go
+	for _, d := range [][4]int64{{7, 2, 3, 1}, {-7, 2, -3, -1}, {7, -2, -3, 1}, {-7, -2, 3, -1}, {6, 3, 2, 0}} {
+		q, r := c.i248.Div(i(d[0]), i(d[1]))
+		c.i248.AssertIsEqual(q, i(d[2]))
+		c.i248.AssertIsEqual(r, i(d[3]))
+	}
+	minInt248 := ConstInt248(new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 247)))
+	q, r := c.i248.Div(minInt248, i(2))
+	c.i248.AssertIsEqual(q, ConstInt248(new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 246))))
+	c.i248.AssertIsEqual(r, i(0))
+}
+
+func TestInt248Overflow(t *testing.T) {
+	for _, op := range []string{"add", "sub", "mul", "div"} {
+		c := &TestInt248OverflowCircuit{op: op}
+		err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+		if err == nil {
+			t.Errorf("expected %s to fail on overflow", op)
+		}
+	}
+}
+
+type TestInt248OverflowCircuit struct {
+	op string
+}
+
+func (c *TestInt248OverflowCircuit) Define(g frontend.API) error {
+	api := newInt248API(g)
+	maxInt248 := ConstInt248(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 247), big.NewInt(1)))
+	minInt248 := ConstInt248(new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 247)))
+	one, minusOne := ConstInt248(big.NewInt(1)), ConstInt248(big.NewInt(-1))
+	switch c.op {
+	case "add":
+		api.Add(maxInt248, one)
+	case "sub":
+		api.Sub(minInt248, one)
+	case "mul":
+		api.Mul(maxInt248, ConstInt248(big.NewInt(2)))
+	case "div":
+		api.Div(minInt248, minusOne)
+	}
+	return nil
 }
@@ -203,6 +203,42 @@ func (api *Uint248API) Not(a Uint248) Uint248 {
 	return api.IsZero(a)
 }
 
+// BitwiseAnd returns the bitwise AND of a and b
+func (api *Uint248API) BitwiseAnd(a, b Uint248) Uint248 {
+	return newU248(bitwise(api.g, a.Val, b.Val, 248, api.g.And))
+}
+
+// BitwiseOr returns the bitwise OR of a and b
+func (api *Uint248API) BitwiseOr(a, b Uint248) Uint248 {
+	return newU248(bitwise(api.g, a.Val, b.Val, 248, api.g.Or))
+}
+
+// BitwiseXor returns the bitwise XOR of a and b
+func (api *Uint248API) BitwiseXor(a, b Uint248) Uint248 {
+	return newU248(bitwise(api.g, a.Val, b.Val, 248, api.g.Xor))
+}
+
+// BitwiseNot returns the bitwise NOT of a, i.e. 2^248 - 1 - a
+func (api *Uint248API) BitwiseNot(a Uint248) Uint248 {
+	rangecheck.New(api.g).Check(a.Val, 248)
+	return newU248(api.g.Sub(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 248), big.NewInt(1)), a.Val))
+}
+
+// Lsh returns a << n. Bits shifted beyond the 248-bit width are discarded
+func (api *Uint248API) Lsh(a Uint248, n int) Uint248 {
+	return newU248(shiftLeft(api.g, a.Val, n, 248))
+}
+
+// Rsh returns a >> n
+func (api *Uint248API) Rsh(a Uint248, n int) Uint248 {
+	return newU248(shiftRight(api.g, a.Val, n, 248))
+}
+
+// Mask returns the lowest n bits of a, i.e. a & (2^n - 1)
+func (api *Uint248API) Mask(a Uint248, n int) Uint248 {
+	return newU248(mask(api.g, a.Val, n, 248))
+}
+
 // Select returns a if s == 1, and b if s == 0
 func (api *Uint248API) Select(s Uint248, a, b Uint248) Uint248 {
 	api.g.AssertIsBoolean(s.Val)
@@ -28,6 +28,7 @@ func (c *TestUint248APICircuit) Define(g frontend.API) error {
 	c.testArithmetic()
 	c.testComparisons()
 	c.testLogical()
+	c.testBitwise()
 
 	return nil
 }
@@ -113,3 +114,20 @@ func (c *TestUint248APICircuit) testLogical() {
 	c.g.AssertIsEqual(c.u248.Not(one).Val, 0)
 	c.g.AssertIsEqual(c.u248.Not(zero).Val, 1)
 }
+
+func (c *TestUint248APICircuit) testBitwise() {
+	a, b := new(big.Int).Sub(MaxUint248, big.NewInt(12345678912345)), testUint248_2
+	u248 := c.u248
+	c.g.AssertIsEqual(u248.BitwiseAnd(ConstUint248(a), ConstUint248(b)).Val, new(big.Int).And(a, b))
+	c.g.AssertIsEqual(u248.BitwiseOr(ConstUint248(a), ConstUint248(b)).Val, new(big.Int).Or(a, b))
+	c.g.AssertIsEqual(u248.BitwiseXor(ConstUint248(a), ConstUint248(b)).Val, new(big.Int).Xor(a, b))
+	ones := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 248), big.NewInt(1))
+	c.g.AssertIsEqual(u248.BitwiseNot(ConstUint248(a)).Val, new(big.Int).Xor(a, ones))
+	// shifted out bits are discarded
+	c.g.AssertIsEqual(u248.Lsh(ConstUint248(a), 8).Val, new(big.Int).And(new(big.Int).Lsh(a, 8), ones))
+	c.g.AssertIsEqual(u248.Lsh(ConstUint248(a), 248).Val, 0)
+	c.g.AssertIsEqual(u248.Rsh(ConstUint248(a), 8).Val, new(big.Int).Rsh(a, 8))
+	c.g.AssertIsEqual(u248.Rsh(ConstUint248(a), 0).Val, a)
+	c.g.AssertIsEqual(u248.Mask(ConstUint248(a), 12).Val, new(big.Int).And(a, big.NewInt(0xfff)))
+	c.g.AssertIsEqual(u248.Mask(ConstUint248(a), 248).Val, a)
+}
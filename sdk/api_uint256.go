@@ -0,0 +1,264 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// uint256LimbBits is the bit size of each of the two limbs of a Uint256
+const uint256LimbBits = 128
+
+// Uint256 is a 256-bit unsigned integer with the same semantics as the EVM's
+// uint256. Val[0] holds the lower 128 bits and Val[1] holds the upper 128 bits.
+type Uint256 struct {
+	Val [2]frontend.Variable
+}
+
+var _ CircuitVariable = Uint256{}
+
+func newU256(lo, hi frontend.Variable) Uint256 {
+	return Uint256{Val: [2]frontend.Variable{lo, hi}}
+}
+
+// ConstUint256 initializes a constant Uint256. This function does not generate
+// circuit wires and should only be used outside of circuit. Supports all int and
+// uint variants, bool, []byte (big-endian), *big.Int, and string inputs. If
+// input is string, this function uses *big.Int SetString function to interpret
+// the string
+func ConstUint256(i interface{}) Uint256 {
+	ensureNotCircuitVariable(i)
+	v := fromInterface(i)
+	if v.Sign() < 0 {
+		panic("cannot initialize Uint256 with negative number")
+	}
+	if v.BitLen() > 256 {
+		panic("cannot initialize Uint256 with bit length > 256")
+	}
+	limbs := decomposeBig(v, uint256LimbBits, 2)
+	return newU256(limbs[0], limbs[1])
+}
+
+func (v Uint256) Values() []frontend.Variable {
+	return v.Val[:]
+}
+
+func (v Uint256) FromValues(vs ...frontend.Variable) CircuitVariable {
+	if len(vs) != 2 {
+		panic("Uint256.FromValues takes 2 params")
+	}
+	v.Val[0] = vs[0]
+	v.Val[1] = vs[1]
+	return v
+}
+
+func (v Uint256) NumVars() uint32 { return 2 }
+
+func (v Uint256) String() string {
+	_, ok := v.Val[0].(*big.Int)
+	if !ok {
+		return ""
+	}
+	hi := new(big.Int).Lsh(fromInterface(v.Val[1]), uint256LimbBits)
+	return hi.Add(hi, fromInterface(v.Val[0])).String()
+}
+
+// Uint256API provides arithmetic on Uint256. The arithmetic follows the EVM:
+// Add, Sub and Mul wrap around modulo 2^256, and dividing by 0 results in 0. The
+// CheckedXXX variants revert like solidity >= 0.8, i.e. proving fails on
+// overflow and underflow. Like the other APIs, inputs are expected to be
+// well-formed, i.e. each limb of a Uint256 is at most 128 bits wide. All results
+// are well-formed.
+type Uint256API struct {
+	g frontend.API `gnark:"-"`
+}
+
+func newUint256API(api frontend.API) *Uint256API {
+	return &Uint256API{api}
+}
+
+// FromBinary interprets the input vs as a list of at most 256 little-endian
+// binary digits and recomposes it to a Uint256
+func (api *Uint256API) FromBinary(vs ...Uint248) Uint256 {
+	if len(vs) > 256 {
+		panic(fmt.Sprintf("cannot construct Uint256 from binary of size %d bits", len(vs)))
+	}
+	bits := make([]frontend.Variable, 256)
+	for i := range bits {
+		if i < len(vs) {
+			bits[i] = vs[i].Val
+		} else {
+			bits[i] = 0
+		}
+	}
+	return newU256(api.g.FromBinary(bits[:uint256LimbBits]...), api.g.FromBinary(bits[uint256LimbBits:]...))
+}
+
+// ToBinary decomposes the input v to a list of 256 little-endian binary digits
+func (api *Uint256API) ToBinary(v Uint256) List[Uint248] {
+	bits := append(api.g.ToBinary(v.Val[0], uint256LimbBits), api.g.ToBinary(v.Val[1], uint256LimbBits)...)
+	return newU248s(bits...)
+}
+
+// Add returns (a + b) mod 2^256
+func (api *Uint256API) Add(a, b Uint256) Uint256 {
+	sum, _ := api.add(a, b)
+	return sum
+}
+
+// CheckedAdd returns a + b. Proving fails if the result overflows
+func (api *Uint256API) CheckedAdd(a, b Uint256) Uint256 {
+	sum, carry := api.add(a, b)
+	api.g.AssertIsEqual(carry, 0)
+	return sum
+}
+
+// Sub returns (a - b) mod 2^256
+func (api *Uint256API) Sub(a, b Uint256) Uint256 {
+	diff, _ := api.sub(a, b)
+	return diff
+}
+
+// CheckedSub returns a - b. Proving fails if b > a
+func (api *Uint256API) CheckedSub(a, b Uint256) Uint256 {
+	diff, borrow := api.sub(a, b)
+	api.g.AssertIsEqual(borrow, 0)
+	return diff
+}
+
+// Mul returns (a * b) mod 2^256
+func (api *Uint256API) Mul(a, b Uint256) Uint256 {
+	prod, _ := api.mul(a, b)
+	return prod
+}
+
+// CheckedMul returns a * b. Proving fails if the result overflows
+func (api *Uint256API) CheckedMul(a, b Uint256) Uint256 {
+	prod, overflow := api.mul(a, b)
+	api.g.AssertIsEqual(overflow, 0)
+	return prod
+}
+
+// Div computes the standard unsigned integer division and returns the quotient
+// and remainder. Like the EVM's DIV and MOD, both the quotient and the
+// remainder are 0 if b is 0. Uses Uint256QuoRemHint
+func (api *Uint256API) Div(a, b Uint256) (quotient, remainder Uint256) {
+	g := api.g
+	out, err := g.Compiler().NewHint(Uint256QuoRemHint, 4, a.Val[0], a.Val[1], b.Val[0], b.Val[1])
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize Uint256QuoRemHint instance: %s", err.Error()))
+	}
+	q, r := newU256(out[0], out[1]), newU256(out[2], out[3])
+	// if b is 0, then checking q * 1 + r == 0 and r < 1 forces q and r to be 0
+	isZero := api.IsZero(b)
+	one, zero := ConstUint256(1), ConstUint256(0)
+	den := api.Select(isZero, one, b)
+	num := api.Select(isZero, zero, a)
+	api.AssertIsEqual(api.CheckedAdd(api.CheckedMul(q, den), r), num)
+	g.AssertIsEqual(api.IsLessThan(r, den).Val, 1)
+	return q, r
+}
+
+// IsZero returns 1 if a == 0, and 0 otherwise
+func (api *Uint256API) IsZero(a Uint256) Uint248 {
+	return newU248(api.g.And(api.g.IsZero(a.Val[0]), api.g.IsZero(a.Val[1])))
+}
+
+// IsEqual returns 1 if a == b, and 0 otherwise
+func (api *Uint256API) IsEqual(a, b Uint256) Uint248 {
+	g := api.g
+	return newU248(g.And(g.IsZero(g.Sub(a.Val[0], b.Val[0])), g.IsZero(g.Sub(a.Val[1], b.Val[1]))))
+}
+
+// IsLessThan returns 1 if a < b, and 0 otherwise
+func (api *Uint256API) IsLessThan(a, b Uint256) Uint248 {
+	g := api.g
+	hi := Cmp(g, a.Val[1], b.Val[1], uint256LimbBits)
+	lo := Cmp(g, a.Val[0], b.Val[0], uint256LimbBits)
+	c := g.Select(g.IsZero(hi), lo, hi)
+	return newU248(g.IsZero(g.Add(c, 1)))
+}
+
+// IsGreaterThan returns 1 if a > b, and 0 otherwise
+func (api *Uint256API) IsGreaterThan(a, b Uint256) Uint248 {
+	return api.IsLessThan(b, a)
+}
+
+// Select returns a if s == 1, and b if s == 0
+func (api *Uint256API) Select(s Uint248, a, b Uint256) Uint256 {
+	api.g.AssertIsBoolean(s.Val)
+	return newU256(api.g.Select(s.Val, a.Val[0], b.Val[0]), api.g.Select(s.Val, a.Val[1], b.Val[1]))
+}
+
+// AssertIsEqual asserts a == b
+func (api *Uint256API) AssertIsEqual(a, b Uint256) {
+	api.g.AssertIsEqual(a.Val[0], b.Val[0])
+	api.g.AssertIsEqual(a.Val[1], b.Val[1])
+}
+
+// AssertIsLessOrEqual asserts a <= b
+func (api *Uint256API) AssertIsLessOrEqual(a, b Uint256) {
+	api.g.AssertIsEqual(api.IsGreaterThan(a, b).Val, 0)
+}
+
+// AssertIsDifferent asserts a != b
+func (api *Uint256API) AssertIsDifferent(a, b Uint256) {
+	api.g.AssertIsEqual(api.IsEqual(a, b).Val, 0)
+}
+
+// add returns (a + b) mod 2^256 and the carry out of the highest limb
+func (api *Uint256API) add(a, b Uint256) (sum Uint256, carry frontend.Variable) {
+	g := api.g
+	c0, lo := splitBits(g, g.Add(a.Val[0], b.Val[0]), uint256LimbBits, uint256LimbBits+1)
+	c1, hi := splitBits(g, g.Add(a.Val[1], b.Val[1], c0), uint256LimbBits, uint256LimbBits+1)
+	return newU256(lo, hi), c1
+}
+
+// sub returns (a - b) mod 2^256 and whether the subtraction borrows, i.e. b > a
+func (api *Uint256API) sub(a, b Uint256) (diff Uint256, borrow frontend.Variable) {
+	g := api.g
+	base := new(big.Int).Lsh(big.NewInt(1), uint256LimbBits)
+	// adding 2^128 to each limb keeps the differences non-negative, the carries
+	// are 1 if the limb does not borrow
+	c0, lo := splitBits(g, g.Add(g.Sub(a.Val[0], b.Val[0]), base), uint256LimbBits, uint256LimbBits+1)
+	c1, hi := splitBits(g, g.Add(g.Sub(a.Val[1], b.Val[1]), base, c0, -1), uint256LimbBits, uint256LimbBits+1)
+	return newU256(lo, hi), g.Sub(1, c1)
+}
+
+// mul returns (a * b) mod 2^256 and whether the product overflows. The product
+// is computed on 64-bit limbs so that no column of the schoolbook
+// multiplication wraps around the native field.
+func (api *Uint256API) mul(a, b Uint256) (prod Uint256, overflow frontend.Variable) {
+	g := api.g
+	const limbBits = uint256LimbBits / 2
+	al, bl := api.toLimbs64(a), api.toLimbs64(b)
+	var digits [4]frontend.Variable
+	var carry, high frontend.Variable = 0, 0
+	for k := 0; k < 7; k++ {
+		var col frontend.Variable = 0
+		for i := 0; i < 4; i++ {
+			if j := k - i; j >= 0 && j < 4 {
+				col = g.Add(col, g.Mul(al[i], bl[j]))
+			}
+		}
+		if k < 4 {
+			// a column is at most 4 * 2^128 plus a carry of at most 2^67
+			carry, digits[k] = splitBits(g, g.Add(col, carry), limbBits, 2*limbBits+3)
+		} else {
+			high = g.Add(high, col)
+		}
+	}
+	base := new(big.Int).Lsh(big.NewInt(1), limbBits)
+	lo := g.Add(digits[0], g.Mul(digits[1], base))
+	hi := g.Add(digits[2], g.Mul(digits[3], base))
+	return newU256(lo, hi), g.Sub(1, g.IsZero(g.Add(high, carry)))
+}
+
+// toLimbs64 splits the 128-bit limbs of v into 64-bit limbs
+func (api *Uint256API) toLimbs64(v Uint256) [4]frontend.Variable {
+	const limbBits = uint256LimbBits / 2
+	h0, l0 := splitBits(api.g, v.Val[0], limbBits, uint256LimbBits)
+	h1, l1 := splitBits(api.g, v.Val[1], limbBits, uint256LimbBits)
+	return [4]frontend.Variable{l0, h0, l1, h1}
+}
@@ -0,0 +1,99 @@
+package sdk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+var (
+	two256          = new(big.Int).Lsh(big.NewInt(1), 256)
+	maxUint256      = new(big.Int).Sub(two256, big.NewInt(1))
+	testUint256A    = new(big.Int).Sub(maxUint256, big.NewInt(12345))
+	testUint256B, _ = new(big.Int).SetString("340282366920938463463374607431768211507", 10)
+)
+
+func TestConstUint256(t *testing.T) {
+	for _, v := range []*big.Int{big.NewInt(0), testUint256A, testUint256B, maxUint256} {
+		if s := ConstUint256(v).String(); s != v.String() {
+			t.Errorf("expected %s, got %s", v, s)
+		}
+	}
+}
+
+func TestUint256API(t *testing.T) {
+	c := &TestUint256APICircuit{
+		A: ConstUint256(testUint256A),
+		B: ConstUint256(testUint256B),
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	for _, op := range []string{"add", "sub", "mul", "narrow"} {
+		c.Op = op
+		err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+		if err == nil {
+			t.Errorf("expected checked %s to fail", op)
+		}
+	}
+}
+
+type TestUint256APICircuit struct {
+	A, B Uint256
+	Op   string
+}
+
+func (c *TestUint256APICircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	u256 := api.Uint256
+	a, b := testUint256A, testUint256B
+	wrap := func(v *big.Int) Uint256 { return ConstUint256(new(big.Int).Mod(v, two256)) }
+
+	u256.AssertIsEqual(u256.Add(c.A, c.B), wrap(new(big.Int).Add(a, b)))
+	u256.AssertIsEqual(u256.Sub(c.B, c.A), wrap(new(big.Int).Sub(b, a)))
+	u256.AssertIsEqual(u256.Mul(c.A, c.B), wrap(new(big.Int).Mul(a, b)))
+	u256.AssertIsEqual(u256.CheckedSub(c.A, c.B), ConstUint256(new(big.Int).Sub(a, b)))
+	u256.AssertIsEqual(u256.CheckedAdd(c.B, c.B), ConstUint256(new(big.Int).Add(b, b)))
+	u256.AssertIsEqual(u256.CheckedMul(c.B, ConstUint256(1<<40)), ConstUint256(new(big.Int).Lsh(b, 40)))
+
+	q, r := u256.Div(c.A, c.B)
+	_q, _r := new(big.Int).QuoRem(a, b, new(big.Int))
+	u256.AssertIsEqual(q, ConstUint256(_q))
+	u256.AssertIsEqual(r, ConstUint256(_r))
+	q, r = u256.Div(c.A, ConstUint256(0))
+	u256.AssertIsEqual(q, ConstUint256(0))
+	u256.AssertIsEqual(r, ConstUint256(0))
+
+	api.Uint248.AssertIsEqual(u256.IsLessThan(c.B, c.A), ConstUint248(1))
+	api.Uint248.AssertIsEqual(u256.IsLessThan(c.A, c.A), ConstUint248(0))
+	api.Uint248.AssertIsEqual(u256.IsGreaterThan(c.A, c.B), ConstUint248(1))
+	api.Uint248.AssertIsEqual(u256.IsZero(u256.Sub(c.A, c.A)), ConstUint248(1))
+	api.Uint248.AssertIsEqual(u256.IsEqual(c.A, c.B), ConstUint248(0))
+	u256.AssertIsLessOrEqual(c.B, c.A)
+	u256.AssertIsDifferent(c.A, c.B)
+	u256.AssertIsEqual(u256.FromBinary(u256.ToBinary(c.A)...), c.A)
+
+	// conversions
+	u256.AssertIsEqual(api.ToUint256(api.ToBytes32(c.A)), c.A)
+	u256.AssertIsEqual(api.ToUint256(api.ToUint521(c.A)), c.A)
+	api.Bytes32.AssertIsEqual(api.ToBytes32(c.A), ConstFromBigEndianBytes(a.Bytes()))
+	api.Uint248.AssertIsEqual(api.ToUint248(c.B), ConstUint248(b))
+	u256.AssertIsEqual(api.ToUint256(ConstUint248(b)), c.B)
+	u256.AssertIsEqual(api.ToUint256(ConstUint64(7)), ConstUint256(7))
+	u256.AssertIsEqual(api.ToUint256(ConstInt248(big.NewInt(-1))), ConstUint256(maxUint256))
+
+	switch c.Op {
+	case "add":
+		u256.CheckedAdd(c.A, c.B)
+	case "sub":
+		u256.CheckedSub(c.B, c.A)
+	case "mul":
+		u256.CheckedMul(c.A, c.B)
+	case "narrow":
+		api.ToUint248(c.A)
+	}
+	return nil
+}
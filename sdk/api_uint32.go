@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/rangecheck"
@@ -181,6 +182,42 @@ func (api *Uint32API) Not(a Uint32) Uint32 {
 	return api.IsZero(a)
 }
 
+// BitwiseAnd returns the bitwise AND of a and b
+func (api *Uint32API) BitwiseAnd(a, b Uint32) Uint32 {
+	return newU32(bitwise(api.g, a.Val, b.Val, 32, api.g.And))
+}
+
+// BitwiseOr returns the bitwise OR of a and b
+func (api *Uint32API) BitwiseOr(a, b Uint32) Uint32 {
+	return newU32(bitwise(api.g, a.Val, b.Val, 32, api.g.Or))
+}
+
+// BitwiseXor returns the bitwise XOR of a and b
+func (api *Uint32API) BitwiseXor(a, b Uint32) Uint32 {
+	return newU32(bitwise(api.g, a.Val, b.Val, 32, api.g.Xor))
+}
+
+// BitwiseNot returns the bitwise NOT of a, i.e. 2^32 - 1 - a
+func (api *Uint32API) BitwiseNot(a Uint32) Uint32 {
+	rangecheck.New(api.g).Check(a.Val, 32)
+	return newU32(api.g.Sub(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 32), big.NewInt(1)), a.Val))
+}
+
+// Lsh returns a << n. Bits shifted beyond the 32-bit width are discarded
+func (api *Uint32API) Lsh(a Uint32, n int) Uint32 {
+	return newU32(shiftLeft(api.g, a.Val, n, 32))
+}
+
+// Rsh returns a >> n
+func (api *Uint32API) Rsh(a Uint32, n int) Uint32 {
+	return newU32(shiftRight(api.g, a.Val, n, 32))
+}
+
+// Mask returns the lowest n bits of a, i.e. a & (2^n - 1)
+func (api *Uint32API) Mask(a Uint32, n int) Uint32 {
+	return newU32(mask(api.g, a.Val, n, 32))
+}
+
 // Select returns a if s == 1, and b if s == 0
 func (api *Uint32API) Select(s Uint32, a, b Uint32) Uint32 {
 	api.g.AssertIsBoolean(s.Val)
@@ -29,6 +29,7 @@ func (c *TestUint32APICircuit) Define(g frontend.API) error {
 	c.testArithmetic()
 	c.testComparisons()
 	c.testLogical()
+	c.testBitwise()
 
 	return nil
 }
@@ -114,3 +115,20 @@ func (c *TestUint32APICircuit) testLogical() {
 	c.g.AssertIsEqual(c.u32.Not(one).Val, 0)
 	c.g.AssertIsEqual(c.u32.Not(zero).Val, 1)
 }
+
+func (c *TestUint32APICircuit) testBitwise() {
+	a, b := testUint32_2, testUint32
+	u32 := c.u32
+	c.g.AssertIsEqual(u32.BitwiseAnd(ConstUint32(a), ConstUint32(b)).Val, new(big.Int).And(a, b))
+	c.g.AssertIsEqual(u32.BitwiseOr(ConstUint32(a), ConstUint32(b)).Val, new(big.Int).Or(a, b))
+	c.g.AssertIsEqual(u32.BitwiseXor(ConstUint32(a), ConstUint32(b)).Val, new(big.Int).Xor(a, b))
+	ones := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 32), big.NewInt(1))
+	c.g.AssertIsEqual(u32.BitwiseNot(ConstUint32(a)).Val, new(big.Int).Xor(a, ones))
+	// shifted out bits are discarded
+	c.g.AssertIsEqual(u32.Lsh(ConstUint32(a), 8).Val, new(big.Int).And(new(big.Int).Lsh(a, 8), ones))
+	c.g.AssertIsEqual(u32.Lsh(ConstUint32(a), 32).Val, 0)
+	c.g.AssertIsEqual(u32.Rsh(ConstUint32(a), 8).Val, new(big.Int).Rsh(a, 8))
+	c.g.AssertIsEqual(u32.Rsh(ConstUint32(a), 0).Val, a)
+	c.g.AssertIsEqual(u32.Mask(ConstUint32(a), 12).Val, new(big.Int).And(a, big.NewInt(0xfff)))
+	c.g.AssertIsEqual(u32.Mask(ConstUint32(a), 32).Val, a)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/rangecheck"
 )
 
 var u521Field *emulated.Field[Uint521Field]
@@ -78,6 +79,9 @@ func ConstUint521(i interface{}) Uint521 {
 	return newU521(&el)
 }
 
+// Uint521API offers the operations of Uint248API on Uint521 except BitwiseNot:
+// the NOT of a over 521 bits, 2^521 - 1 - a, is -a modulo the modulus 2^521 - 1,
+// so use Sub instead.
 type Uint521API struct {
 	g frontend.API                  `gnark:"-"`
 	f *emulated.Field[Uint521Field] `gnark:"-"`
@@ -137,6 +141,138 @@ func (api *Uint521API) Mul(a, b Uint521) Uint521 {
 	return newU521(api.f.Mul(a.Element, b.Element))
 }
 
+// Div computes the standard unsigned integer division (like Go) and returns the
+// quotient and remainder. Uses Uint521QuoRemHint. Proving fails if b is 0
+func (api *Uint521API) Div(a, b Uint521) (quotient, remainder Uint521) {
+	out, err := api.f.NewHint(Uint521QuoRemHint, 2, a.Element, b.Element)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize Uint521QuoRemHint instance: %s", err.Error()))
+	}
+	q, r := newU521(out[0]), newU521(out[1])
+	// the limbs of the hint outputs are width-constrained, which is all
+	// assertMulAdd needs. r < b and q * b + r == a over the integers imply that
+	// both are in their canonical form.
+	bLimbs := api.canonicalLimbs(b)
+	lt, _ := cmpOrderedKeys(api.g, flipByGroups(r.Limbs, 1), flipByGroups(bLimbs, 1), int(Uint521Field{}.BitsPerLimb()))
+	api.g.AssertIsEqual(lt, 1)
+	api.assertMulAdd(q.Limbs, bLimbs, r.Limbs, api.canonicalLimbs(a))
+	return q, r
+}
+
+// Sqrt returns ⌊√a⌋. Uses Uint521SqrtHint
+func (api *Uint521API) Sqrt(a Uint521) Uint521 {
+	out, err := api.f.NewHint(Uint521SqrtHint, 1, a.Element)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize Uint521SqrtHint instance: %s", err.Error()))
+	}
+	s := newU521(out[0])
+	// s <= ⌊√(p-1)⌋ so that s^2 and 2s do not wrap around the modulus
+	maxSqrt := new(big.Int).Sqrt(new(big.Int).Sub(Uint521Field{}.Modulus(), big.NewInt(1)))
+	api.g.AssertIsEqual(api.IsGreaterThan(s, ConstUint521(maxSqrt)).Val, 0)
+	sq := api.Mul(s, s)
+	api.g.AssertIsEqual(api.IsLessThan(a, sq).Val, 0) // s^2 <= a
+	// a < (s+1)^2 <=> a - s^2 <= 2s
+	api.g.AssertIsEqual(api.IsGreaterThan(api.Sub(a, sq), api.Add(s, s)).Val, 0)
+	return s
+}
+
+// IsZero returns 1 if a == 0, and 0 otherwise
+func (api *Uint521API) IsZero(a Uint521) Uint248 {
+	return newU248(api.f.IsZero(a.Element))
+}
+
+// IsLessThan returns 1 if a < b, and 0 otherwise
+func (api *Uint521API) IsLessThan(a, b Uint521) Uint248 {
+	lt, _ := api.cmp(a, b)
+	return newU248(lt)
+}
+
+// IsGreaterThan returns 1 if a > b, and 0 otherwise
+func (api *Uint521API) IsGreaterThan(a, b Uint521) Uint248 {
+	return api.IsLessThan(b, a)
+}
+
+// And returns 1 if a && b [&& other[0] [&& other[1]...]] is true, and 0 otherwise
+// a, b and other... must be 0 or 1
+func (api *Uint521API) And(a, b Uint521, other ...Uint521) Uint521 {
+	res := api.g.And(api.isTrue(a), api.isTrue(b))
+	for _, v := range other {
+		res = api.g.And(res, api.isTrue(v))
+	}
+	return api.fromBits(res)
+}
+
+// Or returns 1 if a || b [|| other[0] [|| other[1]...]] is true, and 0 otherwise
+// a, b and other... must be 0 or 1
+func (api *Uint521API) Or(a, b Uint521, other ...Uint521) Uint521 {
+	res := api.g.Or(api.isTrue(a), api.isTrue(b))
+	for _, v := range other {
+		res = api.g.Or(res, api.isTrue(v))
+	}
+	return api.fromBits(res)
+}
+
+// Not returns 1 if a is 0, and 0 if a is 1. The user must make sure a is either
+// 0 or 1
+func (api *Uint521API) Not(a Uint521) Uint521 {
+	return api.fromBits(api.IsZero(a).Val)
+}
+
+// BitwiseAnd returns the bitwise AND of a and b
+func (api *Uint521API) BitwiseAnd(a, b Uint521) Uint521 {
+	return api.bitwise(a, b, api.g.And)
+}
+
+// BitwiseOr returns the bitwise OR of a and b. 2^521 - 1 is the modulus, so a
+// result with all 521 bits set is 0
+func (api *Uint521API) BitwiseOr(a, b Uint521) Uint521 {
+	return api.bitwise(a, b, api.g.Or)
+}
+
+// BitwiseXor returns the bitwise XOR of a and b. 2^521 - 1 is the modulus, so a
+// result with all 521 bits set is 0
+func (api *Uint521API) BitwiseXor(a, b Uint521) Uint521 {
+	return api.bitwise(a, b, api.g.Xor)
+}
+
+// Lsh returns a << n. Bits shifted beyond the 521-bit width are discarded
+func (api *Uint521API) Lsh(a Uint521, n int) Uint521 {
+	if n <= 0 {
+		return a
+	}
+	bits := api.bits(a)
+	res := make([]frontend.Variable, len(bits))
+	for i := range res {
+		if i < n {
+			res[i] = 0
+		} else {
+			res[i] = bits[i-n]
+		}
+	}
+	return api.fromBits(res...)
+}
+
+// Rsh returns a >> n
+func (api *Uint521API) Rsh(a Uint521, n int) Uint521 {
+	if n <= 0 {
+		return a
+	}
+	bits := api.bits(a)
+	if n >= len(bits) {
+		return api.fromBits(0)
+	}
+	return api.fromBits(bits[n:]...)
+}
+
+// Mask returns the lowest n bits of a, i.e. a & (2^n - 1)
+func (api *Uint521API) Mask(a Uint521, n int) Uint521 {
+	bits := api.bits(a)
+	if n <= 0 {
+		return api.fromBits(0)
+	}
+	return api.fromBits(bits[:min(n, len(bits))]...)
+}
+
 // Select returns a if s == 1, and b if s == 0
 func (api *Uint521API) Select(s Uint248, a, b Uint521) Uint521 {
 	api.g.AssertIsBoolean(s.Val)
@@ -160,3 +296,106 @@ func (api *Uint521API) AssertIsLessOrEqual(a, b Uint521) {
 	_b := api.f.Reduce(b.Element)
 	api.f.AssertIsLessOrEqual(_a, _b)
 }
+
+// AssertIsDifferent asserts a != b
+func (api *Uint521API) AssertIsDifferent(a, b Uint521) {
+	api.g.AssertIsEqual(api.IsEqual(a, b).Val, 0)
+}
+
+// canonicalLimbs returns the little-endian limbs of v reduced to the range
+// [0, 2^521-1)
+func (api *Uint521API) canonicalLimbs(v Uint521) []frontend.Variable {
+	reduced := api.f.Reduce(v.Element)
+	api.f.AssertIsInRange(reduced)
+	return reduced.Limbs
+}
+
+// bits returns the 521 little-endian bits of the canonical form of v
+func (api *Uint521API) bits(v Uint521) []frontend.Variable {
+	f := Uint521Field{}
+	limbs := api.canonicalLimbs(v)
+	bits := make([]frontend.Variable, 0, 521)
+	for i, limb := range limbs {
+		nbBits := min(int(f.BitsPerLimb()), 521-i*int(f.BitsPerLimb()))
+		bits = append(bits, api.g.ToBinary(limb, nbBits)...)
+	}
+	return bits
+}
+
+func (api *Uint521API) fromBits(bits ...frontend.Variable) Uint521 {
+	return newU521(api.f.FromBits(bits...))
+}
+
+// bitwise applies op to each pair of bits of a and b
+func (api *Uint521API) bitwise(a, b Uint521, op func(x, y frontend.Variable) frontend.Variable) Uint521 {
+	aBits, bBits := api.bits(a), api.bits(b)
+	res := make([]frontend.Variable, len(aBits))
+	for i := range res {
+		res[i] = op(aBits[i], bBits[i])
+	}
+	return api.fromBits(res...)
+}
+
+// isTrue returns 1 if v != 0, and 0 otherwise
+func (api *Uint521API) isTrue(v Uint521) frontend.Variable {
+	return api.g.Sub(1, api.IsZero(v).Val)
+}
+
+// cmp returns whether a < b and whether a == b
+func (api *Uint521API) cmp(a, b Uint521) (lt, eq frontend.Variable) {
+	ka, kb := flipByGroups(api.canonicalLimbs(a), 1), flipByGroups(api.canonicalLimbs(b), 1)
+	return cmpOrderedKeys(api.g, ka, kb, int(Uint521Field{}.BitsPerLimb()))
+}
+
+// assertMulAdd asserts q * b + r == a over the integers, where all inputs are
+// little-endian limbs of at most BitsPerLimb bits. The product is computed
+// column by column in the native field, and the carries between columns are
+// range checked so that no column can wrap around the native modulus.
+func (api *Uint521API) assertMulAdd(q, b, r, a []frontend.Variable) {
+	g := api.g
+	limbBits := int(Uint521Field{}.BitsPerLimb())
+	base := new(big.Int).Lsh(big.NewInt(1), uint(limbBits))
+	n := len(q)
+	rangeChecker := rangecheck.New(g)
+	var carry frontend.Variable = 0
+	for k := 0; k < 2*n-1; k++ {
+		col := carry
+		for i := 0; i < n; i++ {
+			if j := k - i; j >= 0 && j < n {
+				col = g.Add(col, g.Mul(q[i], b[j]))
+			}
+		}
+		if k < n {
+			col = g.Sub(g.Add(col, r[k]), a[k])
+		}
+		out, err := g.Compiler().NewHint(QuoRemHint, 2, col, base)
+		if err != nil {
+			panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+		}
+		// a column is less than n * 2^(2*limbBits) plus the previous carry
+		carry = out[0]
+		rangeChecker.Check(carry, limbBits+8)
+		g.AssertIsEqual(g.Mul(carry, base), col)
+	}
+	g.AssertIsEqual(carry, 0)
+}
+
+// quoRem computes a / b with a quotient of at most quoBits bits. Uses
+// Uint521QuoRemHint. The caller must make sure that b < 2^(520-quoBits) so that
+// q * b + r cannot wrap around the modulus. Proving fails if the actual
+// quotient does not fit in quoBits bits.
+func (api *Uint521API) quoRem(a, b Uint521, quoBits int) (quotient, remainder Uint521) {
+	out, err := api.f.NewHint(Uint521QuoRemHint, 2, a.Element, b.Element)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize Uint521QuoRemHint instance: %s", err.Error()))
+	}
+	q, r := newU521(out[0]), newU521(out[1])
+	// bounds q, hence q * b + r is computed without wrapping around
+	api.ToBinary(q, quoBits)
+	// r < b, comparison requires b in its canonical form
+	reducedB := api.f.Reduce(b.Element)
+	api.f.AssertIsInRange(reducedB)
+	api.AssertIsLessOrEqual(api.Add(r, ConstUint521(1)), newU521(reducedB))
+	api.AssertIsEqual(api.Add(api.Mul(q, b), r), a)
+	return q, r
+}
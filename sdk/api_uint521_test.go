@@ -54,3 +54,95 @@ func (c *TestUint521APICircuit) Define(g frontend.API) error {
 
 	return nil
 }
+
+func TestUint521Arithmetics(t *testing.T) {
+	p := Uint521Field{}.Modulus()
+	a := new(big.Int).Sub(p, big.NewInt(12345))                                   // close to 2^521
+	b, _ := new(big.Int).SetString("340282366920938463463374607431768211507", 10) // ~2^128
+	c := &TestUint521ArithmeticsCircuit{
+		A: ConstUint521(a),
+		B: ConstUint521(b),
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	for _, op := range []string{"div0", "narrow"} {
+		c.Op = op
+		err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+		if err == nil {
+			t.Errorf("expected %s to fail", op)
+		}
+	}
+}
+
+type TestUint521ArithmeticsCircuit struct {
+	A, B Uint521
+	Op   string
+}
+
+func (c *TestUint521ArithmeticsCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	p := Uint521Field{}.Modulus()
+	u521 := api.Uint521
+	a, _ := new(big.Int).SetString(c.A.String(), 10)
+	b, _ := new(big.Int).SetString(c.B.String(), 10)
+
+	q, r := u521.Div(c.A, c.B)
+	_q, _r := new(big.Int).QuoRem(a, b, new(big.Int))
+	u521.AssertIsEqual(q, ConstUint521(_q))
+	u521.AssertIsEqual(r, ConstUint521(_r))
+	// quotient larger than the divisor
+	q, r = u521.Div(c.B, ConstUint521(7))
+	u521.AssertIsEqual(q, ConstUint521(new(big.Int).Quo(b, big.NewInt(7))))
+	u521.AssertIsEqual(r, ConstUint521(new(big.Int).Rem(b, big.NewInt(7))))
+	q, r = u521.Div(c.B, c.A)
+	u521.AssertIsEqual(q, ConstUint521(0))
+	u521.AssertIsEqual(r, c.B)
+
+	u521.AssertIsEqual(u521.Sqrt(c.A), ConstUint521(new(big.Int).Sqrt(a)))
+	u521.AssertIsEqual(u521.Sqrt(c.B), ConstUint521(new(big.Int).Sqrt(b)))
+	u521.AssertIsEqual(u521.Sqrt(ConstUint521(0)), ConstUint521(0))
+	u521.AssertIsEqual(u521.Sqrt(ConstUint521(24)), ConstUint521(4))
+	u521.AssertIsEqual(u521.Sqrt(ConstUint521(25)), ConstUint521(5))
+
+	api.Uint248.AssertIsEqual(u521.IsLessThan(c.B, c.A), ConstUint248(1))
+	api.Uint248.AssertIsEqual(u521.IsLessThan(c.A, c.B), ConstUint248(0))
+	api.Uint248.AssertIsEqual(u521.IsLessThan(c.A, c.A), ConstUint248(0))
+	api.Uint248.AssertIsEqual(u521.IsGreaterThan(c.A, c.B), ConstUint248(1))
+	api.Uint248.AssertIsEqual(u521.IsZero(c.A), ConstUint248(0))
+	api.Uint248.AssertIsEqual(u521.IsZero(u521.Sub(c.A, c.A)), ConstUint248(1))
+	u521.AssertIsDifferent(c.A, c.B)
+	api.Uint248.AssertIsEqual(api.ToUint248(c.B), ConstUint248(b))
+
+	// bits above 2^248 and across the limbs of 96 bits
+	x, _ := new(big.Int).SetString("1b3c5d7e9f0a1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2d3e4f5061728394a5b6c7d8e9f", 16)
+	y, _ := new(big.Int).SetString("f0e1d2c3b4a5968778695a4b3c2d1e0ff0e1d2c3b4a5968778695a4b3c2d1e0ff0e1d2c3b4a5", 16)
+	_x, _y := ConstUint521(x), ConstUint521(y)
+	u521.AssertIsEqual(u521.BitwiseAnd(_x, _y), ConstUint521(new(big.Int).And(x, y)))
+	u521.AssertIsEqual(u521.BitwiseOr(_x, _y), ConstUint521(new(big.Int).Or(x, y)))
+	u521.AssertIsEqual(u521.BitwiseXor(_x, _y), ConstUint521(new(big.Int).Xor(x, y)))
+	u521.AssertIsEqual(u521.Lsh(_x, 100), ConstUint521(new(big.Int).Lsh(x, 100)))
+	u521.AssertIsEqual(u521.Lsh(c.A, 1), ConstUint521(new(big.Int).And(new(big.Int).Lsh(a, 1), p)))
+	u521.AssertIsEqual(u521.Lsh(_x, 521), ConstUint521(0))
+	u521.AssertIsEqual(u521.Rsh(_y, 97), ConstUint521(new(big.Int).Rsh(y, 97)))
+	u521.AssertIsEqual(u521.Rsh(c.A, 520), ConstUint521(1))
+	u521.AssertIsEqual(u521.Rsh(_y, 521), ConstUint521(0))
+	u521.AssertIsEqual(u521.Mask(_y, 200), ConstUint521(new(big.Int).And(y, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 200), big.NewInt(1)))))
+	u521.AssertIsEqual(u521.Mask(_y, 521), _y)
+	u521.AssertIsEqual(u521.Mask(_y, 0), ConstUint521(0))
+	one, zero := ConstUint521(1), ConstUint521(0)
+	u521.AssertIsEqual(u521.And(one, one, one), one)
+	u521.AssertIsEqual(u521.And(one, zero), zero)
+	u521.AssertIsEqual(u521.Or(zero, zero, one), one)
+	u521.AssertIsEqual(u521.Or(zero, zero), zero)
+	u521.AssertIsEqual(u521.Not(zero), one)
+	u521.AssertIsEqual(u521.Not(one), zero)
+
+	switch c.Op {
+	case "div0":
+		u521.Div(c.A, ConstUint521(0))
+	case "narrow":
+		api.ToUint248(c.A)
+	}
+	return nil
+}
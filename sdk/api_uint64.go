@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/rangecheck"
@@ -181,6 +182,42 @@ func (api *Uint64API) Not(a Uint64) Uint64 {
 	return api.IsZero(a)
 }
 
+// BitwiseAnd returns the bitwise AND of a and b
+func (api *Uint64API) BitwiseAnd(a, b Uint64) Uint64 {
+	return newU64(bitwise(api.g, a.Val, b.Val, 64, api.g.And))
+}
+
+// BitwiseOr returns the bitwise OR of a and b
+func (api *Uint64API) BitwiseOr(a, b Uint64) Uint64 {
+	return newU64(bitwise(api.g, a.Val, b.Val, 64, api.g.Or))
+}
+
+// BitwiseXor returns the bitwise XOR of a and b
+func (api *Uint64API) BitwiseXor(a, b Uint64) Uint64 {
+	return newU64(bitwise(api.g, a.Val, b.Val, 64, api.g.Xor))
+}
+
+// BitwiseNot returns the bitwise NOT of a, i.e. 2^64 - 1 - a
+func (api *Uint64API) BitwiseNot(a Uint64) Uint64 {
+	rangecheck.New(api.g).Check(a.Val, 64)
+	return newU64(api.g.Sub(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)), a.Val))
+}
+
+// Lsh returns a << n. Bits shifted beyond the 64-bit width are discarded
+func (api *Uint64API) Lsh(a Uint64, n int) Uint64 {
+	return newU64(shiftLeft(api.g, a.Val, n, 64))
+}
+
+// Rsh returns a >> n
+func (api *Uint64API) Rsh(a Uint64, n int) Uint64 {
+	return newU64(shiftRight(api.g, a.Val, n, 64))
+}
+
+// Mask returns the lowest n bits of a, i.e. a & (2^n - 1)
+func (api *Uint64API) Mask(a Uint64, n int) Uint64 {
+	return newU64(mask(api.g, a.Val, n, 64))
+}
+
 // Select returns a if s == 1, and b if s == 0
 func (api *Uint64API) Select(s Uint64, a, b Uint64) Uint64 {
 	api.g.AssertIsBoolean(s.Val)
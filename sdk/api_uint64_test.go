@@ -29,6 +29,7 @@ func (c *TestUint64APICircuit) Define(g frontend.API) error {
 	c.testArithmetic()
 	c.testComparisons()
 	c.testLogical()
+	c.testBitwise()
 
 	return nil
 }
@@ -114,3 +115,20 @@ func (c *TestUint64APICircuit) testLogical() {
 	c.g.AssertIsEqual(c.u64.Not(one).Val, 0)
 	c.g.AssertIsEqual(c.u64.Not(zero).Val, 1)
 }
+
+func (c *TestUint64APICircuit) testBitwise() {
+	a, b := new(big.Int).SetUint64(0x8123456789abcdef), testUint64_2
+	u64 := c.u64
+	c.g.AssertIsEqual(u64.BitwiseAnd(ConstUint64(a), ConstUint64(b)).Val, new(big.Int).And(a, b))
+	c.g.AssertIsEqual(u64.BitwiseOr(ConstUint64(a), ConstUint64(b)).Val, new(big.Int).Or(a, b))
+	c.g.AssertIsEqual(u64.BitwiseXor(ConstUint64(a), ConstUint64(b)).Val, new(big.Int).Xor(a, b))
+	ones := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1))
+	c.g.AssertIsEqual(u64.BitwiseNot(ConstUint64(a)).Val, new(big.Int).Xor(a, ones))
+	// shifted out bits are discarded
+	c.g.AssertIsEqual(u64.Lsh(ConstUint64(a), 8).Val, new(big.Int).And(new(big.Int).Lsh(a, 8), ones))
+	c.g.AssertIsEqual(u64.Lsh(ConstUint64(a), 64).Val, 0)
+	c.g.AssertIsEqual(u64.Rsh(ConstUint64(a), 8).Val, new(big.Int).Rsh(a, 8))
+	c.g.AssertIsEqual(u64.Rsh(ConstUint64(a), 0).Val, a)
+	c.g.AssertIsEqual(u64.Mask(ConstUint64(a), 12).Val, new(big.Int).And(a, big.NewInt(0xfff)))
+	c.g.AssertIsEqual(u64.Mask(ConstUint64(a), 64).Val, a)
+}
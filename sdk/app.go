@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/big"
 	"path/filepath"
+	"sync"
 	"time"
 
 	commonutils "github.com/brevis-network/brevis-sdk/common/utils"
@@ -86,7 +87,38 @@ type TransactionData struct {
 	BlockTimestamp uint64      `json:"block_timestamp,omitempty"` // Optional value
 }
 
-type rawData[T ReceiptData | StorageData | TransactionData] struct {
+// AccountData is the state of an account at a block, i.e. the fields of
+// eth_getProof without the proofs
+type AccountData struct {
+	BlockNum       *big.Int       `json:"block_num,omitempty"`       // Required value
+	BlockBaseFee   *big.Int       `json:"block_base_fee,omitempty"`  // Optional value
+	Address        common.Address `json:"address,omitempty"`         // Required value
+	Nonce          uint64         `json:"nonce,omitempty"`           // Optional value
+	Balance        *big.Int       `json:"balance,omitempty"`         // Optional value
+	CodeHash       common.Hash    `json:"code_hash,omitempty"`       // Optional value
+	StorageRoot    common.Hash    `json:"storage_root,omitempty"`    // Optional value
+	BlockTimestamp uint64         `json:"block_timestamp,omitempty"` // Optional value
+}
+
+// BlockHeaderData is a subset of the fields of a block header
+type BlockHeaderData struct {
+	BlockNum       *big.Int       `json:"block_num,omitempty"`       // Required value
+	BlockBaseFee   *big.Int       `json:"block_base_fee,omitempty"`  // Optional value
+	Hash           common.Hash    `json:"hash,omitempty"`            // Optional value
+	ParentHash     common.Hash    `json:"parent_hash,omitempty"`     // Optional value
+	Coinbase       common.Address `json:"coinbase,omitempty"`        // Optional value
+	GasUsed        uint64         `json:"gas_used,omitempty"`        // Optional value
+	GasLimit       uint64         `json:"gas_limit,omitempty"`       // Optional value
+	PrevRandao     common.Hash    `json:"prev_randao,omitempty"`     // Optional value
+	BlockTimestamp uint64         `json:"block_timestamp,omitempty"` // Optional value
+}
+
+// queryData is the data of a query added to a BrevisApp
+type queryData interface {
+	ReceiptData | StorageData | TransactionData | AccountData | BlockHeaderData
+}
+
+type rawData[T queryData] struct {
 	ordered []T
 	special map[int]T
 }
@@ -108,6 +140,15 @@ func (q *rawData[T]) add(data T, index ...int) {
 	}
 }
 
+// all returns the pinned and the ordered data
+func (q *rawData[T]) all() []T {
+	var l []T
+	for _, e := range q.special {
+		l = append(l, e)
+	}
+	return append(l, q.ordered...)
+}
+
 func (q *rawData[T]) list(max int) []T {
 	var l []T
 	ordered := q.ordered
@@ -128,6 +169,36 @@ type BrevisAppConfig struct {
 	GatewayUrl string `mapstructure:"gateway_url" json:"gateway_url"`
 	OutDir     string `mapstructure:"out_dir" json:"out_dir"`
 
+	// RpcEndpoints are the failovers of RpcUrl, tried in order when the previous
+	// endpoints fail or don't have the data yet. RpcUrl can be left empty to use
+	// only these endpoints, e.g. to rate limit all of them.
+	RpcEndpoints []RpcEndpoint `mapstructure:"rpc_endpoints" json:"rpc_endpoints"`
+
+	// RpcQuorum is the number of endpoints that must return the same receipt or
+	// storage value before it is used. Defaults to 1, i.e. no cross-checking
+	RpcQuorum int `mapstructure:"rpc_quorum" json:"rpc_quorum"`
+
+	// VerifyFetchedData verifies the receipts, txs, storage values and account
+	// states fetched from the RPC against the roots of their block headers, so
+	// that bad data fails the input building with ErrDataVerification instead
+	// of the proof. It costs extra RPC calls, e.g. the receipts of the whole
+	// block of each receipt.
+	VerifyFetchedData bool `mapstructure:"verify_fetched_data" json:"verify_fetched_data"`
+
+	// ExperimentalAccountsAndHeaders allows app circuits to allocate accounts
+	// and block headers, see AccountAllocator. They are only committed by the
+	// app circuit, not proven by brevis, so such circuits cannot be sent to
+	// brevis gateway.
+	ExperimentalAccountsAndHeaders bool `mapstructure:"experimental_accounts_and_headers" json:"experimental_accounts_and_headers"`
+
+	// CacheFinality decides when the data cached in the dataStore can no longer
+	// be reorged. Defaults to the "finalized" block of the RPC
+	CacheFinality FinalityPolicy `mapstructure:"cache_finality" json:"cache_finality"`
+
+	// RpcCallTimeout bounds each RPC call, e.g. "30s" in a config file. A call
+	// that times out fails over to the next endpoint. No timeout if 0
+	RpcCallTimeout time.Duration `mapstructure:"rpc_call_timeout" json:"rpc_call_timeout"`
+
 	// Persistence type, currently supporting "syncmap", "file", "badgerdb" and "s3".
 	// Defaults to "file" under {outDir}/input
 	PersistenceType string `mapstructure:"persistence_type" json:"persistence_type"`
@@ -135,8 +206,17 @@ type BrevisAppConfig struct {
 	// Persistence options as JSON string. See implementations for details.
 	PersistenceOptions string `mapstructure:"persistence_options" json:"persistence_options"`
 
-	// ConcurrentFetchLimit limits the number of concurrent on-chain fetches
+	// ConcurrentFetchLimit limits the number of concurrent on-chain fetches. The
+	// limit is shared by all the RPC requests of the app and of the apps created
+	// from it with NewBrevisAppFromExisting
 	ConcurrentFetchLimit int `mapstructure:"concurrent_fetch_limit" json:"concurrent_fetch_limit"`
+
+	// FetchRateLimit limits the RPC requests of the app and of the apps created
+	// from it to FetchRateLimit per second in total, in bursts of up to
+	// FetchBurst requests. A batch request counts as one. No limit if 0. See
+	// RpcEndpoint.RateLimit for the limit of each endpoint
+	FetchRateLimit float64 `mapstructure:"fetch_rate_limit" json:"fetch_rate_limit"`
+	FetchBurst     int     `mapstructure:"fetch_burst" json:"fetch_burst"`
 }
 
 // BrevisHashInfo contains Brevis circuit hashes
@@ -200,39 +280,90 @@ type BrevisApp struct {
 	receipts    rawData[ReceiptData]
 	storageVals rawData[StorageData]
 	txs         rawData[TransactionData]
+	accounts    rawData[AccountData]
+	headers     rawData[BlockHeaderData]
 
 	mockReceipts rawData[ReceiptData]
 	mockStorage  rawData[StorageData]
 	mockTxs      rawData[TransactionData]
+	mockAccounts rawData[AccountData]
+	mockHeaders  rawData[BlockHeaderData]
 
 	concurrentFetchLimit int
 
+	// The RPC endpoints of the source chain, in failover order. ec is the first one
+	rpcEndpoints []*rpcEndpoint
+	rpcQuorum    int
+
+	// Verifies the fetched data against the roots of the block headers
+	verifyFetchedData bool
+
+	// When the cached data can no longer be reorged, see FinalityPolicy
+	cacheFinality FinalityPolicy
+
+	// Allows app circuits to allocate accounts and block headers
+	experimentalAccountsAndHeaders bool
+
+	// Bounds each RPC call if not 0
+	rpcCallTimeout time.Duration
+
+	// Schedules the RPC requests, shared with the apps created from this one
+	scheduler *fetchScheduler
+
+	// The dummy input commitments given by the gateway, or by the query bundle
+	dummyInput *gwproto.CircuitDummyInputResponse
+	// The allocation the app circuit must have if the app is imported from a
+	// query bundle
+	bundleAllocation *QueryBundleAllocation
+
+	// Fetches on-chain data in batches and caches it, see chain()
+	fetcher     *chainFetcher
+	fetcherOnce sync.Once
+
 	// Persists data to reduce the number of RPC queries
 	dataStore gokv.Store
 
 	*BrevisHashInfo
 
 	// cache fields
-	circuitInput                    CircuitInput
-	buildInputCalled                bool
-	queryId                         []byte
-	nonce                           uint64
-	srcChainId, dstChainId          uint64
-	maxReceipts, maxStorage, maxTxs int
-	dataPoints                      int
+	circuitInput                                             CircuitInput
+	buildInputCalled                                         bool
+	queryId                                                  []byte
+	nonce                                                    uint64
+	srcChainId, dstChainId                                   uint64
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders int
+	dataPoints                                               int
 }
 
 // NewBrevisAppWithConfig creates a BrevisApp with specified configs
 func NewBrevisAppWithConfig(config *BrevisAppConfig) (*BrevisApp, error) {
-	return newBrevisApp(
+	app, err := newBrevisApp(
 		config.SrcChainId,
-		config.RpcUrl,
+		rpcEndpoints(config.RpcUrl, config.RpcEndpoints),
+		config.RpcQuorum,
 		config.OutDir,
 		config.PersistenceType,
 		config.PersistenceOptions,
 		config.ConcurrentFetchLimit,
+		config.FetchRateLimit,
+		config.FetchBurst,
 		config.GatewayUrl,
 	)
+	if err != nil {
+		return nil, err
+	}
+	app.verifyFetchedData = config.VerifyFetchedData
+	app.experimentalAccountsAndHeaders = config.ExperimentalAccountsAndHeaders
+	if app.verifyFetchedData {
+		for _, caps := range app.RpcCapabilities() {
+			if !caps.GetProof {
+				log.Warnf("rpc %s doesn't support eth_getProof, which the verification of storage and accounts needs", caps.Url)
+			}
+		}
+	}
+	app.cacheFinality = config.CacheFinality
+	app.rpcCallTimeout = config.RpcCallTimeout
+	return app, nil
 }
 
 // NewBrevisApp returns a BrevisApp with local file persistence under {outDir}/input
@@ -247,25 +378,23 @@ func NewBrevisApp(
 	if len(gatewayUrlOverride) != 0 {
 		gatewayUrl = gatewayUrlOverride[0]
 	}
-	return newBrevisApp(srcChainId, rpcUrl, outDir, "", "", 0, gatewayUrl)
+	return newBrevisApp(srcChainId, rpcEndpoints(rpcUrl, nil), 0, outDir, "", "", 0, 0, 0, gatewayUrl)
 }
 
 func newBrevisApp(
-	srcChainId uint64, rpcUrl string, outDir string, persistenceType string, persistenceOptions string,
-	concurrentFetchLimit int, gatewayUrlOverride string,
+	srcChainId uint64, endpointConfigs []RpcEndpoint, rpcQuorum int, outDir string, persistenceType string, persistenceOptions string,
+	concurrentFetchLimit int, fetchRateLimit float64, fetchBurst int, gatewayUrlOverride string,
 ) (*BrevisApp, error) {
-	ec, err := ethclient.Dial(rpcUrl)
-	if err != nil {
-		return nil, fmt.Errorf("ethclient.Dial rpcUrl: %s err: %w", rpcUrl, err)
+	if concurrentFetchLimit <= 0 {
+		concurrentFetchLimit = defaultConcurrentFetchLimit
 	}
-
-	chainId, err := ec.ChainID(context.Background())
+	scheduler := newFetchScheduler(concurrentFetchLimit, fetchRateLimit, fetchBurst)
+	endpoints, err := dialRPCEndpoints(srcChainId, endpointConfigs, scheduler)
 	if err != nil {
-		return nil, fmt.Errorf("ec.ChainID %d err: %w", chainId, err)
+		return nil, err
 	}
-
-	if srcChainId != chainId.Uint64() {
-		return nil, fmt.Errorf("invalid src chain id %d rpcUrl %s pair", srcChainId, rpcUrl)
+	if rpcQuorum > len(endpoints) {
+		return nil, fmt.Errorf("rpc quorum %d is larger than the number of rpc endpoints %d", rpcQuorum, len(endpoints))
 	}
 	var gc *GatewayClient
 	if gatewayUrlOverride == "" {
@@ -293,9 +422,6 @@ func newBrevisApp(
 		return nil, fmt.Errorf("invalid circuit digest hashes number of limbs: %d", len(resp.HashesLimbs))
 	}
 
-	if concurrentFetchLimit <= 0 {
-		concurrentFetchLimit = defaultConcurrentFetchLimit
-	}
 	// Setup dataStore, defaults to "file" under {outDir}/input
 	if persistenceType == "" {
 		persistenceType = "file"
@@ -315,13 +441,18 @@ func newBrevisApp(
 
 	return &BrevisApp{
 		gc:                   gc,
-		ec:                   ec,
+		ec:                   endpoints[0].ec,
+		rpcEndpoints:         endpoints,
+		rpcQuorum:            rpcQuorum,
 		brevisRequest:        br,
 		srcChainId:           srcChainId,
 		receipts:             rawData[ReceiptData]{},
 		storageVals:          rawData[StorageData]{},
 		txs:                  rawData[TransactionData]{},
+		accounts:             rawData[AccountData]{},
+		headers:              rawData[BlockHeaderData]{},
 		concurrentFetchLimit: concurrentFetchLimit,
+		scheduler:            scheduler,
 		dataStore:            dataStore,
 		BrevisHashInfo: &BrevisHashInfo{
 			P2AggRecursionLeafCircuitDigestHash:                 &pgoldilocks.HashOut256{resp.HashesLimbs[0], resp.HashesLimbs[1], resp.HashesLimbs[2], resp.HashesLimbs[3]},
@@ -344,16 +475,25 @@ func newBrevisApp(
 // Best used when the dependencies are shared across multiple BrevisApp instances
 func NewBrevisAppFromExisting(existing *BrevisApp) (*BrevisApp, error) {
 	return &BrevisApp{
-		gc:                   existing.gc,
-		ec:                   existing.ec,
-		brevisRequest:        existing.brevisRequest,
-		srcChainId:           existing.srcChainId,
-		receipts:             rawData[ReceiptData]{},
-		storageVals:          rawData[StorageData]{},
-		txs:                  rawData[TransactionData]{},
-		dataStore:            existing.dataStore,
-		concurrentFetchLimit: existing.concurrentFetchLimit,
-		BrevisHashInfo:       existing.BrevisHashInfo,
+		gc:                             existing.gc,
+		ec:                             existing.ec,
+		rpcEndpoints:                   existing.rpcEndpoints,
+		rpcQuorum:                      existing.rpcQuorum,
+		verifyFetchedData:              existing.verifyFetchedData,
+		experimentalAccountsAndHeaders: existing.experimentalAccountsAndHeaders,
+		cacheFinality:                  existing.cacheFinality,
+		rpcCallTimeout:                 existing.rpcCallTimeout,
+		brevisRequest:                  existing.brevisRequest,
+		srcChainId:                     existing.srcChainId,
+		receipts:                       rawData[ReceiptData]{},
+		storageVals:                    rawData[StorageData]{},
+		txs:                            rawData[TransactionData]{},
+		accounts:                       rawData[AccountData]{},
+		headers:                        rawData[BlockHeaderData]{},
+		dataStore:                      existing.dataStore,
+		concurrentFetchLimit:           existing.concurrentFetchLimit,
+		scheduler:                      existing.scheduler,
+		BrevisHashInfo:                 existing.BrevisHashInfo,
 	}, nil
 }
 
@@ -467,15 +607,60 @@ func (q *BrevisApp) AddMockTransaction(data TransactionData, index ...int) {
 	q.mockTxs.add(data, index...)
 }
 
+// AddAccount adds the AccountData to be queried. If an index is specified,
+// the data will be assigned to the specified index of DataInput.Accounts.
+// Accounts are experimental and only available to circuits implementing
+// AccountAllocator, see BrevisAppConfig.ExperimentalAccountsAndHeaders.
+func (q *BrevisApp) AddAccount(data AccountData, index ...int) {
+	if data.BlockNum == nil {
+		panic(fmt.Sprintf("account data block num missing: %+v", data))
+	}
+	q.accounts.add(data, index...)
+}
+
+// AddMockAccount adds the MockAccount to be queried. If an index is
+// specified, the data will be assigned to the specified index of
+// DataInput.Accounts.
+// It should be used ONLY for circuit implementation and testing.
+func (q *BrevisApp) AddMockAccount(data AccountData, index ...int) {
+	q.mockAccounts.add(data, index...)
+}
+
+// AddBlockHeader adds the BlockHeaderData to be queried. If an index is
+// specified, the data will be assigned to the specified index of
+// DataInput.BlockHeaders. Block headers are experimental and only available to
+// circuits implementing BlockHeaderAllocator, see
+// BrevisAppConfig.ExperimentalAccountsAndHeaders.
+func (q *BrevisApp) AddBlockHeader(data BlockHeaderData, index ...int) {
+	if data.BlockNum == nil {
+		panic(fmt.Sprintf("block header data block num missing: %+v", data))
+	}
+	q.headers.add(data, index...)
+}
+
+// AddMockBlockHeader adds the MockBlockHeader to be queried. If an index is
+// specified, the data will be assigned to the specified index of
+// DataInput.BlockHeaders.
+// It should be used ONLY for circuit implementation and testing.
+func (q *BrevisApp) AddMockBlockHeader(data BlockHeaderData, index ...int) {
+	q.mockHeaders.add(data, index...)
+}
+
 // BuildCircuitInput executes all added queries and package the query results
-// into circuit assignment (the DataInput struct) The provided ctx is used
-// when performing network calls to the provided blockchain RPC.
+// into circuit assignment (the DataInput struct)
 func (q *BrevisApp) BuildCircuitInput(app AppCircuit) (CircuitInput, error) {
-	in, err := q.BuildCircuitInputStage1(app)
+	return q.BuildCircuitInputCtx(context.Background(), app)
+}
+
+// BuildCircuitInputCtx is BuildCircuitInput with a context. The provided ctx is
+// used when performing network calls to the provided blockchain RPC, the input
+// building stops once it's done.
+func (q *BrevisApp) BuildCircuitInputCtx(ctx context.Context, app AppCircuit) (CircuitInput, error) {
+	in, err := q.BuildCircuitInputStage1Ctx(ctx, app)
 	if err != nil {
 		return CircuitInput{}, fmt.Errorf("BuildCircuitInputStage1 err: %w", err)
 	}
-	in, err = q.BuildCircuitInputStage2(app, in)
+	in, err = q.BuildCircuitInputStage2Ctx(ctx, app, in)
 	if err != nil {
 		return CircuitInput{}, fmt.Errorf("BuildCircuitInputStage2 err: %w", err)
 	}
@@ -483,20 +668,34 @@ func (q *BrevisApp) BuildCircuitInput(app AppCircuit) (CircuitInput, error) {
 }
 
 // BuildCircuitInputStage1 assigns the number of data points, sets toggles and adds mock data if specified.
-// This does not involve on-chain queries.
+// This does not involve on-chain queries, except for the mock transactions.
 func (q *BrevisApp) BuildCircuitInputStage1(app AppCircuit) (CircuitInput, error) {
-	q.maxReceipts, q.maxStorage, q.maxTxs = app.Allocate()
+	return q.BuildCircuitInputStage1Ctx(context.Background(), app)
+}
+
+// BuildCircuitInputStage1Ctx is BuildCircuitInputStage1 with a context that
+// bounds the queries of the mock transactions.
+func (q *BrevisApp) BuildCircuitInputStage1Ctx(ctx context.Context, app AppCircuit) (CircuitInput, error) {
+	q.maxReceipts, q.maxStorage, q.maxTxs, q.maxAccounts, q.maxHeaders = AppAllocation(app)
 	err := q.checkAllocations(app)
 	if err != nil {
 		return CircuitInput{}, err
 	}
 
-	q.dataPoints = DataPointsNextPowerOf2(q.maxReceipts + q.maxStorage + q.maxTxs)
-	in := defaultCircuitInput(q.maxReceipts, q.maxStorage, q.maxTxs, q.dataPoints)
+	q.dataPoints = DataPointsNextPowerOf2(q.maxReceipts + q.maxStorage + q.maxTxs + q.maxAccounts + q.maxHeaders)
+	if b := q.bundleAllocation; b != nil && *b != (QueryBundleAllocation{
+		MaxReceipts: q.maxReceipts, MaxStorage: q.maxStorage, MaxTxs: q.maxTxs,
+		MaxAccounts: q.maxAccounts, MaxHeaders: q.maxHeaders, DataPoints: q.dataPoints,
+	}) {
+		return CircuitInput{}, fmt.Errorf("the app circuit allocation doesn't match the allocation %+v of the query bundle", *b)
+	}
+	in := defaultCircuitInput(q.maxReceipts, q.maxStorage, q.maxTxs, q.maxAccounts, q.maxHeaders, q.dataPoints)
 
 	q.setReceiptsToggles(&in)
 	q.setStorageSlotsToggles(&in)
 	q.setTransactionsToggles(&in)
+	setToggles(q.accounts, in.Accounts.Toggles)
+	setToggles(q.headers, in.BlockHeaders.Toggles)
 
 	if q.realDataLength() > 0 && q.mockDataLength() > 0 {
 		return CircuitInput{}, fmt.Errorf("you cannot add real data and mock data at the same time")
@@ -513,11 +712,23 @@ func (q *BrevisApp) BuildCircuitInputStage1(app AppCircuit) (CircuitInput, error
 	}
 
 	// transaction
-	err = q.assignMockTransactions(&in)
+	err = q.assignMockTransactions(ctx, &in)
 	if err != nil {
 		return buildCircuitInputErr("failed to assign in from transaction queries", err)
 	}
 
+	// account
+	err = assignMockData(q.mockAccounts, in.Accounts, q.buildMockAccount)
+	if err != nil {
+		return buildCircuitInputErr("failed to assign in from account queries", err)
+	}
+
+	// block header
+	err = assignMockData(q.mockHeaders, in.BlockHeaders, q.buildMockBlockHeader)
+	if err != nil {
+		return buildCircuitInputErr("failed to assign in from block header queries", err)
+	}
+
 	return in, nil
 }
 
@@ -525,11 +736,19 @@ func (q *BrevisApp) BuildCircuitInputStage1(app AppCircuit) (CircuitInput, error
 // This involves on-chain queries, gateway query and dry-run so should preferably be deferred.
 // NOTE: "in" needs to be the CircuitInput returned from BuildCircuitInputStage1.
 func (q *BrevisApp) BuildCircuitInputStage2(app AppCircuit, in CircuitInput) (CircuitInput, error) {
-	var errG errgroup.Group
+	return q.BuildCircuitInputStage2Ctx(context.Background(), app, in)
+}
+
+// BuildCircuitInputStage2Ctx is BuildCircuitInputStage2 with a context that
+// bounds the on-chain queries. The first failed query cancels the others.
+func (q *BrevisApp) BuildCircuitInputStage2Ctx(ctx context.Context, app AppCircuit, in CircuitInput) (CircuitInput, error) {
+	q.prefetchQueryData(ctx)
+
+	errG, ctx := errgroup.WithContext(ctx)
 	errG.SetLimit(q.concurrentFetchLimit)
 	// receipt
 	errG.Go(func() error {
-		err := q.assignReceipts(&in)
+		err := q.assignReceipts(ctx, &in)
 		if err != nil {
 			return fmt.Errorf("failed to assign in from receipt queries: %w", err)
 		}
@@ -538,7 +757,7 @@ func (q *BrevisApp) BuildCircuitInputStage2(app AppCircuit, in CircuitInput) (Ci
 
 	// storage
 	errG.Go(func() error {
-		err := q.assignStorageSlots(&in)
+		err := q.assignStorageSlots(ctx, &in)
 		if err != nil {
 			return fmt.Errorf("failed to assign in from storage queries: %w", err)
 		}
@@ -547,27 +766,50 @@ func (q *BrevisApp) BuildCircuitInputStage2(app AppCircuit, in CircuitInput) (Ci
 
 	// transaction
 	errG.Go(func() error {
-		err := q.assignTransactions(&in)
+		err := q.assignTransactions(ctx, &in)
 		if err != nil {
 			return fmt.Errorf("failed to assign in from transaction queries: %w", err)
 		}
 		return nil
 	})
 
+	// account
+	errG.Go(func() error {
+		err := assignData(ctx, q.concurrentFetchLimit, q.accounts, in.Accounts.Raw, q.buildAccount)
+		if err != nil {
+			return fmt.Errorf("failed to assign in from account queries: %w", err)
+		}
+		return nil
+	})
+
+	// block header
+	errG.Go(func() error {
+		err := assignData(ctx, q.concurrentFetchLimit, q.headers, in.BlockHeaders.Raw, q.buildBlockHeader)
+		if err != nil {
+			return fmt.Errorf("failed to assign in from block header queries: %w", err)
+		}
+		return nil
+	})
+
 	err := errG.Wait()
 	if err != nil {
 		return buildCircuitInputErr("failed to build input", err)
 	}
 
-	dummyResponse, err := q.gc.GetCircuitDummyInput(&gwproto.CircuitDummyInputRequest{
-		ChainId: q.srcChainId,
-	})
-	if err != nil || dummyResponse == nil {
-		return buildCircuitInputErr("failed to get dummy information from brevis gateway", err)
-	}
-	if dummyResponse.Err != nil || len(dummyResponse.Receipt) == 0 ||
-		len(dummyResponse.Storage) == 0 || len(dummyResponse.Tx) == 0 {
-		return CircuitInput{}, fmt.Errorf("failed to get dummy information from brevis gateway: %s", dummyResponse.Err.Msg)
+	dummyResponse := q.dummyInput
+	if dummyResponse == nil {
+		dummyResponse, err = q.gc.GetCircuitDummyInput(&gwproto.CircuitDummyInputRequest{
+			ChainId: q.srcChainId,
+		})
+		if err != nil || dummyResponse == nil {
+			return buildCircuitInputErr("failed to get dummy information from brevis gateway", err)
+		}
+		if dummyResponse.Err != nil || len(dummyResponse.Receipt) == 0 ||
+			len(dummyResponse.Storage) == 0 || len(dummyResponse.Tx) == 0 {
+			return CircuitInput{}, fmt.Errorf("failed to get dummy information from brevis gateway: %s", dummyResponse.Err.Msg)
+		}
+		// kept for ExportQueryBundle
+		q.dummyInput = dummyResponse
 	}
 
 	// 1. mimc hash data at each position to generate and assign input commitments and toggles commitment
@@ -608,6 +850,9 @@ func (q *BrevisApp) PrepareRequest(
 	if !q.buildInputCalled {
 		panic("must call BuildCircuitInput before PrepareRequest")
 	}
+	if err = q.checkGatewaySupport(); err != nil {
+		return
+	}
 	if len(apiKey) > 0 {
 		fmt.Println("Use Brevis Partner Flow to PrepareRequest...")
 		return q.prepareQueryForBrevisPartnerFlow(
@@ -807,6 +1052,9 @@ func (q *BrevisApp) prepareQueryForBrevisPartnerFlow(
 	if !q.buildInputCalled {
 		panic("must call BuildCircuitInput before PrepareRequest")
 	}
+	if err = q.checkGatewaySupport(); err != nil {
+		return
+	}
 	q.srcChainId = srcChainId
 	q.dstChainId = dstChainId
 
@@ -891,6 +1139,9 @@ func (q *BrevisApp) GenerateProtoQuery(
 	if q.mockDataLength() > 0 {
 		panic("you cannot use mock data to generate proto query")
 	}
+	if err := q.checkGatewaySupport(); err != nil {
+		return nil, err
+	}
 	appCircuitInfo, err := buildAppCircuitInfo(q.circuitInput, q.maxReceipts, q.maxStorage, q.maxTxs, vk, witness)
 	if err != nil {
 		return nil, err
@@ -945,8 +1196,26 @@ func (q *BrevisApp) SendBatchQuery(
 	return
 }
 
+// checkGatewaySupport returns an error if the allocation of the app circuit
+// cannot be proven by the brevis gateway. The gateway takes all the data points
+// after the receipts and storage for txs, so an app circuit that allocates
+// accounts or block headers is not supported even without such queries.
+func (q *BrevisApp) checkGatewaySupport() error {
+	if q.maxAccounts > 0 {
+		return fmt.Errorf("app circuits allocating accounts are not supported by brevis gateway yet, %d allocated", q.maxAccounts)
+	}
+	if q.maxHeaders > 0 {
+		return fmt.Errorf("app circuits allocating block headers are not supported by brevis gateway yet, %d allocated", q.maxHeaders)
+	}
+	return nil
+}
+
 func (q *BrevisApp) checkAllocations(cb AppCircuit) error {
-	maxReceipts, maxSlots, maxTxs := cb.Allocate()
+	maxReceipts, maxSlots, maxTxs, maxAccounts, maxHeaders := AppAllocation(cb)
+	if (maxAccounts > 0 || maxHeaders > 0) && !q.experimentalAccountsAndHeaders {
+		return fmt.Errorf("app circuits allocating accounts or block headers are experimental and need "+
+			"BrevisAppConfig.ExperimentalAccountsAndHeaders, %d accounts and %d block headers allocated", maxAccounts, maxHeaders)
+	}
 
 	numReceipts := len(q.receipts.special) + len(q.receipts.ordered)
 	if maxReceipts%32 != 0 {
@@ -984,9 +1253,33 @@ func (q *BrevisApp) checkAllocations(cb AppCircuit) error {
 	if numTxs > maxTxs {
 		return allocationLenErr("transaction", numTxs, maxTxs)
 	}
+	numAccounts := len(q.accounts.special) + len(q.accounts.ordered)
+	if maxAccounts%32 != 0 {
+		return allocationMultipleErr("account", maxAccounts)
+	}
+	for index := range q.accounts.special {
+		if index >= maxAccounts {
+			return allocationIndexErr("account", index, maxAccounts)
+		}
+	}
+	if numAccounts > maxAccounts {
+		return allocationLenErr("account", numAccounts, maxAccounts)
+	}
+	numHeaders := len(q.headers.special) + len(q.headers.ordered)
+	if maxHeaders%32 != 0 {
+		return allocationMultipleErr("block header", maxHeaders)
+	}
+	for index := range q.headers.special {
+		if index >= maxHeaders {
+			return allocationIndexErr("block header", index, maxHeaders)
+		}
+	}
+	if numHeaders > maxHeaders {
+		return allocationLenErr("block header", numHeaders, maxHeaders)
+	}
 
-	if maxReceipts == 0 && maxSlots == 0 && maxTxs == 0 {
-		return fmt.Errorf("no receipts, slots and txs used in circuit")
+	if maxReceipts == 0 && maxSlots == 0 && maxTxs == 0 && maxAccounts == 0 && maxHeaders == 0 {
+		return fmt.Errorf("no receipts, slots, txs, accounts and block headers used in circuit")
 	}
 	return nil
 }
@@ -1081,6 +1374,11 @@ func (q *BrevisApp) assignInputCommitment(w *CircuitInput, dummyInputCommitment
 		j++
 	}
 
+	// accounts and block headers are not proven by the aggregation service so
+	// there is no dummy of them from brevis gateway. see HostCircuit.commitInput
+	j = assignLocalInputCommitments(hasher, w, leafs, j, w.Accounts, localDummyInputCommitment(defaultAccount()))
+	j = assignLocalInputCommitments(hasher, w, leafs, j, w.BlockHeaders, localDummyInputCommitment(defaultBlockHeader()))
+
 	for i := j; i < q.dataPoints; i++ {
 		w.InputCommitments[i] = ticData
 		leafs[i] = new(big.Int).SetBytes(ticData)
@@ -1092,6 +1390,28 @@ func (q *BrevisApp) assignInputCommitment(w *CircuitInput, dummyInputCommitment
 	}
 }
 
+// assignLocalInputCommitments assigns the input commitments of points from
+// index j and returns the index after them. The toggled off ones are assigned
+// dummy.
+func assignLocalInputCommitments[T localData](hasher *utils.PoseidonBn254Hasher, w *CircuitInput, leafs []*big.Int, j int,
+	points DataPoints[T], dummy *big.Int) int {
+	for i, d := range points.Raw {
+		if fromInterface(points.Toggles[i]).Sign() != 0 {
+			result, err := doHash(hasher, d.goPack())
+			if err != nil {
+				panic(fmt.Sprintf("failed to hash %s: %s", d.String(), err.Error()))
+			}
+			w.InputCommitments[j] = result
+			leafs[j] = result
+		} else {
+			w.InputCommitments[j] = dummy
+			leafs[j] = dummy
+		}
+		j++
+	}
+	return j
+}
+
 func DoHashWithPoseidonBn254(packed []*big.Int) (*big.Int, error) {
 	hasher := utils.NewPoseidonBn254()
 	return DoHash(hasher, packed)
@@ -1193,8 +1513,8 @@ func (q *BrevisApp) setReceiptsToggles(in *CircuitInput) {
 	}
 }
 
-func (q *BrevisApp) assignReceipts(in *CircuitInput) error {
-	var errG errgroup.Group
+func (q *BrevisApp) assignReceipts(ctx context.Context, in *CircuitInput) error {
+	errG, ctx := errgroup.WithContext(ctx)
 	errG.SetLimit(q.concurrentFetchLimit)
 	processedIndices := make(map[int]bool)
 	// assigning user appointed receipts at specific indices
@@ -1204,7 +1524,7 @@ func (q *BrevisApp) assignReceipts(in *CircuitInput) error {
 		processedIndices[index] = true
 
 		errG.Go(func() error {
-			receipt, err := q.buildReceipt(receiptData)
+			receipt, err := q.buildReceipt(ctx, receiptData)
 			if err != nil {
 				return err
 			}
@@ -1224,7 +1544,7 @@ func (q *BrevisApp) assignReceipts(in *CircuitInput) error {
 
 		index := j
 		errG.Go(func() error {
-			receipt, err := q.buildReceipt(receiptData)
+			receipt, err := q.buildReceipt(ctx, receiptData)
 			if err != nil {
 				return err
 			}
@@ -1238,29 +1558,44 @@ func (q *BrevisApp) assignReceipts(in *CircuitInput) error {
 }
 
 func (q *BrevisApp) BuildReceipt(t ReceiptData) (Receipt, error) {
-	return q.buildReceipt(t)
+	return q.BuildReceiptCtx(context.Background(), t)
 }
 
-func (q *BrevisApp) buildReceipt(r ReceiptData) (Receipt, error) {
-	key := generateReceiptKey(r, q.srcChainId)
-	var data ReceiptData
-	ok, err := q.dataStore.Get(key, &data)
+// BuildReceiptCtx is BuildReceipt with a context that bounds the on-chain fetches
+func (q *BrevisApp) BuildReceiptCtx(ctx context.Context, t ReceiptData) (Receipt, error) {
+	return q.buildReceipt(ctx, t)
+}
+
+func (q *BrevisApp) buildReceipt(ctx context.Context, r ReceiptData) (Receipt, error) {
+	data, err := q.resolveReceipt(ctx, r)
 	if err != nil {
-		// log error and continue
-		// TODO: Debug
-		log.Errorf("dataStore Get key: %s, err: %s", key, err)
+		return Receipt{}, err
+	}
+	return convertReceiptDataToReceipt(&data), nil
+}
+
+// resolveReceipt returns the data of the query with all its fields, from the
+// dataStore if cached
+func (q *BrevisApp) resolveReceipt(ctx context.Context, r ReceiptData) (ReceiptData, error) {
+	if err := ctx.Err(); err != nil {
+		return ReceiptData{}, err
 	}
-	if !ok || err != nil {
+	key := generateReceiptKey(r, q.srcChainId)
+	var data ReceiptData
+	ok := q.getCachedData(ctx, key, &data, func() *big.Int { return data.BlockNum })
+	if !ok {
+		// the data given by the user is not tagged with its block
+		var fetchedBlock *big.Int
 		if r.isReadyToSave() {
 			data = r
 		} else {
-			receiptInfo, mptKey, blockNum, baseFee, time, err := q.getReceiptInfos(r.TxHash)
+			receiptInfo, mptKey, blockNum, baseFee, time, err := q.getReceiptInfos(ctx, r.TxHash)
 			if err != nil {
-				return Receipt{}, err
+				return ReceiptData{}, err
 			}
 			fields, err := buildLogFieldsData(r.Fields, receiptInfo)
 			if err != nil {
-				return Receipt{}, err
+				return ReceiptData{}, err
 			}
 
 			data = ReceiptData{
@@ -1271,16 +1606,11 @@ func (q *BrevisApp) buildReceipt(r ReceiptData) (Receipt, error) {
 				Fields:         fields,
 				BlockTimestamp: time,
 			}
+			fetchedBlock = data.BlockNum
 		}
-		err = q.dataStore.Set(key, &data)
-		if err != nil {
-			// log error and continue
-			// TODO: Debug
-			log.Errorf("dataStore Set key: %s, err: %s", key, err)
-			q.dataStore.Delete(key)
-		}
+		q.setCachedData(ctx, key, &data, fetchedBlock)
 	}
-	return convertReceiptDataToReceipt(&data), nil
+	return data, nil
 }
 
 func (q *BrevisApp) setStorageSlotsToggles(in *CircuitInput) {
@@ -1297,8 +1627,8 @@ func (q *BrevisApp) setStorageSlotsToggles(in *CircuitInput) {
 	}
 }
 
-func (q *BrevisApp) assignStorageSlots(in *CircuitInput) error {
-	var errG errgroup.Group
+func (q *BrevisApp) assignStorageSlots(ctx context.Context, in *CircuitInput) error {
+	errG, ctx := errgroup.WithContext(ctx)
 	errG.SetLimit(q.concurrentFetchLimit)
 	processedIndices := make(map[int]bool)
 	// assigning user appointed data at specific indices
@@ -1308,7 +1638,7 @@ func (q *BrevisApp) assignStorageSlots(in *CircuitInput) error {
 		processedIndices[index] = true
 
 		errG.Go(func() error {
-			storage, err := q.buildStorageSlot(storageData)
+			storage, err := q.buildStorageSlot(ctx, storageData)
 			if err != nil {
 				return err
 			}
@@ -1328,7 +1658,7 @@ func (q *BrevisApp) assignStorageSlots(in *CircuitInput) error {
 
 		index := j
 		errG.Go(func() error {
-			storage, err := q.buildStorageSlot(storageData)
+			storage, err := q.buildStorageSlot(ctx, storageData)
 			if err != nil {
 				return err
 			}
@@ -1342,30 +1672,45 @@ func (q *BrevisApp) assignStorageSlots(in *CircuitInput) error {
 }
 
 func (q *BrevisApp) BuildStorageSlot(s StorageData) (StorageSlot, error) {
-	return q.buildStorageSlot(s)
+	return q.BuildStorageSlotCtx(context.Background(), s)
 }
 
-func (q *BrevisApp) buildStorageSlot(s StorageData) (StorageSlot, error) {
-	key := generateStorageKey(s, q.srcChainId)
-	var data StorageData
-	ok, err := q.dataStore.Get(key, &data)
+// BuildStorageSlotCtx is BuildStorageSlot with a context that bounds the on-chain fetches
+func (q *BrevisApp) BuildStorageSlotCtx(ctx context.Context, s StorageData) (StorageSlot, error) {
+	return q.buildStorageSlot(ctx, s)
+}
+
+func (q *BrevisApp) buildStorageSlot(ctx context.Context, s StorageData) (StorageSlot, error) {
+	data, err := q.resolveStorageSlot(ctx, s)
 	if err != nil {
-		// log error and continue
-		// TODO: Debug
-		log.Errorf("dataStore Get key: %s, err: %s", key, err)
+		return StorageSlot{}, err
 	}
-	if !ok || err != nil {
+	return convertStorageDataToStorage(&data), nil
+}
+
+// resolveStorageSlot returns the data of the query with all its fields, from the
+// dataStore if cached
+func (q *BrevisApp) resolveStorageSlot(ctx context.Context, s StorageData) (StorageData, error) {
+	if err := ctx.Err(); err != nil {
+		return StorageData{}, err
+	}
+	key := generateStorageKey(s, q.srcChainId)
+	var data StorageData
+	ok := q.getCachedData(ctx, key, &data, func() *big.Int { return data.BlockNum })
+	if !ok {
+		// the data given by the user is not tagged with its block
+		var fetchedBlock *big.Int
 		if s.isReadyToSave() {
 			data = s
 		} else {
-			baseFee, time, err := q.getBlockInfo(s.BlockNum)
+			baseFee, time, err := q.getBlockInfo(ctx, s.BlockNum)
 			if err != nil {
-				return StorageSlot{}, err
+				return StorageData{}, err
 			}
 
-			value, err := q.getStorageValue(s.BlockNum, s.Address, s.Slot)
+			value, err := q.getStorageValue(ctx, s.BlockNum, s.Address, s.Slot)
 			if err != nil {
-				return StorageSlot{}, err
+				return StorageData{}, err
 			}
 
 			data = StorageData{
@@ -1376,16 +1721,11 @@ func (q *BrevisApp) buildStorageSlot(s StorageData) (StorageSlot, error) {
 				Value:          value,
 				BlockTimestamp: time,
 			}
+			fetchedBlock = data.BlockNum
 		}
-		err = q.dataStore.Set(key, &data)
-		if err != nil {
-			// log error and continue
-			// TODO: Debug
-			log.Errorf("dataStore Set key: %s, err: %s", key, err)
-			q.dataStore.Delete(key)
-		}
+		q.setCachedData(ctx, key, &data, fetchedBlock)
 	}
-	return convertStorageDataToStorage(&data), nil
+	return data, nil
 }
 
 func (q *BrevisApp) setTransactionsToggles(in *CircuitInput) {
@@ -1402,8 +1742,8 @@ func (q *BrevisApp) setTransactionsToggles(in *CircuitInput) {
 	}
 }
 
-func (q *BrevisApp) assignTransactions(in *CircuitInput) error {
-	var errG errgroup.Group
+func (q *BrevisApp) assignTransactions(ctx context.Context, in *CircuitInput) error {
+	errG, ctx := errgroup.WithContext(ctx)
 	errG.SetLimit(q.concurrentFetchLimit)
 	processedIndices := make(map[int]bool)
 	// assigning user appointed data at specific indices
@@ -1413,7 +1753,7 @@ func (q *BrevisApp) assignTransactions(in *CircuitInput) error {
 		processedIndices[index] = true
 
 		errG.Go(func() error {
-			tx, err := q.buildTx(txData)
+			tx, err := q.buildTx(ctx, txData)
 			if err != nil {
 				return err
 			}
@@ -1432,7 +1772,7 @@ func (q *BrevisApp) assignTransactions(in *CircuitInput) error {
 
 		index := j
 		errG.Go(func() error {
-			tx, err := q.buildTx(txData)
+			tx, err := q.buildTx(ctx, txData)
 			if err != nil {
 				return err
 			}
@@ -1442,29 +1782,44 @@ func (q *BrevisApp) assignTransactions(in *CircuitInput) error {
 		j++
 	}
 
-	return nil
+	return errG.Wait()
 }
 
 func (q *BrevisApp) BuildTx(t TransactionData) (Transaction, error) {
-	return q.buildTx(t)
+	return q.BuildTxCtx(context.Background(), t)
 }
 
-func (q *BrevisApp) buildTx(t TransactionData) (Transaction, error) {
-	key := generateTxKey(t, q.srcChainId)
-	var data TransactionData
-	ok, err := q.dataStore.Get(key, &data)
+// BuildTxCtx is BuildTx with a context that bounds the on-chain fetches
+func (q *BrevisApp) BuildTxCtx(ctx context.Context, t TransactionData) (Transaction, error) {
+	return q.buildTx(ctx, t)
+}
+
+func (q *BrevisApp) buildTx(ctx context.Context, t TransactionData) (Transaction, error) {
+	data, err := q.resolveTx(ctx, t)
 	if err != nil {
-		// log error and continue
-		// TODO: Debug
-		log.Errorf("dataStore Get key: %s, err: %s", key, err)
+		return Transaction{}, err
 	}
-	if !ok || err != nil {
+	return convertTxDataToTransaction(&data), nil
+}
+
+// resolveTx returns the data of the query with all its fields, from the
+// dataStore if cached
+func (q *BrevisApp) resolveTx(ctx context.Context, t TransactionData) (TransactionData, error) {
+	if err := ctx.Err(); err != nil {
+		return TransactionData{}, err
+	}
+	key := generateTxKey(t, q.srcChainId)
+	var data TransactionData
+	ok := q.getCachedData(ctx, key, &data, func() *big.Int { return data.BlockNum })
+	if !ok {
+		// the data given by the user is not tagged with its block
+		var fetchedBlock *big.Int
 		if t.isReadyToSave() {
 			data = t
 		} else {
-			leafHash, mptKey, blockNumber, baseFee, time, err := q.calculateTxLeafHashBlockBaseFeeAndMPTKey(t.Hash)
+			leafHash, mptKey, blockNumber, baseFee, time, err := q.calculateTxLeafHashBlockBaseFeeAndMPTKey(ctx, t.Hash)
 			if err != nil {
-				return Transaction{}, err
+				return TransactionData{}, err
 			}
 
 			data = TransactionData{
@@ -1475,16 +1830,183 @@ func (q *BrevisApp) buildTx(t TransactionData) (Transaction, error) {
 				LeafHash:       leafHash,
 				BlockTimestamp: time,
 			}
+			fetchedBlock = data.BlockNum
 		}
-		err = q.dataStore.Set(key, &data)
+		q.setCachedData(ctx, key, &data, fetchedBlock)
+	}
+	return data, nil
+}
+
+// setToggles toggles on the pinned indices of data, then the first free indices
+// for the ordered data
+func setToggles[T queryData](data rawData[T], toggles []frontend.Variable) {
+	for i := range data.special {
+		toggles[i] = 1
+	}
+	j := 0
+	for range data.ordered {
+		for toggles[j] == 1 {
+			j++
+		}
+		toggles[j] = 1
+		j++
+	}
+}
+
+// assignData builds the pinned data at their indices in raw, then the ordered
+// data at the free indices, at most limit at a time
+func assignData[T queryData, R any](ctx context.Context, limit int, data rawData[T], raw []R,
+	build func(context.Context, T) (R, error)) error {
+	errG, ctx := errgroup.WithContext(ctx)
+	errG.SetLimit(limit)
+	processedIndices := make(map[int]bool)
+	// assigning user appointed data at specific indices
+	for i, d := range data.special {
+		index, d := i, d
+		processedIndices[index] = true
+
+		errG.Go(func() error {
+			v, err := build(ctx, d)
+			if err != nil {
+				return err
+			}
+			raw[index] = v
+			return nil
+		})
+	}
+
+	// distribute other data in order to the rest of the unassigned spaces
+	j := 0
+	for _, d := range data.ordered {
+		d := d
+		for processedIndices[j] {
+			j++
+		}
+		processedIndices[j] = true
+
+		index := j
+		errG.Go(func() error {
+			v, err := build(ctx, d)
+			if err != nil {
+				return err
+			}
+			raw[index] = v
+			return nil
+		})
+		j++
+	}
+
+	return errG.Wait()
+}
+
+// resolveData returns the data of the query d at block blkNum with all its
+// fields, from the dataStore if cached. The data given by the user in full is
+// kept as is, otherwise it's fetched by fetch.
+func resolveData[T AccountData | BlockHeaderData, PT interface {
+	*T
+	isReadyToSave() bool
+}](ctx context.Context, q *BrevisApp, key string, d T, blkNum *big.Int, fetch func() (T, error)) (T, error) {
+	var data T
+	if err := ctx.Err(); err != nil {
+		return data, err
+	}
+	if q.getCachedData(ctx, key, &data, func() *big.Int { return blkNum }) {
+		return data, nil
+	}
+	// the data given by the user is not tagged with its block
+	var fetchedBlock *big.Int
+	if PT(&d).isReadyToSave() {
+		data = d
+	} else {
+		var err error
+		if data, err = fetch(); err != nil {
+			return data, err
+		}
+		fetchedBlock = blkNum
+	}
+	q.setCachedData(ctx, key, &data, fetchedBlock)
+	return data, nil
+}
+
+func (q *BrevisApp) BuildAccount(a AccountData) (Account, error) {
+	return q.BuildAccountCtx(context.Background(), a)
+}
+
+// BuildAccountCtx is BuildAccount with a context that bounds the on-chain fetches
+func (q *BrevisApp) BuildAccountCtx(ctx context.Context, a AccountData) (Account, error) {
+	return q.buildAccount(ctx, a)
+}
+
+func (q *BrevisApp) buildAccount(ctx context.Context, a AccountData) (Account, error) {
+	data, err := q.resolveAccount(ctx, a)
+	if err != nil {
+		return Account{}, err
+	}
+	return convertAccountDataToAccount(&data), nil
+}
+
+// resolveAccount returns the data of the query with all its fields, from the
+// dataStore if cached
+func (q *BrevisApp) resolveAccount(ctx context.Context, a AccountData) (AccountData, error) {
+	return resolveData(ctx, q, generateAccountKey(a, q.srcChainId), a, a.BlockNum, func() (AccountData, error) {
+		baseFee, time, err := q.getBlockInfo(ctx, a.BlockNum)
+		if err != nil {
+			return AccountData{}, err
+		}
+		state, err := q.getAccountState(ctx, a.BlockNum, a.Address)
 		if err != nil {
-			// log error and continue
-			// TODO: Debug
-			log.Errorf("dataStore Set key: %s, err: %s", key, err)
-			q.dataStore.Delete(key)
+			return AccountData{}, err
 		}
+		return AccountData{
+			BlockNum:       a.BlockNum,
+			BlockBaseFee:   baseFee,
+			Address:        a.Address,
+			Nonce:          state.Nonce,
+			Balance:        state.Balance,
+			CodeHash:       state.CodeHash,
+			StorageRoot:    state.StorageHash,
+			BlockTimestamp: time,
+		}, nil
+	})
+}
+
+func (q *BrevisApp) BuildBlockHeader(h BlockHeaderData) (BlockHeader, error) {
+	return q.BuildBlockHeaderCtx(context.Background(), h)
+}
+
+// BuildBlockHeaderCtx is BuildBlockHeader with a context that bounds the on-chain fetches
+func (q *BrevisApp) BuildBlockHeaderCtx(ctx context.Context, h BlockHeaderData) (BlockHeader, error) {
+	return q.buildBlockHeader(ctx, h)
+}
+
+func (q *BrevisApp) buildBlockHeader(ctx context.Context, h BlockHeaderData) (BlockHeader, error) {
+	data, err := q.resolveBlockHeader(ctx, h)
+	if err != nil {
+		return BlockHeader{}, err
 	}
-	return convertTxDataToTransaction(&data), nil
+	return convertBlockHeaderDataToBlockHeader(&data), nil
+}
+
+// resolveBlockHeader returns the data of the query with all its fields, from the
+// dataStore if cached
+func (q *BrevisApp) resolveBlockHeader(ctx context.Context, h BlockHeaderData) (BlockHeaderData, error) {
+	return resolveData(ctx, q, generateBlockHeaderKey(h, q.srcChainId), h, h.BlockNum, func() (BlockHeaderData, error) {
+		header, hash, err := q.getBlockHeader(ctx, h.BlockNum)
+		if err != nil {
+			return BlockHeaderData{}, err
+		}
+		return BlockHeaderData{
+			BlockNum:       h.BlockNum,
+			BlockBaseFee:   header.BaseFee,
+			Hash:           hash,
+			ParentHash:     header.ParentHash,
+			Coinbase:       header.Coinbase,
+			GasUsed:        header.GasUsed,
+			GasLimit:       header.GasLimit,
+			PrevRandao:     header.MixDigest,
+			BlockTimestamp: header.Time,
+		}, nil
+	})
 }
 
 func buildCircuitInputErr(m string, err error) (CircuitInput, error) {
@@ -1508,11 +2030,13 @@ func (q *BrevisApp) calculateMPTKeyWithIndex(index int) *big.Int {
 }
 
 func (q *BrevisApp) realDataLength() int {
-	return len(q.receipts.ordered) + len(q.receipts.special) + len(q.storageVals.ordered) + len(q.storageVals.special) + len(q.txs.ordered) + len(q.txs.special)
+	return len(q.receipts.ordered) + len(q.receipts.special) + len(q.storageVals.ordered) + len(q.storageVals.special) + len(q.txs.ordered) + len(q.txs.special) +
+		len(q.accounts.ordered) + len(q.accounts.special) + len(q.headers.ordered) + len(q.headers.special)
 }
 
 func (q *BrevisApp) mockDataLength() int {
-	return len(q.mockReceipts.ordered) + len(q.mockReceipts.special) + len(q.mockStorage.ordered) + len(q.mockStorage.special) + len(q.mockTxs.ordered) + len(q.mockTxs.special)
+	return len(q.mockReceipts.ordered) + len(q.mockReceipts.special) + len(q.mockStorage.ordered) + len(q.mockStorage.special) + len(q.mockTxs.ordered) + len(q.mockTxs.special) +
+		len(q.mockAccounts.ordered) + len(q.mockAccounts.special) + len(q.mockHeaders.ordered) + len(q.mockHeaders.special)
 }
 
 func allocationIndexErr(name string, pinnedIndex, maxCount int) error {
@@ -1520,6 +2044,23 @@ func allocationIndexErr(name string, pinnedIndex, maxCount int) error {
 		pinnedIndex, name, maxCount)
 }
 
+// chain returns the fetcher of the on-chain data of the queries
+func (q *BrevisApp) chain() *chainFetcher {
+	q.fetcherOnce.Do(func() {
+		endpoints := q.rpcEndpoints
+		if len(endpoints) == 0 && q.ec != nil {
+			endpoints = []*rpcEndpoint{{client: q.ec.Client(), ec: q.ec}}
+		}
+		q.fetcher = newChainFetcher(endpoints, q.rpcQuorum)
+		q.fetcher.callTimeout = q.rpcCallTimeout
+		if q.scheduler == nil {
+			q.scheduler = newFetchScheduler(q.concurrentFetchLimit, 0, 0)
+		}
+		q.fetcher.scheduler = q.scheduler
+	})
+	return q.fetcher
+}
+
 func (q *BrevisApp) CloseDataStore() error {
 	return q.dataStore.Close()
 }
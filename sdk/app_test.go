@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/brevis-network/brevis-sdk/sdk/proto/gwproto"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -54,3 +55,36 @@ func TestDataMarshalUnmarshal(t *testing.T) {
 	err = json.Unmarshal(sJson, &storage2)
 	check(err)
 }
+
+type testAccountAllocation struct {
+	testAllocation
+	accounts int
+}
+
+func (a testAccountAllocation) AllocateAccounts() int { return a.accounts }
+
+func TestCheckGatewaySupport(t *testing.T) {
+	c := newTestChain()
+	q, _ := newTestFetchApp(t, c, true)
+	// given by the gateway otherwise
+	q.dummyInput = &gwproto.CircuitDummyInputResponse{Receipt: "0x01", Storage: "0x02", Tx: "0x03"}
+	_, err := q.BuildCircuitInput(testAllocation{receipts: 32, storage: 32, txs: 32})
+	check(err)
+	check(q.checkGatewaySupport())
+
+	// accounts allocated without any account query
+	app := testAccountAllocation{testAllocation{receipts: 32, storage: 32, txs: 32}, 32}
+	if _, err = q.BuildCircuitInput(app); err == nil {
+		t.Error("expected accounts to be rejected unless allowed")
+	}
+	q.experimentalAccountsAndHeaders = true
+	_, err = q.BuildCircuitInput(app)
+	check(err)
+	_, _, _, _, err = q.PrepareRequest(nil, nil, 1, 1, common.Address{}, common.Address{}, 0, nil, "")
+	if err == nil {
+		t.Error("expected the request of an app circuit allocating accounts to be rejected")
+	}
+	if _, err = q.GenerateProtoQuery(nil, nil, nil, common.Address{}); err == nil {
+		t.Error("expected the query of an app circuit allocating accounts to be rejected")
+	}
+}
@@ -22,6 +22,7 @@ type CircuitAPI struct {
 	Bytes32 *Bytes32API
 	Uint32  *Uint32API
 	Uint64  *Uint64API
+	Uint256 *Uint256API
 
 	g                    frontend.API
 	output               []variable `gnark:"-"`
@@ -37,6 +38,7 @@ func NewCircuitAPI(gapi frontend.API) *CircuitAPI {
 		Bytes32: newBytes32API(gapi),
 		Uint32:  newUint32API(gapi),
 		Uint64:  newUint64API(gapi),
+		Uint256: newUint256API(gapi),
 	}
 }
 
@@ -240,11 +242,16 @@ func Select[T CircuitVariable](api *CircuitAPI, s Uint248, a, b T) T {
 }
 
 // ToBytes32 casts the input to a Bytes32 type. Supports Bytes32, Int248,
-// Uint521, and Uint248.
+// Uint521, Uint256, and Uint248.
 func (api *CircuitAPI) ToBytes32(i interface{}) Bytes32 {
 	switch v := i.(type) {
 	case Bytes32:
 		return v
+	case Uint256:
+		// moves the lower 120 bits of the high limb into the low limb of Bytes32
+		hi, mid := splitBits(api.g, v.Val[1], numBitsPerVar-uint256LimbBits, uint256LimbBits)
+		lo := api.g.Add(v.Val[0], api.g.Mul(mid, new(big.Int).Lsh(big.NewInt(1), uint256LimbBits)))
+		return Bytes32{Val: [2]variable{lo, hi}}
 	case Int248:
 		bits := api.Int248.ToBinary(v)
 		sign := bits[len(bits)-1]
@@ -264,11 +271,13 @@ func (api *CircuitAPI) ToBytes32(i interface{}) Bytes32 {
 }
 
 // ToUint521 casts the input to a Uint521 type. Supports Uint521, Bytes32,
-// and Uint248
+// Uint256, and Uint248
 func (api *CircuitAPI) ToUint521(i interface{}) Uint521 {
 	switch v := i.(type) {
 	case Uint521:
 		return v
+	case Uint256:
+		return api.ToUint521(api.ToBytes32(v))
 	case Bytes32:
 		// Recompose the Bytes32 into BigField.NbLimbs limbs
 		bits := v.toBinaryVars(api.g)
@@ -290,12 +299,15 @@ func (api *CircuitAPI) ToUint521(i interface{}) Uint521 {
 }
 
 // ToUint248 casts the input to a Uint248 type. Supports Uint32, Uint64, Uint248, Int248,
-// Bytes32, and Uint521
+// Bytes32, Uint256, and Uint521. Proving fails if a Bytes32, Uint256, or Uint521
+// input does not fit in 248 bits
 // Note that using ToUint248 with negative Int248 results in a wraparound modulo 2^248
 func (api *CircuitAPI) ToUint248(i interface{}) Uint248 {
 	switch v := i.(type) {
 	case Uint248:
 		return v
+	case Uint256:
+		return api.ToUint248(api.ToBytes32(v))
 	case Int248:
 		return newU248(v.Val)
 	case Uint32:
@@ -306,14 +318,40 @@ func (api *CircuitAPI) ToUint248(i interface{}) Uint248 {
 		api.g.AssertIsEqual(v.Val[1], 0)
 		return newU248(v.Val[0])
 	case Uint521:
-		max248 := ConstUint521(MaxUint248)
-		api.Uint521.AssertIsLessOrEqual(v, max248)
+		// ToBinary asserts the bits above numBitsPerVar are all 0
 		bits := api.Uint521.ToBinary(v, numBitsPerVar)
 		return api.Uint248.FromBinary(bits[:numBitsPerVar]...)
 	}
 	panic(fmt.Errorf("unsupported casting from %T to Uint248", i))
 }
 
+// ToUint256 casts the input to a Uint256 type. Supports Uint32, Uint64,
+// Uint248, Int248, Bytes32, Uint256, and Uint521. Int248 values are sign
+// extended like uint256(int256(v)) in solidity. Proving fails if a Uint521 input
+// does not fit in 256 bits
+func (api *CircuitAPI) ToUint256(i interface{}) Uint256 {
+	switch v := i.(type) {
+	case Uint256:
+		return v
+	case Uint32:
+		return newU256(v.Val, 0)
+	case Uint64:
+		return newU256(v.Val, 0)
+	case Uint248:
+		hi, lo := splitBits(api.g, v.Val, uint256LimbBits, numBitsPerVar)
+		return newU256(lo, hi)
+	case Int248:
+		return api.ToUint256(api.ToBytes32(v))
+	case Bytes32:
+		mid, lo := splitBits(api.g, v.Val[0], uint256LimbBits, numBitsPerVar)
+		hi := api.g.Add(mid, api.g.Mul(v.Val[1], new(big.Int).Lsh(big.NewInt(1), uint(numBitsPerVar-uint256LimbBits))))
+		return newU256(lo, hi)
+	case Uint521:
+		return api.Uint256.FromBinary(api.Uint521.ToBinary(v, 256)...)
+	}
+	panic(fmt.Errorf("unsupported casting from %T to Uint256", i))
+}
+
 // ToInt248 casts the input to a Int248 type. Supports Int248, Uint248,
 // and Bytes32
 // Note that Uint248 values with the top bit set will be interpreted as negative values
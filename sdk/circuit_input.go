@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"fmt"
 	"math/big"
 
 	bn254_fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
@@ -13,13 +14,17 @@ type DataInput struct {
 	Receipts     DataPoints[Receipt]
 	StorageSlots DataPoints[StorageSlot]
 	Transactions DataPoints[Transaction]
+	Accounts     DataPoints[Account]
+	BlockHeaders DataPoints[BlockHeader]
 }
 
-func defaultDataInput(maxReceipts, maxStorage, maxTxs int) DataInput {
+func defaultDataInput(maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders int) DataInput {
 	return DataInput{
 		Receipts:     NewDataPoints(maxReceipts, defaultReceipt),
 		StorageSlots: NewDataPoints(maxStorage, defaultStorageSlot),
 		Transactions: NewDataPoints(maxTxs, defaultTransaction),
+		Accounts:     NewDataPoints(maxAccounts, defaultAccount),
+		BlockHeaders: NewDataPoints(maxHeaders, defaultBlockHeader),
 	}
 }
 
@@ -28,7 +33,10 @@ func (d DataInput) Toggles() []frontend.Variable {
 	toggles = append(toggles, d.Receipts.Toggles...)
 	toggles = append(toggles, d.StorageSlots.Toggles...)
 	toggles = append(toggles, d.Transactions.Toggles...)
-	dataPoints := DataPointsNextPowerOf2(len(d.Receipts.Toggles) + len(d.StorageSlots.Toggles) + len(d.Transactions.Toggles))
+	toggles = append(toggles, d.Accounts.Toggles...)
+	toggles = append(toggles, d.BlockHeaders.Toggles...)
+	dataPoints := DataPointsNextPowerOf2(len(d.Receipts.Toggles) + len(d.StorageSlots.Toggles) + len(d.Transactions.Toggles) +
+		len(d.Accounts.Toggles) + len(d.BlockHeaders.Toggles))
 	// pad the reset (the dummy part) with off toggles
 	for i := len(toggles); i < dataPoints; i++ {
 		toggles = append(toggles, 0)
@@ -56,13 +64,13 @@ type CircuitInput struct {
 	dryRunOutput []byte `gnark:"-"`
 }
 
-func defaultCircuitInput(maxReceipts, maxStorage, maxTxs, dataPoints int) CircuitInput {
+func defaultCircuitInput(maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders, dataPoints int) CircuitInput {
 	var inputCommits = make([]frontend.Variable, dataPoints)
 	for i := 0; i < dataPoints; i++ {
 		inputCommits[i] = 0
 	}
 	return CircuitInput{
-		DataInput:                       defaultDataInput(maxReceipts, maxStorage, maxTxs),
+		DataInput:                       defaultDataInput(maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders),
 		InputCommitmentsRoot:            0,
 		InputCommitments:                inputCommits,
 		TogglesCommitment:               0,
@@ -89,6 +97,8 @@ func (in CircuitInput) Clone() CircuitInput {
 			Receipts:     in.Receipts.Clone(),
 			StorageSlots: in.StorageSlots.Clone(),
 			Transactions: in.Transactions.Clone(),
+			Accounts:     in.Accounts.Clone(),
+			BlockHeaders: in.BlockHeaders.Clone(),
 		},
 	}
 }
@@ -112,7 +122,7 @@ func (c OutputCommitment) Hash() common.Hash {
 }
 
 type DataPoints[T any] struct {
-	// Raw is the structured input data (receipts, txs, and slots).
+	// Raw is the structured input data (receipts, txs, slots, accounts, and block headers).
 	Raw []T
 	// Toggles is a bitmap that toggles the effectiveness of each position of Raw.
 	// len(Toggles) must equal len(Raw)
@@ -668,3 +678,302 @@ func (t Transaction) goPack() []*big.Int {
 	bits = append(bits, decomposeBits(fromInterface(t.BlockTimestamp.Val), 8*8)...)
 	return packBitsToInt(bits, bn254_fr.Bits-1)
 }
+
+// Account is the state of an account at a block. Experimental, see
+// AccountAllocator
+type Account struct {
+	BlockNum     Uint32
+	BlockBaseFee Uint248
+
+	// The address of the account
+	Address Uint248
+	// The number of transactions sent from the account, or the number of
+	// contracts created by it if it is a contract
+	Nonce Uint248
+	// The ETH balance of the account in wei
+	Balance Uint248
+	// The keccak256 hash of the account's code. For an EOA, this is the hash of
+	// empty bytes
+	CodeHash Bytes32
+	// The root of the account's storage trie
+	StorageRoot Bytes32
+
+	BlockTimestamp Uint248
+}
+
+func defaultAccount() Account {
+	return Account{
+		BlockNum:       newU32(0),
+		BlockBaseFee:   newU248(0),
+		Address:        newU248(0),
+		Nonce:          newU248(0),
+		Balance:        newU248(0),
+		CodeHash:       ConstFromBigEndianBytes([]byte{}),
+		StorageRoot:    ConstFromBigEndianBytes([]byte{}),
+		BlockTimestamp: newU248(0),
+	}
+}
+
+var _ CircuitVariable = Account{}
+
+func (a Account) Values() []frontend.Variable {
+	var ret []frontend.Variable
+	ret = append(ret, a.BlockNum.Values()...)
+	ret = append(ret, a.BlockBaseFee.Values()...)
+	ret = append(ret, a.Address.Values()...)
+	ret = append(ret, a.Nonce.Values()...)
+	ret = append(ret, a.Balance.Values()...)
+	ret = append(ret, a.CodeHash.Values()...)
+	ret = append(ret, a.StorageRoot.Values()...)
+	ret = append(ret, a.BlockTimestamp.Values()...)
+	return ret
+}
+
+func (a Account) FromValues(vs ...frontend.Variable) CircuitVariable {
+	na := Account{}
+
+	start, end := uint32(0), a.BlockNum.NumVars()
+	na.BlockNum = a.BlockNum.FromValues(vs[start:end]...).(Uint32)
+
+	start, end = end, end+a.BlockBaseFee.NumVars()
+	na.BlockBaseFee = a.BlockBaseFee.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+a.Address.NumVars()
+	na.Address = a.Address.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+a.Nonce.NumVars()
+	na.Nonce = a.Nonce.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+a.Balance.NumVars()
+	na.Balance = a.Balance.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+a.CodeHash.NumVars()
+	na.CodeHash = a.CodeHash.FromValues(vs[start:end]...).(Bytes32)
+
+	start, end = end, end+a.StorageRoot.NumVars()
+	na.StorageRoot = a.StorageRoot.FromValues(vs[start:end]...).(Bytes32)
+
+	start, end = end, end+a.BlockTimestamp.NumVars()
+	na.BlockTimestamp = a.BlockTimestamp.FromValues(vs[start:end]...).(Uint248)
+
+	return na
+}
+
+func (a Account) NumVars() uint32 {
+	return a.BlockNum.NumVars() +
+		a.BlockBaseFee.NumVars() +
+		a.Address.NumVars() +
+		a.Nonce.NumVars() +
+		a.Balance.NumVars() +
+		a.CodeHash.NumVars() +
+		a.StorageRoot.NumVars() +
+		a.BlockTimestamp.NumVars()
+}
+
+func (a Account) String() string { return "Account" }
+
+func (a Account) Pack(api frontend.API) []frontend.Variable {
+	return a.pack(api)
+}
+
+// pack packs the accounts into Bn254 scalars
+// - 4 bytes for block num
+// - 16 bytes for block base fee
+// - 20 bytes for address
+// - 8 bytes for nonce
+// - 16 bytes for balance
+// - 32 bytes for code hash
+// - 32 bytes for storage root
+// - 8 bytes for block timestamp
+func (a Account) pack(api frontend.API) []frontend.Variable {
+	var bits []frontend.Variable
+	bits = append(bits, api.ToBinary(a.BlockNum.Val, 8*4)...)
+	bits = append(bits, api.ToBinary(a.BlockBaseFee.Val, 8*16)...)
+	bits = append(bits, api.ToBinary(a.Address.Val, 8*20)...)
+	bits = append(bits, api.ToBinary(a.Nonce.Val, 8*8)...)
+	bits = append(bits, api.ToBinary(a.Balance.Val, 8*16)...)
+	bits = append(bits, a.CodeHash.toBinaryVars(api)...)
+	bits = append(bits, a.StorageRoot.toBinaryVars(api)...)
+	bits = append(bits, api.ToBinary(a.BlockTimestamp.Val, 8*8)...)
+	return packBitsToFr(api, bits)
+}
+
+func (a Account) GoPack() []*big.Int {
+	return a.goPack()
+}
+
+func (a Account) goPack() []*big.Int {
+	var bits []uint
+	bits = append(bits, decomposeBits(fromInterface(a.BlockNum.Val), 8*4)...)
+	bits = append(bits, decomposeBits(fromInterface(a.BlockBaseFee.Val), 8*16)...)
+	bits = append(bits, decomposeBits(fromInterface(a.Address.Val), 8*20)...)
+	bits = append(bits, decomposeBits(fromInterface(a.Nonce.Val), 8*8)...)
+	bits = append(bits, decomposeBits(fromInterface(a.Balance.Val), 8*16)...)
+	bits = append(bits, a.CodeHash.toBinary()...)
+	bits = append(bits, a.StorageRoot.toBinary()...)
+	bits = append(bits, decomposeBits(fromInterface(a.BlockTimestamp.Val), 8*8)...)
+	return packBitsToInt(bits, bn254_fr.Bits-1)
+}
+
+// localData is a data type that is not proven by the aggregation service, i.e.
+// the accounts and the block headers. Its toggled off data points are
+// committed with the commitment of its default value.
+type localData interface {
+	CircuitVariable
+	pack(api frontend.API) []frontend.Variable
+	goPack() []*big.Int
+}
+
+// localDummyInputCommitment is the input commitment of the toggled off data
+// points of a localData type, i.e. the commitment to its default value empty
+func localDummyInputCommitment(empty localData) *big.Int {
+	commitment, err := DoHashWithPoseidonBn254(empty.goPack())
+	if err != nil {
+		panic(fmt.Errorf("failed to hash dummy %s: %s", empty.String(), err.Error()))
+	}
+	return commitment
+}
+
+// BlockHeader is a subset of the fields of a block header. Experimental, see
+// BlockHeaderAllocator
+type BlockHeader struct {
+	BlockNum     Uint32
+	BlockBaseFee Uint248
+
+	// The hash of the block
+	BlockHash Bytes32
+	// The hash of the parent block
+	ParentHash Bytes32
+	// The address that received the priority fees of the block (aka miner)
+	Coinbase Uint248
+	GasUsed  Uint248
+	GasLimit Uint248
+	// The randomness from the beacon chain (aka mixHash). Only meaningful for
+	// blocks after the merge
+	PrevRandao Bytes32
+
+	BlockTimestamp Uint248
+}
+
+func defaultBlockHeader() BlockHeader {
+	return BlockHeader{
+		BlockNum:       newU32(0),
+		BlockBaseFee:   newU248(0),
+		BlockHash:      ConstFromBigEndianBytes([]byte{}),
+		ParentHash:     ConstFromBigEndianBytes([]byte{}),
+		Coinbase:       newU248(0),
+		GasUsed:        newU248(0),
+		GasLimit:       newU248(0),
+		PrevRandao:     ConstFromBigEndianBytes([]byte{}),
+		BlockTimestamp: newU248(0),
+	}
+}
+
+var _ CircuitVariable = BlockHeader{}
+
+func (h BlockHeader) Values() []frontend.Variable {
+	var ret []frontend.Variable
+	ret = append(ret, h.BlockNum.Values()...)
+	ret = append(ret, h.BlockBaseFee.Values()...)
+	ret = append(ret, h.BlockHash.Values()...)
+	ret = append(ret, h.ParentHash.Values()...)
+	ret = append(ret, h.Coinbase.Values()...)
+	ret = append(ret, h.GasUsed.Values()...)
+	ret = append(ret, h.GasLimit.Values()...)
+	ret = append(ret, h.PrevRandao.Values()...)
+	ret = append(ret, h.BlockTimestamp.Values()...)
+	return ret
+}
+
+func (h BlockHeader) FromValues(vs ...frontend.Variable) CircuitVariable {
+	nh := BlockHeader{}
+
+	start, end := uint32(0), h.BlockNum.NumVars()
+	nh.BlockNum = h.BlockNum.FromValues(vs[start:end]...).(Uint32)
+
+	start, end = end, end+h.BlockBaseFee.NumVars()
+	nh.BlockBaseFee = h.BlockBaseFee.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+h.BlockHash.NumVars()
+	nh.BlockHash = h.BlockHash.FromValues(vs[start:end]...).(Bytes32)
+
+	start, end = end, end+h.ParentHash.NumVars()
+	nh.ParentHash = h.ParentHash.FromValues(vs[start:end]...).(Bytes32)
+
+	start, end = end, end+h.Coinbase.NumVars()
+	nh.Coinbase = h.Coinbase.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+h.GasUsed.NumVars()
+	nh.GasUsed = h.GasUsed.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+h.GasLimit.NumVars()
+	nh.GasLimit = h.GasLimit.FromValues(vs[start:end]...).(Uint248)
+
+	start, end = end, end+h.PrevRandao.NumVars()
+	nh.PrevRandao = h.PrevRandao.FromValues(vs[start:end]...).(Bytes32)
+
+	start, end = end, end+h.BlockTimestamp.NumVars()
+	nh.BlockTimestamp = h.BlockTimestamp.FromValues(vs[start:end]...).(Uint248)
+
+	return nh
+}
+
+func (h BlockHeader) NumVars() uint32 {
+	return h.BlockNum.NumVars() +
+		h.BlockBaseFee.NumVars() +
+		h.BlockHash.NumVars() +
+		h.ParentHash.NumVars() +
+		h.Coinbase.NumVars() +
+		h.GasUsed.NumVars() +
+		h.GasLimit.NumVars() +
+		h.PrevRandao.NumVars() +
+		h.BlockTimestamp.NumVars()
+}
+
+func (h BlockHeader) String() string { return "BlockHeader" }
+
+func (h BlockHeader) Pack(api frontend.API) []frontend.Variable {
+	return h.pack(api)
+}
+
+// pack packs the block headers into Bn254 scalars
+// - 4 bytes for block num
+// - 16 bytes for block base fee
+// - 32 bytes for block hash
+// - 32 bytes for parent hash
+// - 20 bytes for coinbase
+// - 8 bytes for gas used
+// - 8 bytes for gas limit
+// - 32 bytes for prevRandao
+// - 8 bytes for block timestamp
+func (h BlockHeader) pack(api frontend.API) []frontend.Variable {
+	var bits []frontend.Variable
+	bits = append(bits, api.ToBinary(h.BlockNum.Val, 8*4)...)
+	bits = append(bits, api.ToBinary(h.BlockBaseFee.Val, 8*16)...)
+	bits = append(bits, h.BlockHash.toBinaryVars(api)...)
+	bits = append(bits, h.ParentHash.toBinaryVars(api)...)
+	bits = append(bits, api.ToBinary(h.Coinbase.Val, 8*20)...)
+	bits = append(bits, api.ToBinary(h.GasUsed.Val, 8*8)...)
+	bits = append(bits, api.ToBinary(h.GasLimit.Val, 8*8)...)
+	bits = append(bits, h.PrevRandao.toBinaryVars(api)...)
+	bits = append(bits, api.ToBinary(h.BlockTimestamp.Val, 8*8)...)
+	return packBitsToFr(api, bits)
+}
+
+func (h BlockHeader) GoPack() []*big.Int {
+	return h.goPack()
+}
+
+func (h BlockHeader) goPack() []*big.Int {
+	var bits []uint
+	bits = append(bits, decomposeBits(fromInterface(h.BlockNum.Val), 8*4)...)
+	bits = append(bits, decomposeBits(fromInterface(h.BlockBaseFee.Val), 8*16)...)
+	bits = append(bits, h.BlockHash.toBinary()...)
+	bits = append(bits, h.ParentHash.toBinary()...)
+	bits = append(bits, decomposeBits(fromInterface(h.Coinbase.Val), 8*20)...)
+	bits = append(bits, decomposeBits(fromInterface(h.GasUsed.Val), 8*8)...)
+	bits = append(bits, decomposeBits(fromInterface(h.GasLimit.Val), 8*8)...)
+	bits = append(bits, h.PrevRandao.toBinary()...)
+	bits = append(bits, decomposeBits(fromInterface(h.BlockTimestamp.Val), 8*8)...)
+	return packBitsToInt(bits, bn254_fr.Bits-1)
+}
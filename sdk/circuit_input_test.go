@@ -202,6 +202,85 @@ func TestTransactionPack(t *testing.T) {
 	}
 }
 
+type TestAccountPackCircuit struct {
+	Account Account             `gnark:",public"`
+	Packed  []frontend.Variable `gnark:",public"`
+}
+
+func (c *TestAccountPackCircuit) Define(api frontend.API) error {
+	packed := c.Account.pack(api)
+	for i, v := range packed {
+		api.AssertIsEqual(v, c.Packed[i])
+	}
+	return nil
+}
+
+func TestAccountPack(t *testing.T) {
+	acc := Account{
+		BlockNum:       ConstUint32(1234567),
+		BlockBaseFee:   ConstUint248(1),
+		Address:        ConstUint248(common.HexToAddress("0xDEF171Fe48CF0115B1d80b88dc8eAB59176FEe57")),
+		Nonce:          ConstUint248(12),
+		Balance:        ConstUint248(new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))),
+		CodeHash:       ConstFromBigEndianBytes(hexutil.MustDecode("0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470")),
+		StorageRoot:    ConstFromBigEndianBytes(hexutil.MustDecode("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")),
+		BlockTimestamp: ConstUint248(12345),
+	}
+	c := &TestAccountPackCircuit{
+		Account: acc,
+		Packed:  newVars(acc.goPack()),
+	}
+	a := &TestAccountPackCircuit{
+		Account: acc,
+		Packed:  newVars(acc.goPack()),
+	}
+
+	err := test.IsSolved(c, a, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+type TestBlockHeaderPackCircuit struct {
+	Header BlockHeader         `gnark:",public"`
+	Packed []frontend.Variable `gnark:",public"`
+}
+
+func (c *TestBlockHeaderPackCircuit) Define(api frontend.API) error {
+	packed := c.Header.pack(api)
+	for i, v := range packed {
+		api.AssertIsEqual(v, c.Packed[i])
+	}
+	return nil
+}
+
+func TestBlockHeaderPack(t *testing.T) {
+	h := BlockHeader{
+		BlockNum:       ConstUint32(1234567),
+		BlockBaseFee:   ConstUint248(1),
+		BlockHash:      ConstFromBigEndianBytes(hexutil.MustDecode("0x9c2d3d42dcdafb0cb8c10089d02447b96c5fce87f298e50f88f2e188a6afcc41")),
+		ParentHash:     ConstFromBigEndianBytes(hexutil.MustDecode("0xaa4ba4b304228a9d05087e147c9e86d84c708bbbe62bb35b28dab74492f6c726")),
+		Coinbase:       ConstUint248(common.HexToAddress("0x95222290DD7278Aa3Ddd389Cc1E1d165CC4BAfe5")),
+		GasUsed:        ConstUint248(12345678),
+		GasLimit:       ConstUint248(30000000),
+		PrevRandao:     ConstFromBigEndianBytes(hexutil.MustDecode("0x784ba4b304228a9d05087e147c9e86d84c708bbbe62bb35b28dab74492f6c732")),
+		BlockTimestamp: ConstUint248(12345),
+	}
+	c := &TestBlockHeaderPackCircuit{
+		Header: h,
+		Packed: newVars(h.goPack()),
+	}
+	a := &TestBlockHeaderPackCircuit{
+		Header: h,
+		Packed: newVars(h.goPack()),
+	}
+
+	err := test.IsSolved(c, a, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func TestReceiptCircuitVariable(t *testing.T) {
 	r := Receipt{
 		BlockNum:     ConstUint32(1234567),
@@ -262,6 +341,39 @@ func TestTransactionCircuitVariable(t *testing.T) {
 	compareValues(t, values, reconstructed.Values())
 }
 
+func TestAccountCircuitVariable(t *testing.T) {
+	acc := Account{
+		BlockNum:       ConstUint32(1234567),
+		BlockBaseFee:   ConstUint248(1),
+		Address:        ConstUint248(common.HexToAddress("0xDEF171Fe48CF0115B1d80b88dc8eAB59176FEe57")),
+		Nonce:          ConstUint248(12),
+		Balance:        ConstUint248(new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))),
+		CodeHash:       ConstFromBigEndianBytes(hexutil.MustDecode("0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470")),
+		StorageRoot:    ConstFromBigEndianBytes(hexutil.MustDecode("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")),
+		BlockTimestamp: ConstUint248(12345),
+	}
+	values := acc.Values()
+	reconstructed := acc.FromValues(values...)
+	compareValues(t, values, reconstructed.Values())
+}
+
+func TestBlockHeaderCircuitVariable(t *testing.T) {
+	h := BlockHeader{
+		BlockNum:       ConstUint32(1234567),
+		BlockBaseFee:   ConstUint248(1),
+		BlockHash:      ConstFromBigEndianBytes(hexutil.MustDecode("0x9c2d3d42dcdafb0cb8c10089d02447b96c5fce87f298e50f88f2e188a6afcc41")),
+		ParentHash:     ConstFromBigEndianBytes(hexutil.MustDecode("0xaa4ba4b304228a9d05087e147c9e86d84c708bbbe62bb35b28dab74492f6c726")),
+		Coinbase:       ConstUint248(common.HexToAddress("0x95222290DD7278Aa3Ddd389Cc1E1d165CC4BAfe5")),
+		GasUsed:        ConstUint248(12345678),
+		GasLimit:       ConstUint248(30000000),
+		PrevRandao:     ConstFromBigEndianBytes(hexutil.MustDecode("0x784ba4b304228a9d05087e147c9e86d84c708bbbe62bb35b28dab74492f6c732")),
+		BlockTimestamp: ConstUint248(12345),
+	}
+	values := h.Values()
+	reconstructed := h.FromValues(values...)
+	compareValues(t, values, reconstructed.Values())
+}
+
 func compareValues(t *testing.T, a, b []frontend.Variable) {
 	if len(a) != len(b) {
 		t.Errorf("len(a) (%d) != len(b) (%d)", len(a), len(b))
@@ -0,0 +1,283 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/celer-network/goutils/log"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FinalityPolicy decides when a block can no longer be reorged. The data
+// cached in the dataStore is tagged with the hash of the block it's fetched
+// from, and is only used if the block is still canonical, otherwise the cached
+// data of the block is purged and fetched again. Once the block is final and
+// its hash is checked, its data is used as is.
+type FinalityPolicy struct {
+	// BlockTag is the block tag of the RPC whose block and the blocks before it
+	// are final, either "finalized" or "safe". Defaults to "finalized" if
+	// Confirmations is 0
+	BlockTag string `mapstructure:"block_tag" json:"block_tag"`
+	// Confirmations makes a block final once the latest block is Confirmations
+	// blocks after it. Only used if BlockTag is empty
+	Confirmations uint64 `mapstructure:"confirmations" json:"confirmations"`
+}
+
+// blockCacheTag is the hash of a block whose data is cached and the keys of the
+// cached data. Final is whether the hash was the canonical one while the block
+// was final, i.e. the data can no longer be reorged.
+type blockCacheTag struct {
+	Hash  common.Hash `json:"hash"`
+	Keys  []string    `json:"keys"`
+	Final bool        `json:"final,omitempty"`
+}
+
+// guards the read-modify-write of the block cache tags, which can be shared by
+// BrevisApps through their dataStore
+var blockCacheTagsLock sync.Mutex
+
+func generateBlockCacheTagKey(blkNum uint64, srcChainId uint64) string {
+	return fmt.Sprintf("b-%d-%d", srcChainId, blkNum)
+}
+
+// getCachedData gets the data cached at key into v. The data is a miss if the
+// hash of its block, given by blkNum after v is read, isn't the one of the
+// canonical block anymore. The hash is not checked again once it's checked
+// while the block is final.
+func (q *BrevisApp) getCachedData(ctx context.Context, key string, v interface{}, blkNum func() *big.Int) bool {
+	ok, err := q.dataStore.Get(key, v)
+	if err != nil {
+		// log error and continue
+		log.Errorf("dataStore Get key: %s, err: %s", key, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	num := blkNum()
+	if num == nil {
+		return true
+	}
+	tagKey := generateBlockCacheTagKey(num.Uint64(), q.srcChainId)
+	var tag blockCacheTag
+	ok, err = q.dataStore.Get(tagKey, &tag)
+	if err != nil || !ok || !slices.Contains(tag.Keys, key) {
+		// cached without the block hash, e.g. the data given by the user or
+		// cached before the data was tagged, which can't be checked
+		return q.isFinal(ctx, num)
+	}
+	if tag.Final {
+		return true
+	}
+	// the finality is checked before the hash so that the hash is canonical
+	// while the block is final
+	final := q.isFinal(ctx, num)
+	_, hash, err := q.chain().header(ctx, num)
+	if err != nil {
+		// the data can't be fetched again either
+		log.Warnf("cannot get hash of block %d to check cached data %s, using it as is: %s", num, key, err)
+		return true
+	}
+	if hash != tag.Hash {
+		log.Infof("block %d is reorged from %x to %x, purging its cached data", num, tag.Hash, hash)
+		if err = q.purgeBlockCache(num.Uint64()); err != nil {
+			log.Errorf("cannot purge cached data of block %d: %s", num, err)
+		}
+		return false
+	}
+	if final {
+		if err = q.finalizeBlockCache(num.Uint64(), hash); err != nil {
+			log.Errorf("cannot mark cached data of block %d final: %s", num, err)
+		}
+	}
+	return true
+}
+
+// setCachedData caches v at key and tags it with the hash of block blkNum, if
+// not nil
+func (q *BrevisApp) setCachedData(ctx context.Context, key string, v interface{}, blkNum *big.Int) {
+	if blkNum != nil {
+		// the finality is checked before the hash, see getCachedData. It's not
+		// fetched for each data, the data is marked final once it's read if not
+		final := q.chain().knownFinal(blkNum)
+		_, hash, err := q.chain().header(ctx, blkNum)
+		if err != nil {
+			log.Warnf("cannot get hash of block %d, %s is not cached: %s", blkNum, key, err)
+			return
+		}
+		if err = q.tagBlockCache(blkNum.Uint64(), hash, final, key); err != nil {
+			log.Errorf("cannot tag cached data %s with block %d: %s", key, blkNum, err)
+			return
+		}
+	}
+	err := q.dataStore.Set(key, v)
+	if err != nil {
+		// log error and continue
+		log.Errorf("dataStore Set key: %s, err: %s", key, err)
+		q.dataStore.Delete(key)
+	}
+}
+
+// finalizeBlockCache marks the cached data of the block final if it's still of
+// the hash
+func (q *BrevisApp) finalizeBlockCache(blkNum uint64, hash common.Hash) error {
+	blockCacheTagsLock.Lock()
+	defer blockCacheTagsLock.Unlock()
+	tagKey := generateBlockCacheTagKey(blkNum, q.srcChainId)
+	var tag blockCacheTag
+	ok, err := q.dataStore.Get(tagKey, &tag)
+	if err != nil || !ok || tag.Hash != hash || tag.Final {
+		return err
+	}
+	tag.Final = true
+	return q.dataStore.Set(tagKey, &tag)
+}
+
+// tagBlockCache adds key to the cached data of the block, which is final if the
+// hash is checked while the block is final. The cached data of another hash of
+// the block is purged.
+func (q *BrevisApp) tagBlockCache(blkNum uint64, hash common.Hash, final bool, key string) error {
+	blockCacheTagsLock.Lock()
+	defer blockCacheTagsLock.Unlock()
+	tagKey := generateBlockCacheTagKey(blkNum, q.srcChainId)
+	var tag blockCacheTag
+	ok, err := q.dataStore.Get(tagKey, &tag)
+	if err != nil {
+		return err
+	}
+	if ok && tag.Hash != hash {
+		for _, k := range tag.Keys {
+			if err = q.dataStore.Delete(k); err != nil {
+				return err
+			}
+		}
+		ok = false
+	}
+	if !ok {
+		tag = blockCacheTag{Hash: hash}
+	}
+	if slices.Contains(tag.Keys, key) && (tag.Final || !final) {
+		return nil
+	}
+	if !slices.Contains(tag.Keys, key) {
+		tag.Keys = append(tag.Keys, key)
+	}
+	tag.Final = tag.Final || final
+	return q.dataStore.Set(tagKey, &tag)
+}
+
+// PurgeCachedData deletes the data cached in the dataStore that was fetched
+// from the blocks fromBlock to toBlock, both inclusive. Data cached before the
+// data was tagged with its block, or given complete by the user, is not
+// purged.
+func (q *BrevisApp) PurgeCachedData(fromBlock, toBlock uint64) error {
+	if fromBlock > toBlock {
+		return fmt.Errorf("invalid block range %d to %d", fromBlock, toBlock)
+	}
+	for num := fromBlock; num <= toBlock; num++ {
+		if err := q.purgeBlockCache(num); err != nil {
+			return fmt.Errorf("cannot purge cached data of block %d: %w", num, err)
+		}
+	}
+	return nil
+}
+
+func (q *BrevisApp) purgeBlockCache(blkNum uint64) error {
+	blockCacheTagsLock.Lock()
+	defer blockCacheTagsLock.Unlock()
+	tagKey := generateBlockCacheTagKey(blkNum, q.srcChainId)
+	var tag blockCacheTag
+	ok, err := q.dataStore.Get(tagKey, &tag)
+	if err != nil || !ok {
+		return err
+	}
+	for _, k := range tag.Keys {
+		if err = q.dataStore.Delete(k); err != nil {
+			return err
+		}
+	}
+	return q.dataStore.Delete(tagKey)
+}
+
+// isFinal returns whether the block is final by the finality policy of the app.
+// A block is not final if the final block can't be fetched.
+func (q *BrevisApp) isFinal(ctx context.Context, blkNum *big.Int) bool {
+	final, err := q.chain().finalBlock(ctx, q.cacheFinality)
+	if err != nil {
+		return false
+	}
+	return blkNum.Uint64() <= final
+}
+
+// knownFinal returns whether the block is final by the final block fetched
+// last, without fetching it
+func (f *chainFetcher) knownFinal(blkNum *big.Int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.finalAt.IsZero() && blkNum.Uint64() <= f.final
+}
+
+// finalBlock returns the number of the latest final block by the policy. It's
+// fetched again after chainHeadTTL, failures are not cached.
+func (f *chainFetcher) finalBlock(ctx context.Context, policy FinalityPolicy) (uint64, error) {
+	f.mu.Lock()
+	final, at := f.final, f.finalAt
+	f.mu.Unlock()
+	if !at.IsZero() && time.Since(at) < chainHeadTTL {
+		return final, nil
+	}
+	v, err, _ := f.group.Do("final-block", func() (interface{}, error) {
+		final, err := f.fetchFinalBlock(ctx, policy)
+		if err != nil {
+			log.Warnf("cannot get final block, cached data is checked against the canonical block hashes: %s", err)
+			return nil, err
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// the final block never goes back
+		f.final, f.finalAt = max(f.final, final), time.Now()
+		return f.final, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint64), nil
+}
+
+func (f *chainFetcher) fetchFinalBlock(ctx context.Context, policy FinalityPolicy) (uint64, error) {
+	var final uint64
+	if policy.BlockTag == "" && policy.Confirmations > 0 {
+		var latest hexutil.Uint64
+		if err := f.call(ctx, &latest, nil, "eth_blockNumber"); err != nil {
+			return 0, err
+		}
+		if uint64(latest) < policy.Confirmations {
+			return 0, fmt.Errorf("no block has %d confirmations yet", policy.Confirmations)
+		}
+		final = uint64(latest) - policy.Confirmations
+	} else {
+		tag := policy.BlockTag
+		if tag == "" {
+			tag = "finalized"
+		}
+		if tag != "finalized" && tag != "safe" {
+			return 0, fmt.Errorf("invalid finality block tag %s", tag)
+		}
+		var raw json.RawMessage
+		if err := f.call(ctx, &raw, nil, "eth_getBlockByNumber", tag, false); err != nil {
+			return 0, err
+		}
+		h, err := decodeHeader(raw)
+		if err != nil {
+			return 0, err
+		}
+		final = h.header.Number.Uint64()
+	}
+	return final, nil
+}
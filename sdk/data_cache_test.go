@@ -0,0 +1,205 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/brevis-network/brevis-sdk/store"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// reorg replaces block 100 with a block of the same txs but another hash
+func (c *testChain) reorg() {
+	old := c.blocks[100]
+	header := types.CopyHeader(old.Header())
+	header.Extra = append(header.Extra, "reorg"...)
+	c.blocks[100] = types.NewBlockWithHeader(header).WithBody(types.Body{Transactions: old.Transactions()})
+	for _, r := range c.receipts {
+		r.BlockHash = c.blocks[100].Hash()
+	}
+}
+
+// cacheTestHandlers serves a header of number final as the finalized block
+func cacheTestHandlers(c *testChain, final *uint64) map[string]func(params []json.RawMessage) (interface{}, error) {
+	handlers := c.handlers(true)
+	getBlock := handlers["eth_getBlockByNumber"]
+	handlers["eth_getBlockByNumber"] = func(params []json.RawMessage) (interface{}, error) {
+		if string(params[0]) == `"finalized"` {
+			if final == nil {
+				return nil, nil
+			}
+			// only the number of the final block is used
+			return &types.Header{Number: new(big.Int).SetUint64(*final), Difficulty: common.Big0}, nil
+		}
+		return getBlock(params)
+	}
+	return handlers
+}
+
+func TestCachedDataReorg(t *testing.T) {
+	c := newTestChain()
+	dataStore, err := store.InitStore("syncmap", "")
+	check(err)
+	rpc := newFakeRPC(t, cacheTestHandlers(c, nil))
+	newApp := func() *BrevisApp {
+		ec, err := ethclient.Dial(rpc.URL)
+		check(err)
+		return &BrevisApp{ec: ec, dataStore: dataStore, concurrentFetchLimit: 4, srcChainId: 1}
+	}
+	receipt := ReceiptData{TxHash: c.txHash(0), Fields: []LogFieldData{{}}}
+	storage := StorageData{BlockNum: big.NewInt(100), Address: testAccount, Slot: common.Hash{}}
+	build := func() {
+		q := newApp()
+		_, err := q.buildReceipt(context.Background(), receipt)
+		check(err)
+		_, err = q.buildStorageSlot(context.Background(), storage)
+		check(err)
+	}
+
+	build()
+	if n := rpc.count("eth_getTransactionReceipt"); n != 1 {
+		t.Fatalf("expected 1 eth_getTransactionReceipt, got %d", n)
+	}
+	// block 100 is still canonical, the cached data is used
+	build()
+	if rpc.count("eth_getTransactionReceipt") != 1 || rpc.count("eth_getStorageAt") != 1 {
+		t.Error("expected the cached data to be used")
+	}
+
+	// the cached data of the reorged block is fetched again
+	c.reorg()
+	build()
+	if rpc.count("eth_getTransactionReceipt") != 2 || rpc.count("eth_getStorageAt") != 2 {
+		t.Error("expected the data of the reorged block to be fetched again")
+	}
+	var tag blockCacheTag
+	ok, err := dataStore.Get(generateBlockCacheTagKey(100, 1), &tag)
+	check(err)
+	if !ok || tag.Hash != c.blocks[100].Hash() || len(tag.Keys) != 2 {
+		t.Errorf("unexpected block cache tag %+v", tag)
+	}
+}
+
+func TestCachedDataFinality(t *testing.T) {
+	c := newTestChain()
+	dataStore, err := store.InitStore("syncmap", "")
+	check(err)
+	final := uint64(99)
+	handlers := cacheTestHandlers(c, &final)
+	rpc := newFakeRPC(t, handlers)
+	ec, err := ethclient.Dial(rpc.URL)
+	check(err)
+	storage := StorageData{BlockNum: big.NewInt(100), Address: testAccount, Slot: common.Hash{}}
+	build := func() {
+		q := &BrevisApp{ec: ec, dataStore: dataStore, concurrentFetchLimit: 4, srcChainId: 1}
+		_, err := q.buildStorageSlot(context.Background(), storage)
+		check(err)
+	}
+	tag := func() blockCacheTag {
+		var tag blockCacheTag
+		_, err := dataStore.Get(generateBlockCacheTagKey(100, 1), &tag)
+		check(err)
+		return tag
+	}
+	build()
+	if tag().Final {
+		t.Error("expected the cached data of block 100 not to be final")
+	}
+
+	// block 100 is reorged before it's final, the data cached before the reorg
+	// is not used even though the block is final now
+	c.reorg()
+	final = 101
+	build()
+	if n := rpc.count("eth_getStorageAt"); n != 2 {
+		t.Errorf("expected the data of the reorged block to be fetched again, got %d eth_getStorageAt", n)
+	}
+	if tag := tag(); !tag.Final || tag.Hash != c.blocks[100].Hash() {
+		t.Errorf("expected the data cached while block 100 is final to be final, got %+v", tag)
+	}
+
+	// the hash of the data cached while the block is final is not checked again
+	c.reorg()
+	build()
+	if n := rpc.count("eth_getStorageAt"); n != 2 {
+		t.Errorf("expected the cached data of the final block to be used, got %d eth_getStorageAt", n)
+	}
+
+	// by confirmations, block 100 has only one
+	q := &BrevisApp{ec: ec, dataStore: dataStore, concurrentFetchLimit: 4, srcChainId: 1,
+		cacheFinality: FinalityPolicy{Confirmations: 2}}
+	handlers["eth_blockNumber"] = func(params []json.RawMessage) (interface{}, error) {
+		return hexutil.EncodeUint64(101), nil
+	}
+	if q.isFinal(context.Background(), big.NewInt(100)) || !q.isFinal(context.Background(), big.NewInt(99)) {
+		t.Error("expected only the blocks with 2 confirmations to be final")
+	}
+
+	check(q.PurgeCachedData(99, 101))
+	ok, err := dataStore.Get(generateStorageKey(storage, 1), &StorageData{})
+	check(err)
+	if ok {
+		t.Error("expected the cached data to be purged")
+	}
+	if err = q.PurgeCachedData(2, 1); err == nil {
+		t.Error("expected an invalid block range to be rejected")
+	}
+}
+
+func TestCachedDataLongLivedApp(t *testing.T) {
+	c := newTestChain()
+	dataStore, err := store.InitStore("syncmap", "")
+	check(err)
+	final := uint64(99)
+	handlers := cacheTestHandlers(c, &final)
+	getBlock := handlers["eth_getBlockByNumber"]
+	finalizedDown := true
+	handlers["eth_getBlockByNumber"] = func(params []json.RawMessage) (interface{}, error) {
+		if string(params[0]) == `"finalized"` && finalizedDown {
+			return nil, fmt.Errorf("finalized block unavailable")
+		}
+		return getBlock(params)
+	}
+	rpc := newFakeRPC(t, handlers)
+	ec, err := ethclient.Dial(rpc.URL)
+	check(err)
+	q := &BrevisApp{ec: ec, dataStore: dataStore, concurrentFetchLimit: 4, srcChainId: 1}
+	ctx := context.Background()
+
+	// a failure is not cached
+	if q.isFinal(ctx, big.NewInt(99)) {
+		t.Error("expected no block to be final without the final block")
+	}
+	finalizedDown = false
+	if !q.isFinal(ctx, big.NewInt(99)) {
+		t.Error("expected the final block to be fetched again after a failure")
+	}
+
+	storage := StorageData{BlockNum: big.NewInt(100), Address: testAccount, Slot: common.Hash{}}
+	_, err = q.buildStorageSlot(ctx, storage)
+	check(err)
+	c.reorg()
+	final = 101
+	// the reorg and the new final block are seen once they expire
+	f := q.chain()
+	f.mu.Lock()
+	f.finalAt = f.finalAt.Add(-chainHeadTTL)
+	h := f.headers[100]
+	h.fetchedAt = h.fetchedAt.Add(-chainHeadTTL)
+	f.headers[100] = h
+	f.mu.Unlock()
+	if !q.isFinal(ctx, big.NewInt(101)) {
+		t.Error("expected the final block to advance")
+	}
+	_, err = q.buildStorageSlot(ctx, storage)
+	check(err)
+	if n := rpc.count("eth_getStorageAt"); n != 2 {
+		t.Errorf("expected the data of the reorged block to be fetched again, got %d eth_getStorageAt", n)
+	}
+}
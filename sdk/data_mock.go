@@ -1,5 +1,10 @@
 package sdk
 
+import (
+	"context"
+	"math/big"
+)
+
 func (q *BrevisApp) assignMockReceipts(in *CircuitInput) error {
 	// assigning user appointed receipts at specific indices
 	for i, r := range q.mockReceipts.special {
@@ -109,10 +114,60 @@ func (q *BrevisApp) buildMockStorageSlot(s StorageData) (StorageSlot, error) {
 	}, nil
 }
 
-func (q *BrevisApp) assignMockTransactions(in *CircuitInput) (err error) {
+// assignMockData assigns the pinned data at their indices, then the ordered data
+// at the free indices
+func assignMockData[T queryData, R any](data rawData[T], points DataPoints[R], build func(T) (R, error)) error {
+	for i, val := range data.special {
+		v, err := build(val)
+		if err != nil {
+			return err
+		}
+		points.Raw[i] = v
+		points.Toggles[i] = 1
+	}
+
+	j := 0
+	for _, val := range data.ordered {
+		for points.Toggles[j] == 1 {
+			j++
+		}
+		v, err := build(val)
+		if err != nil {
+			return err
+		}
+		points.Raw[j] = v
+		points.Toggles[j] = 1
+		j++
+	}
+
+	return nil
+}
+
+func (q *BrevisApp) buildMockAccount(a AccountData) (Account, error) {
+	balance := a.Balance
+	if balance == nil {
+		balance = big.NewInt(0)
+	}
+	return Account{
+		BlockNum:       newU32(a.BlockNum),
+		BlockBaseFee:   newU248(a.BlockBaseFee),
+		Address:        ConstUint248(a.Address),
+		Nonce:          newU248(a.Nonce),
+		Balance:        newU248(balance),
+		CodeHash:       ConstFromBigEndianBytes(a.CodeHash[:]),
+		StorageRoot:    ConstFromBigEndianBytes(a.StorageRoot[:]),
+		BlockTimestamp: newU248(a.BlockTimestamp),
+	}, nil
+}
+
+func (q *BrevisApp) buildMockBlockHeader(h BlockHeaderData) (BlockHeader, error) {
+	return convertBlockHeaderDataToBlockHeader(&h), nil
+}
+
+func (q *BrevisApp) assignMockTransactions(ctx context.Context, in *CircuitInput) (err error) {
 	// assigning user appointed txs at specific indices
 	for i, t := range q.txs.special {
-		tx, err := q.buildTx(t)
+		tx, err := q.buildTx(ctx, t)
 		if err != nil {
 			return err
 		}
@@ -126,7 +181,7 @@ func (q *BrevisApp) assignMockTransactions(in *CircuitInput) (err error) {
 		for in.Transactions.Toggles[j] == 1 {
 			j++
 		}
-		tx, err := q.buildTx(t)
+		tx, err := q.buildTx(ctx, t)
 		if err != nil {
 			return err
 		}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/celer-network/goutils/log"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -72,6 +73,24 @@ func (q *TransactionData) isReadyToSave() bool {
 		q.BlockTimestamp != 0
 }
 
+func (q *AccountData) isReadyToSave() bool {
+	return q.BlockBaseFee != nil &&
+		q.BlockBaseFee.Sign() == 1 &&
+		q.Balance != nil &&
+		q.CodeHash != (common.Hash{}) &&
+		q.StorageRoot != (common.Hash{}) &&
+		q.BlockTimestamp != 0
+}
+
+func (q *BlockHeaderData) isReadyToSave() bool {
+	return q.BlockBaseFee != nil &&
+		q.BlockBaseFee.Sign() == 1 &&
+		q.Hash != (common.Hash{}) &&
+		q.ParentHash != (common.Hash{}) &&
+		q.GasLimit != 0 &&
+		q.BlockTimestamp != 0
+}
+
 func generateReceiptKey(receipt ReceiptData, srcChainId uint64) string {
 	key := fmt.Sprintf("r-%d-%s", srcChainId, receipt.TxHash.Hex()[2:])
 	for _, logFieldPos := range receipt.Fields {
@@ -96,6 +115,19 @@ func generateStorageKey(storage StorageData, srcChainId uint64) string {
 	)
 }
 
+func generateAccountKey(account AccountData, srcChainId uint64) string {
+	return fmt.Sprintf(
+		"a-%d-%d-%s",
+		srcChainId,
+		account.BlockNum.Uint64(),
+		strings.ToLower(account.Address.Hex())[2:],
+	)
+}
+
+func generateBlockHeaderKey(header BlockHeaderData, srcChainId uint64) string {
+	return fmt.Sprintf("h-%d-%d", srcChainId, header.BlockNum.Uint64())
+}
+
 func generateTxKey(tx TransactionData, srcChainId uint64) string {
 	return fmt.Sprintf("t-%d-%s", srcChainId, tx.Hash.Hex()[2:])
 }
@@ -145,21 +177,27 @@ func buildLogFieldsData(fs []LogFieldData, receipt *types.Receipt) (fields []Log
 }
 
 // Send rpc request to query receipt related information
-func (q *BrevisApp) getReceiptInfos(txHash common.Hash) (receipt *types.Receipt, mptKey *big.Int, blockNumber *big.Int, baseFee *big.Int, time uint64, err error) {
-	receipt, err = q.ec.TransactionReceipt(context.Background(), txHash)
+func (q *BrevisApp) getReceiptInfos(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, mptKey *big.Int, blockNumber *big.Int, baseFee *big.Int, time uint64, err error) {
+	receipt, err = q.chain().receipt(ctx, txHash)
 	if err != nil {
 		return nil, nil, nil, nil, 0, fmt.Errorf("cannot get mpt key with wrong tx hash %s: %s", txHash.Hex(), err.Error())
 	}
 	mptKey = q.calculateMPTKeyWithIndex(int(receipt.TransactionIndex))
 	blockNumber = receipt.BlockNumber
 
-	header, _, err := GetHeaderAndTxHashes(q.ec, context.Background(), receipt.BlockNumber)
+	header, _, err := q.chain().header(ctx, receipt.BlockNumber)
 	if err != nil {
 		return nil, nil, nil, nil, 0, fmt.Errorf("cannot get block with wrong tx hash %s: %s", txHash.Hex(), err.Error())
 	}
 
 	baseFee = header.BaseFee
 	time = header.Time
+
+	if q.verifyFetchedData {
+		if err = q.chain().verifyReceipt(ctx, receipt); err != nil {
+			return nil, nil, nil, nil, 0, err
+		}
+	}
 	return
 }
 
@@ -197,8 +235,8 @@ func convertFieldDataToField(f LogFieldData) LogField {
 	}
 }
 
-func (q *BrevisApp) getBlockInfo(blkNum *big.Int) (baseFee *big.Int, time uint64, err error) {
-	header, _, err := GetHeaderAndTxHashes(q.ec, context.Background(), blkNum)
+func (q *BrevisApp) getBlockInfo(ctx context.Context, blkNum *big.Int) (baseFee *big.Int, time uint64, err error) {
+	header, _, err := q.chain().header(ctx, blkNum)
 	if err != nil {
 		return nil, 0, fmt.Errorf("cannot get blk base fee with wrong blkNum %d: %s", blkNum, err.Error())
 	}
@@ -207,12 +245,166 @@ func (q *BrevisApp) getBlockInfo(blkNum *big.Int) (baseFee *big.Int, time uint64
 	return
 }
 
-func (q *BrevisApp) getStorageValue(blkNum *big.Int, account common.Address, slot common.Hash) (result common.Hash, err error) {
-	value, err := q.ec.StorageAt(context.Background(), account, slot, blkNum)
+func (q *BrevisApp) getStorageValue(ctx context.Context, blkNum *big.Int, account common.Address, slot common.Hash) (result common.Hash, err error) {
+	value, err := q.chain().storageAt(ctx, blkNum, account, slot)
 	if err != nil {
+		if archiveErr := q.chain().archiveNodeError(blkNum, err); archiveErr != nil {
+			return common.Hash{}, fmt.Errorf("cannot get storage value for account 0x%x with slot 0x%x: %w", account.Bytes(), slot, archiveErr)
+		}
 		return common.Hash{}, fmt.Errorf("cannot get storage value for account 0x%x with slot 0x%x blkNum %d: %s", account.Bytes(), slot, blkNum, err.Error())
 	}
-	return common.BytesToHash(value), nil
+	if q.verifyFetchedData {
+		if err = q.chain().verifyStorage(ctx, blkNum, account, slot, value); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return value, nil
+}
+
+func (q *BrevisApp) getAccountState(ctx context.Context, blkNum *big.Int, account common.Address) (*accountState, error) {
+	result, err := q.chain().account(ctx, blkNum, account)
+	if err != nil {
+		if archiveErr := q.chain().archiveNodeError(blkNum, err); archiveErr != nil {
+			return nil, fmt.Errorf("cannot get account state for account 0x%x: %w", account.Bytes(), archiveErr)
+		}
+		return nil, fmt.Errorf("cannot get account state for account 0x%x blkNum %d: %s", account.Bytes(), blkNum, err.Error())
+	}
+	if q.verifyFetchedData {
+		if err = q.chain().verifyAccount(ctx, blkNum, account, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// getBlockHeader returns the header of the block and the block hash reported
+// by the RPC. The hash is not derived from the header since some chains hash
+// their headers differently.
+func (q *BrevisApp) getBlockHeader(ctx context.Context, blkNum *big.Int) (*types.Header, common.Hash, error) {
+	header, hash, err := q.chain().header(ctx, blkNum)
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("cannot get blk header with blkNum %d: %w", blkNum, err)
+	}
+	return header, hash, nil
+}
+
+// prefetchQueryData fetches the on-chain data of the queries that are neither
+// complete nor in the dataStore with batch requests. Failures are only logged
+// since each query falls back to fetching its data individually.
+func (q *BrevisApp) prefetchQueryData(ctx context.Context) {
+	f := q.chain()
+
+	var txHashes, txQueries []common.Hash
+	blkNums := make(map[common.Hash]*big.Int)
+	for _, r := range q.receipts.all() {
+		var data ReceiptData
+		if r.isReadyToSave() || q.inDataStore(generateReceiptKey(r, q.srcChainId), &data) {
+			continue
+		}
+		txHashes = append(txHashes, r.TxHash)
+		if r.BlockNum != nil {
+			blkNums[r.TxHash] = r.BlockNum
+		}
+	}
+	for _, t := range q.txs.all() {
+		var data TransactionData
+		if t.isReadyToSave() || q.inDataStore(generateTxKey(t, q.srcChainId), &data) {
+			continue
+		}
+		txHashes = append(txHashes, t.Hash)
+		txQueries = append(txQueries, t.Hash)
+		if t.BlockNum != nil {
+			blkNums[t.Hash] = t.BlockNum
+		}
+	}
+	if err := f.prefetchReceipts(ctx, txHashes, blkNums); err != nil {
+		log.Warnf("failed to prefetch receipts: %s", err)
+	}
+	var txBlocks []*big.Int
+	for _, hash := range txQueries {
+		if r, err := f.receipt(ctx, hash); err == nil {
+			txBlocks = append(txBlocks, r.BlockNumber)
+		}
+	}
+	if err := f.prefetchBlocks(ctx, txBlocks); err != nil {
+		log.Warnf("failed to prefetch blocks: %s", err)
+	}
+
+	var slots []StorageData
+	for _, s := range q.storageVals.all() {
+		var data StorageData
+		if s.isReadyToSave() || q.inDataStore(generateStorageKey(s, q.srcChainId), &data) {
+			continue
+		}
+		slots = append(slots, s)
+	}
+	if err := f.prefetchStorage(ctx, slots); err != nil {
+		log.Warnf("failed to prefetch storage values: %s", err)
+	}
+
+	var accounts []AccountData
+	for _, a := range q.accounts.all() {
+		var data AccountData
+		if a.isReadyToSave() || q.inDataStore(generateAccountKey(a, q.srcChainId), &data) {
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+	if err := f.prefetchAccounts(ctx, accounts); err != nil {
+		log.Warnf("failed to prefetch account states: %s", err)
+	}
+
+	var headers []*big.Int
+	for _, h := range q.headers.all() {
+		var data BlockHeaderData
+		if h.isReadyToSave() || q.inDataStore(generateBlockHeaderKey(h, q.srcChainId), &data) {
+			continue
+		}
+		headers = append(headers, h.BlockNum)
+	}
+	if err := f.prefetchHeaders(ctx, headers); err != nil {
+		log.Warnf("failed to prefetch block headers: %s", err)
+	}
+}
+
+func (q *BrevisApp) inDataStore(key string, v interface{}) bool {
+	ok, err := q.dataStore.Get(key, v)
+	return ok && err == nil
+}
+
+func ConvertBlockHeaderDataToBlockHeader(data *BlockHeaderData) BlockHeader {
+	return convertBlockHeaderDataToBlockHeader(data)
+}
+
+func convertBlockHeaderDataToBlockHeader(data *BlockHeaderData) BlockHeader {
+	return BlockHeader{
+		BlockNum:       newU32(data.BlockNum),
+		BlockBaseFee:   newU248(data.BlockBaseFee),
+		BlockHash:      ConstFromBigEndianBytes(data.Hash[:]),
+		ParentHash:     ConstFromBigEndianBytes(data.ParentHash[:]),
+		Coinbase:       ConstUint248(data.Coinbase),
+		GasUsed:        newU248(data.GasUsed),
+		GasLimit:       newU248(data.GasLimit),
+		PrevRandao:     ConstFromBigEndianBytes(data.PrevRandao[:]),
+		BlockTimestamp: newU248(data.BlockTimestamp),
+	}
+}
+
+func ConvertAccountDataToAccount(data *AccountData) Account {
+	return convertAccountDataToAccount(data)
+}
+
+func convertAccountDataToAccount(data *AccountData) Account {
+	return Account{
+		BlockNum:       newU32(data.BlockNum),
+		BlockBaseFee:   newU248(data.BlockBaseFee),
+		Address:        ConstUint248(data.Address),
+		Nonce:          newU248(data.Nonce),
+		Balance:        newU248(data.Balance),
+		CodeHash:       ConstFromBigEndianBytes(data.CodeHash[:]),
+		StorageRoot:    ConstFromBigEndianBytes(data.StorageRoot[:]),
+		BlockTimestamp: newU248(data.BlockTimestamp),
+	}
 }
 
 func ConvertStorageDataToStorage(data *StorageData) StorageSlot {
@@ -230,26 +422,30 @@ func convertStorageDataToStorage(data *StorageData) StorageSlot {
 	}
 }
 
-func (q *BrevisApp) calculateTxLeafHashBlockBaseFeeAndMPTKey(txHash common.Hash) (leafHash common.Hash, mptKey *big.Int, blockNumber *big.Int, baseFee *big.Int, time uint64, err error) {
-	receipt, err := q.ec.TransactionReceipt(context.Background(), txHash)
+func (q *BrevisApp) calculateTxLeafHashBlockBaseFeeAndMPTKey(ctx context.Context, txHash common.Hash) (leafHash common.Hash, mptKey *big.Int, blockNumber *big.Int, baseFee *big.Int, time uint64, err error) {
+	receipt, err := q.chain().receipt(ctx, txHash)
 	if err != nil {
 		return common.Hash{}, nil, nil, nil, 0, fmt.Errorf("cannot calculate tx leaf hash with wrong tx hash %s: %s", txHash.Hex(), err.Error())
 	}
 	mptKey = q.calculateMPTKeyWithIndex(int(receipt.TransactionIndex))
 	blockNumber = receipt.BlockNumber
 
-	header, _, err := GetHeaderAndTxHashes(q.ec, context.Background(), receipt.BlockNumber)
-
+	header, _, err := q.chain().header(ctx, receipt.BlockNumber)
 	if err != nil {
 		return common.Hash{}, nil, nil, nil, 0, fmt.Errorf("cannot calculate tx leaf hash with wrong tx hash %s: %s", txHash.Hex(), err.Error())
 	}
 	baseFee = header.BaseFee
 	time = header.Time
 
-	bk, err := q.ec.BlockByNumber(context.Background(), receipt.BlockNumber)
+	bk, err := q.chain().block(ctx, receipt.BlockNumber)
 	if err != nil {
 		return common.Hash{}, nil, nil, nil, 0, fmt.Errorf("cannot calculate tx leaf hash with wrong tx hash %s: %s", txHash.Hex(), err.Error())
 	}
+	if q.verifyFetchedData {
+		if err = q.chain().verifyTx(ctx, txHash, receipt, bk); err != nil {
+			return common.Hash{}, nil, nil, nil, 0, err
+		}
+	}
 	proofs, _, _, err := getTransactionProof(bk, int(receipt.TransactionIndex))
 	if err != nil {
 		return common.Hash{}, nil, nil, nil, 0, fmt.Errorf("cannot calculate tx leaf hash with wrong tx hash %s: %s", txHash.Hex(), err.Error())
@@ -0,0 +1,213 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ErrDataVerification is wrapped by the errors of data fetched from the RPC
+// that doesn't match the roots of its block header, see
+// BrevisAppConfig.VerifyFetchedData
+var ErrDataVerification = errors.New("fetched data verification failed")
+
+func verificationErr(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrDataVerification, fmt.Sprintf(format, args...))
+}
+
+// verifyReceipt checks that the receipt is the one at its index in the
+// receipts trie of its block
+func (f *chainFetcher) verifyReceipt(ctx context.Context, r *types.Receipt) error {
+	header, hash, err := f.header(ctx, r.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("cannot get header of block %d to verify receipt of tx %s: %w", r.BlockNumber, r.TxHash.Hex(), err)
+	}
+	if r.BlockHash != hash {
+		return verificationErr("receipt of tx %s is in block %s, but block %d is %s", r.TxHash.Hex(), r.BlockHash.Hex(), r.BlockNumber, hash.Hex())
+	}
+	receipts, err := f.blockReceipts(ctx, r.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("cannot get receipts of block %d to verify receipt of tx %s: %w", r.BlockNumber, r.TxHash.Hex(), err)
+	}
+	index := int(r.TransactionIndex)
+	if index >= len(receipts) {
+		return verificationErr("receipt of tx %s is at index %d, but block %d has %d receipts", r.TxHash.Hex(), index, r.BlockNumber, len(receipts))
+	}
+	if _, _, _, err = GetReceiptProof(types.NewBlockWithHeader(header), receipts, index); err != nil {
+		return verificationErr("receipt of tx %s: %s", r.TxHash.Hex(), err.Error())
+	}
+	expected, err := hashReceipt(receipts[index])
+	if err != nil {
+		return err
+	}
+	got, err := hashReceipt(r)
+	if err != nil {
+		return err
+	}
+	if receipts[index].TxHash != r.TxHash || expected != got {
+		return verificationErr("receipt of tx %s doesn't match receipt %d of block %d", r.TxHash.Hex(), index, r.BlockNumber)
+	}
+	return nil
+}
+
+// blockReceipts returns the receipts of all the txs of the block
+func (f *chainFetcher) blockReceipts(ctx context.Context, blkNum *big.Int) (types.Receipts, error) {
+	bk, err := f.block(ctx, blkNum)
+	if err != nil {
+		return nil, err
+	}
+	var hashes []common.Hash
+	blkNums := make(map[common.Hash]*big.Int)
+	for _, tx := range bk.Transactions() {
+		hashes = append(hashes, tx.Hash())
+		blkNums[tx.Hash()] = blkNum
+	}
+	if err = f.prefetchReceipts(ctx, hashes, blkNums); err != nil {
+		return nil, err
+	}
+	var receipts types.Receipts
+	for _, hash := range hashes {
+		// receipts that couldn't be prefetched are fetched individually
+		r, err := f.receipt(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get receipt of tx %s: %w", hash.Hex(), err)
+		}
+		receipts = append(receipts, r)
+	}
+	return receipts, nil
+}
+
+// verifyTx checks that the tx is at the index given by its receipt in the txs
+// trie of the block
+func (f *chainFetcher) verifyTx(ctx context.Context, txHash common.Hash, r *types.Receipt, bk *types.Block) error {
+	header, _, err := f.header(ctx, r.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("cannot get header of block %d to verify tx %s: %w", r.BlockNumber, txHash.Hex(), err)
+	}
+	if root := types.DeriveSha(bk.Transactions(), trie.NewStackTrie(nil)); root != header.TxHash {
+		return verificationErr("txs root of block %d is %s, but the header's is %s", r.BlockNumber, root.Hex(), header.TxHash.Hex())
+	}
+	index := int(r.TransactionIndex)
+	if index >= bk.Transactions().Len() || bk.Transactions()[index].Hash() != txHash {
+		return verificationErr("tx %s is not at index %d of block %d", txHash.Hex(), index, r.BlockNumber)
+	}
+	return nil
+}
+
+type storageProofResult struct {
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	StorageProof []struct {
+		Key   string          `json:"key"`
+		Proof []hexutil.Bytes `json:"proof"`
+	} `json:"storageProof"`
+}
+
+// verifyStorage checks the value of the slot with the eth_getProof proofs of
+// the account and the slot against the state root of the block
+func (f *chainFetcher) verifyStorage(ctx context.Context, blkNum *big.Int, address common.Address, slot, value common.Hash) error {
+	header, _, err := f.header(ctx, blkNum)
+	if err != nil {
+		return fmt.Errorf("cannot get header of block %d to verify storage of %x: %w", blkNum, address, err)
+	}
+	var res storageProofResult
+	err = f.call(ctx, &res, nil, "eth_getProof", address, []common.Hash{slot}, hexutil.EncodeBig(blkNum))
+	if err != nil {
+		return fmt.Errorf("cannot get storage proof of %x slot %x at block %d: %w", address, slot, blkNum, err)
+	}
+	account, err := verifyAccountProof(header.Root, address, res.AccountProof)
+	if err != nil {
+		return verificationErr("account %x at block %d: %s", address, blkNum, err.Error())
+	}
+	if len(res.StorageProof) != 1 {
+		return verificationErr("expected 1 storage proof of %x slot %x at block %d, got %d", address, slot, blkNum, len(res.StorageProof))
+	}
+	enc, err := verifyMPTProof(account.Root, slot.Bytes(), res.StorageProof[0].Proof)
+	if err != nil {
+		return verificationErr("storage of %x slot %x at block %d: %s", address, slot, blkNum, err.Error())
+	}
+	proven := common.Hash{}
+	if len(enc) > 0 {
+		_, content, _, err := rlp.Split(enc)
+		if err != nil {
+			return verificationErr("storage of %x slot %x at block %d: %s", address, slot, blkNum, err.Error())
+		}
+		proven = common.BytesToHash(content)
+	}
+	if proven != value {
+		return verificationErr("storage value of %x slot %x at block %d is %x, but the proven value is %x", address, slot, blkNum, value, proven)
+	}
+	return nil
+}
+
+// verifyAccount checks the state of the account with the eth_getProof proof of
+// the account against the state root of the block
+func (f *chainFetcher) verifyAccount(ctx context.Context, blkNum *big.Int, address common.Address, state *accountState) error {
+	header, _, err := f.header(ctx, blkNum)
+	if err != nil {
+		return fmt.Errorf("cannot get header of block %d to verify account %x: %w", blkNum, address, err)
+	}
+	var res storageProofResult
+	err = f.call(ctx, &res, nil, "eth_getProof", address, []common.Hash{}, hexutil.EncodeBig(blkNum))
+	if err != nil {
+		return fmt.Errorf("cannot get account proof of %x at block %d: %w", address, blkNum, err)
+	}
+	account, err := verifyAccountProof(header.Root, address, res.AccountProof)
+	if err != nil {
+		return verificationErr("account %x at block %d: %s", address, blkNum, err.Error())
+	}
+	balance := new(big.Int)
+	if state.Balance != nil {
+		balance = state.Balance
+	}
+	// the code hash and storage root of a missing account may be reported as zero
+	codeHash, storageRoot := state.CodeHash, state.StorageHash
+	if codeHash == (common.Hash{}) {
+		codeHash = types.EmptyCodeHash
+	}
+	if storageRoot == (common.Hash{}) {
+		storageRoot = types.EmptyRootHash
+	}
+	if state.Nonce != account.Nonce || balance.Cmp(account.Balance.ToBig()) != 0 ||
+		codeHash != common.BytesToHash(account.CodeHash) || storageRoot != account.Root {
+		return verificationErr("state of account %x at block %d doesn't match its proof", address, blkNum)
+	}
+	return nil
+}
+
+// verifyAccountProof returns the proven state of the account, which is empty if
+// the account doesn't exist
+func verifyAccountProof(stateRoot common.Hash, address common.Address, proof []hexutil.Bytes) (*types.StateAccount, error) {
+	enc, err := verifyMPTProof(stateRoot, address.Bytes(), proof)
+	if err != nil {
+		return nil, err
+	}
+	account := types.NewEmptyStateAccount()
+	if len(enc) == 0 {
+		return account, nil
+	}
+	if err = rlp.DecodeBytes(enc, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// verifyMPTProof returns the value of the secure trie key proven against root.
+// The value is nil if the proof proves the absence of the key.
+func verifyMPTProof(root common.Hash, key []byte, proof []hexutil.Bytes) ([]byte, error) {
+	db := memorydb.New()
+	for _, node := range proof {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	return trie.VerifyProof(root, crypto.Keccak256(key), db)
+}
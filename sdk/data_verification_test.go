@@ -0,0 +1,80 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/brevis-network/brevis-sdk/store"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func newTestVerifyApp(t *testing.T, handlers map[string]func(params []json.RawMessage) (interface{}, error)) *BrevisApp {
+	ec, err := ethclient.Dial(newFakeRPC(t, handlers).URL)
+	check(err)
+	dataStore, err := store.InitStore("syncmap", "")
+	check(err)
+	return &BrevisApp{ec: ec, dataStore: dataStore, concurrentFetchLimit: 4, srcChainId: 1, verifyFetchedData: true}
+}
+
+func TestVerifyFetchedData(t *testing.T) {
+	c := newTestChain()
+	for _, blockReceipts := range []bool{true, false} {
+		q, _ := newTestFetchApp(t, c, blockReceipts)
+		q.verifyFetchedData = true
+		buildTestFetchApp(q)
+	}
+
+	q := newTestVerifyApp(t, c.handlers(true))
+	state, err := q.getAccountState(context.Background(), big.NewInt(101), testAccount)
+	check(err)
+	if state.Nonce != 1 || state.Balance.Uint64() != 5 {
+		t.Errorf("unexpected account state %+v", state)
+	}
+}
+
+func TestVerifyFetchedDataMismatch(t *testing.T) {
+	c := newTestChain()
+	tampered := func(r *types.Receipt) *types.Receipt {
+		cpy := *r
+		return &cpy
+	}
+
+	// the receipt of tx 0 has a wrong status and the one of tx 1 a wrong index
+	handlers := c.handlers(true)
+	handlers["eth_getTransactionReceipt"] = func(params []json.RawMessage) (interface{}, error) {
+		var hash common.Hash
+		check(json.Unmarshal(params[0], &hash))
+		r := tampered(c.receipts[hash])
+		switch hash {
+		case c.txHash(0):
+			r.Status = 0
+		case c.txHash(1):
+			r.TransactionIndex = 2
+		}
+		return r, nil
+	}
+	handlers["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		return common.BigToHash(big.NewInt(99)), nil
+	}
+	q := newTestVerifyApp(t, handlers)
+
+	_, err := q.buildReceipt(context.Background(), ReceiptData{TxHash: c.txHash(0), Fields: []LogFieldData{{}}})
+	if !errors.Is(err, ErrDataVerification) {
+		t.Errorf("expected the tampered receipt to fail verification, got %v", err)
+	}
+	// a new app since verifying the receipt caches the receipts of the block
+	q = newTestVerifyApp(t, handlers)
+	_, err = q.buildTx(context.Background(), TransactionData{Hash: c.txHash(1)})
+	if !errors.Is(err, ErrDataVerification) {
+		t.Errorf("expected the tx at a wrong index to fail verification, got %v", err)
+	}
+	_, err = q.buildStorageSlot(context.Background(), StorageData{BlockNum: big.NewInt(100), Address: testAccount, Slot: common.Hash{}})
+	if !errors.Is(err, ErrDataVerification) {
+		t.Errorf("expected the wrong storage value to fail verification, got %v", err)
+	}
+}
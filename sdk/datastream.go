@@ -3,9 +3,13 @@ package sdk
 import (
 	"fmt"
 	"math/big"
+	"math/bits"
 	"os"
+	"sort"
 
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/rangecheck"
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
@@ -204,12 +208,33 @@ type GetValueFunc[T any] func(current T) Uint248
 // result of the group. The optional param maxUniqueGroupValuesOptional can be
 // supplied to optimize performance. It assumes the worst case (all values in the
 // data stream are unique) if no maxUniqueGroupValuesOptional is configured.
+//
+// GroupBy uses the filter-then-reduce strategy of GroupByFiltered, whose cost
+// is quadratic in the worst case. When there are many groups, GroupBySorted is
+// cheaper as its cost is linear in the length of the data stream, but its result
+// data stream is laid out differently.
 func GroupBy[T, R CircuitVariable](
 	ds *DataStream[T],
 	reducer ReduceFunc[T, R],
 	reducerInit R,
 	field GetValueFunc[T],
 	maxUniqueGroupValuesOptional ...int,
+) (*DataStream[R], error) {
+	return GroupByFiltered(ds, reducer, reducerInit, field, maxUniqueGroupValuesOptional...)
+}
+
+// GroupByFiltered is the filter-then-reduce strategy used by GroupBy. It runs a
+// Filter and a Reduce over the whole data stream for each of the
+// maxUniqueGroupValuesOptional groups, hence its cost is quadratic in the worst
+// case. The result data stream has one element per group, ordered by the first
+// appearance of the group value in the source data stream, followed by toggled
+// off elements.
+func GroupByFiltered[T, R CircuitVariable](
+	ds *DataStream[T],
+	reducer ReduceFunc[T, R],
+	reducerInit R,
+	field GetValueFunc[T],
+	maxUniqueGroupValuesOptional ...int,
 ) (*DataStream[R], error) {
 	if len(maxUniqueGroupValuesOptional) > 1 {
 		panic("invalid amount of optional params")
@@ -250,6 +275,119 @@ func GroupBy[T, R CircuitVariable](
 	return newDataStream(ds.api, aggResults, aggResultToggles), nil
 }
 
+// GroupBySorted is a sort-then-reduce alternative to GroupBy. The data stream is
+// permuted so that it is sorted by the group field, then each run of adjacent
+// elements sharing the same group value is reduced in a single pass. Its cost is
+// linear in the length of the data stream regardless of the number of groups.
+//
+// The result data stream has the same length as the source data stream. The
+// aggregation result of a group is placed at the position of the last element
+// of its run, in ascending order of the group values, and all other positions
+// are toggled off. The group values must fit in 248 bits.
+func GroupBySorted[T, R CircuitVariable](
+	ds *DataStream[T],
+	reducer ReduceFunc[T, R],
+	reducerInit R,
+	field GetValueFunc[T],
+) (*DataStream[R], error) {
+	g := ds.api.g
+	n := len(ds.underlying)
+	if n == 0 {
+		return newDataStream(ds.api, []R{}, []frontend.Variable{}), nil
+	}
+	values := make([]frontend.Variable, n)
+	for i, v := range ds.underlying {
+		values[i] = field(v).Val
+	}
+	perm, err := computeGroupSortHint(g, values)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every column of the data stream (including the toggles) goes into its own
+	// lookup table. Looking up all the tables with the same hinted index proves
+	// that each sorted row is a row of the source data stream. Requiring the
+	// sorted rows to be strictly increasing by (group value, index) further proves
+	// that no row is used twice, hence the sorted rows are a permutation of the
+	// source rows.
+	numVars := len(ds.underlying[0].Values())
+	tables := make([]*logderivlookup.Table, numVars+1)
+	for i := range tables {
+		tables[i] = logderivlookup.New(g)
+	}
+	for i, v := range ds.underlying {
+		for j, val := range v.Values() {
+			tables[j].Insert(val)
+		}
+		tables[numVars].Insert(ds.toggles[i])
+	}
+	columns := make([][]frontend.Variable, len(tables))
+	for i, t := range tables {
+		columns[i] = t.Lookup(perm...)
+	}
+	sorted := make([]T, n)
+	sortedToggles := columns[numVars]
+	sortedValues := make([]frontend.Variable, n)
+	for i := range sorted {
+		row := make([]frontend.Variable, numVars)
+		for j := range row {
+			row[j] = columns[j][i]
+		}
+		sorted[i] = (*new(T)).FromValues(row...).(T)
+		sortedValues[i] = field(sorted[i]).Val
+	}
+
+	rangeChecker := rangecheck.New(g)
+	// sameAsNext[i] is 1 if sorted[i] and sorted[i+1] are in the same group
+	sameAsNext := make([]frontend.Variable, n)
+	for i := 0; i < n-1; i++ {
+		same := g.IsZero(g.Sub(sortedValues[i+1], sortedValues[i]))
+		// if in the same group, the index must strictly increase, otherwise the group
+		// value must strictly increase
+		diff := g.Select(same,
+			g.Sub(perm[i+1], perm[i], 1),
+			g.Sub(sortedValues[i+1], sortedValues[i], 1))
+		rangeChecker.Check(diff, numBitsPerVar)
+		sameAsNext[i] = same
+	}
+	sameAsNext[n-1] = 0
+
+	aggResults := make([]R, n)
+	aggResultToggles := make([]frontend.Variable, n)
+	initVals := reducerInit.Values()
+	acc := reducerInit
+	var hasValid frontend.Variable = 0
+	for i, data := range sorted {
+		var isStart frontend.Variable = 1
+		if i > 0 {
+			isStart = g.Sub(1, sameAsNext[i-1])
+		}
+		accVals := acc.Values()
+		baseVals := make([]frontend.Variable, len(accVals))
+		for j := range accVals {
+			baseVals[j] = g.Select(isStart, initVals[j], accVals[j])
+		}
+		base := acc.FromValues(baseVals...).(R)
+		newAcc := reducer(base, data)
+		newAccVals := newAcc.Values()
+		if len(newAccVals) != len(baseVals) {
+			panic("not the same number of elements between original and reduced variables")
+		}
+		vals := make([]frontend.Variable, len(baseVals))
+		for j := range baseVals {
+			vals[j] = g.Select(sortedToggles[i], newAccVals[j], baseVals[j])
+		}
+		acc = acc.FromValues(vals...).(R)
+		hasValid = g.Select(isStart, sortedToggles[i], g.Or(hasValid, sortedToggles[i]))
+
+		aggResults[i] = acc
+		// only turn on toggles for the last element of a group that has at least 1
+		// toggled on item
+		aggResultToggles[i] = g.And(hasValid, g.Sub(1, sameAsNext[i]))
+	}
+	return newDataStream(ds.api, aggResults, aggResultToggles), nil
+}
+
 func computeGroupValuesHint(api frontend.API, values, toggles []frontend.Variable) ([]frontend.Variable, error) {
 	inputs := []frontend.Variable{len(values)}
 	inputs = append(inputs, values...)
@@ -285,6 +423,29 @@ func GroupValuesHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
 	return nil
 }
 
+func computeGroupSortHint(api frontend.API, values []frontend.Variable) ([]frontend.Variable, error) {
+	return api.Compiler().NewHint(GroupSortHint, len(values), values...)
+}
+
+// GroupSortHint outputs the indices of the input values sorted in ascending
+// order of the values. Indices of equal values are kept in ascending order.
+func GroupSortHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != len(outputs) {
+		return fmt.Errorf("GroupSortHint: input len %d and output len %d mismatch", len(inputs), len(outputs))
+	}
+	indices := make([]int, len(inputs))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return inputs[indices[i]].Cmp(inputs[indices[j]]) < 0
+	})
+	for i, index := range indices {
+		outputs[i].SetInt64(int64(index))
+	}
+	return nil
+}
+
 type MapFunc[T, R CircuitVariable] func(current T) R
 
 // Map maps each valid element in the data stream by calling the user defined mapFunc
@@ -317,6 +478,31 @@ func Reduce[T, R CircuitVariable](ds *DataStream[T], initial R, reducer ReduceFu
 	return acc
 }
 
+// Scan is like Reduce, but emits the accumulator after each element instead of
+// only the final one. The accumulator is left unchanged by toggled off
+// elements. The result data stream has the same toggles as the source.
+// Example: Scan([1,2,3,4], 0, mySumFunc) -> [1,3,6,10]
+func Scan[T, R CircuitVariable](ds *DataStream[T], initial R, reducer ReduceFunc[T, R]) *DataStream[R] {
+	acc := initial
+	res := make([]R, len(ds.underlying))
+	for i, data := range ds.underlying {
+		newAcc := reducer(acc, data)
+		oldAccVals := acc.Values()
+		if len(newAcc.Values()) != len(oldAccVals) {
+			panic("not the same number of elements between original and reduced variables")
+		}
+		values := make([]frontend.Variable, len(oldAccVals))
+		for j, newAccV := range newAcc.Values() {
+			values[j] = ds.api.g.Select(ds.toggles[i], newAccV, oldAccVals[j])
+		}
+		acc = acc.FromValues(values...).(R)
+		res[i] = acc
+	}
+	toggles := make([]frontend.Variable, len(ds.toggles))
+	copy(toggles, ds.toggles)
+	return newDataStream(ds.api, res, toggles)
+}
+
 // FilterFunc must return 1/0 to include/exclude `current` in the filter result
 type FilterFunc[T CircuitVariable] func(current T) Uint248
 
@@ -352,30 +538,385 @@ func MaxGeneric[T CircuitVariable](ds *DataStream[T], initialMax T, gt SortFunc[
 	})
 }
 
+// Numeric is the set of circuit variable types supported by the numeric
+// reducers Min, Max, Sum and Mean
+type Numeric interface {
+	CircuitVariable
+	Uint32 | Uint64 | Uint248 | Int248 | Uint521
+}
+
 // Min finds out the minimum value from the data stream. Uses MinGeneric. Note if
 // the data stream is empty (all data points are toggled off), this function
-// returns MaxUint248.
-func Min(ds *DataStream[Uint248]) Uint248 {
-	return MinGeneric(ds, newU248(MaxUint248), ds.api.Uint248.IsLessThan)
+// returns the largest value of T, e.g. MaxUint248 for Uint248.
+func Min[T Numeric](ds *DataStream[T]) T {
+	return MinGeneric(ds, maxOf[T](), func(a, b T) Uint248 { return isLessThan(ds.api, a, b) })
 }
 
 // Max finds out the maximum value from the data stream. Uses MaxGeneric. Note if
 // the data stream is empty (all data points are toggled off), this function
-// returns 0.
-func Max(ds *DataStream[Uint248]) Uint248 {
-	return MaxGeneric(ds, newU248(0), ds.api.Uint248.IsGreaterThan)
+// returns the smallest value of T, i.e. 0 for unsigned types and -2^247 for
+// Int248.
+func Max[T Numeric](ds *DataStream[T]) T {
+	return MaxGeneric(ds, minOf[T](), func(a, b T) Uint248 { return isLessThan(ds.api, b, a) })
 }
 
-// Sum sums values of the selected field in the data stream. Uses Reduce
-func Sum(ds *DataStream[Uint248]) Uint248 {
-	return Reduce(ds, newU248(0), func(sum Uint248, curr Uint248) (newSum Uint248) {
-		return ds.api.Uint248.Add(sum, curr)
-	})
+// Sum sums values of the selected field in the data stream. The sum is
+// accumulated in the native field, which is wider than T, and proving fails if
+// the result does not fit in T. Uint521 values are summed modulo 2^521-1 like
+// Uint521API.Add does.
+func Sum[T Numeric](ds *DataStream[T]) T {
+	api := ds.api
+	var ret any
+	switch any(*new(T)).(type) {
+	case Uint32:
+		ret = newU32(sumUnsigned(ds, 32))
+	case Uint64:
+		ret = newU64(sumUnsigned(ds, 64))
+	case Uint248:
+		ret = newU248(sumUnsigned(ds, numBitsPerVar))
+	case Int248:
+		ret = newI248(sumSigned(any(ds).(*DataStream[Int248])))
+	case Uint521:
+		ret = Reduce(any(ds).(*DataStream[Uint521]), ConstUint521(0), func(sum Uint521, curr Uint521) Uint521 {
+			return api.Uint521.Add(sum, curr)
+		})
+	}
+	return ret.(T)
+}
+
+// Mean calculates the arithmetic mean over the selected fields of the data
+// stream. Uses MeanWithRemainder.
+func Mean[T Numeric](ds *DataStream[T]) T {
+	mean, _ := MeanWithRemainder(ds)
+	return mean
+}
+
+// MeanWithRemainder calculates the arithmetic mean over the selected fields of
+// the data stream, rounded down (towards negative infinity for Int248), and the
+// remainder such that mean * count + remainder == sum and 0 <= remainder <
+// count. The sum is accumulated in a type wider than T, so the mean is exact
+// even if the sum itself does not fit in T. Uint521 values are summed modulo
+// 2^521-1 and their mean must be less than 2^(520-log2(len(ds))). Note if the
+// data stream is empty (all data points are toggled off), this function returns
+// 0 for both the mean and the remainder.
+func MeanWithRemainder[T Numeric](ds *DataStream[T]) (mean T, remainder Uint248) {
+	api := ds.api
+	g := api.g
+	count := Count(ds)
+	isEmpty := api.Uint248.IsZero(count)
+	divisor := g.Select(isEmpty.Val, 1, count.Val)
+
+	var ret any
+	var rem frontend.Variable
+	switch any(*new(T)).(type) {
+	case Uint32:
+		var q frontend.Variable
+		q, rem = divSmall(g, []frontend.Variable{nativeSum(ds)}, divisor, 32)
+		ret = newU32(q)
+	case Uint64:
+		var q frontend.Variable
+		q, rem = divSmall(g, []frontend.Variable{nativeSum(ds)}, divisor, 64)
+		ret = newU64(q)
+	case Uint248:
+		values := make([]frontend.Variable, len(ds.underlying))
+		for i, v := range ds.underlying {
+			values[i] = any(v).(Uint248).Val
+		}
+		var q frontend.Variable
+		q, rem = divSmall(g, wideSum(g, values, ds.toggles), divisor, wideDigitBits)
+		ret = newU248(q)
+	case Int248:
+		// the mean of the shifted values is the shifted mean, and the remainder
+		// stays the same since the shift is a multiple of count
+		keys := make([]frontend.Variable, len(ds.underlying))
+		for i, v := range ds.underlying {
+			keys[i] = flipSignBit(g, any(v).(Int248).Val)
+		}
+		var q frontend.Variable
+		q, rem = divSmall(g, wideSum(g, keys, ds.toggles), divisor, wideDigitBits)
+		ret = newI248(flipSignBit(g, q))
+	case Uint521:
+		sum := Sum(any(ds).(*DataStream[Uint521]))
+		// count < 2^bits.Len(len(ds)) satisfies the divisor bound of quoRem
+		quoBits := 520 - bits.Len(uint(len(ds.underlying)))
+		q, r := api.Uint521.quoRem(sum, api.ToUint521(newU248(divisor)), quoBits)
+		ret = q
+		rem = api.ToUint248(r).Val
+	}
+	return Select(api, isEmpty, zeroOf[T](), ret.(T)), newU248(g.Select(isEmpty.Val, 0, rem))
+}
+
+// CumSum computes the running sums of the data stream, i.e. each element of the
+// result is the sum of all valid elements up to and including it. The result
+// data stream has the same toggles as the source. Proving fails if any running
+// sum does not fit in T. Uint521 values are summed modulo 2^521-1.
+// Example: CumSum([1,2,3,4]) -> [1,3,6,10]
+func CumSum[T Numeric](ds *DataStream[T]) *DataStream[T] {
+	api := ds.api
+	zero := zeroOf[T]()
+	sum := zero
+	res := make([]T, len(ds.underlying))
+	for i, v := range ds.underlying {
+		// toggled off elements are replaced by 0 so that they cannot trigger
+		// overflow checks
+		sum = checkedAdd(api, sum, Select(api, newU248(ds.toggles[i]), v, zero))
+		res[i] = sum
+	}
+	toggles := make([]frontend.Variable, len(ds.toggles))
+	copy(toggles, ds.toggles)
+	return newDataStream(api, res, toggles)
+}
+
+// Diff computes the differences between consecutive valid elements of the data
+// stream. Each valid element except the first one is replaced by its value
+// minus the previous valid element, and the first valid element is toggled off
+// in the result. For the unsigned types the data stream must be non-decreasing
+// (e.g. cumulative values such as TWAP accumulators), otherwise proving fails.
+// Example: Diff([1,3,6,10]) -> [_,2,3,4]
+func Diff[T Numeric](ds *DataStream[T]) *DataStream[T] {
+	api := ds.api
+	g := api.g
+	res := make([]T, len(ds.underlying))
+	toggles := make([]frontend.Variable, len(ds.underlying))
+	prev := zeroOf[T]()
+	var hasPrev frontend.Variable = 0
+	for i, v := range ds.underlying {
+		toggles[i] = g.Mul(ds.toggles[i], hasPrev)
+		// invalid elements yield prev - prev so that they cannot trigger underflow
+		// checks
+		curr := Select(api, newU248(toggles[i]), v, prev)
+		res[i] = checkedSub(api, curr, prev)
+		prev = Select(api, newU248(ds.toggles[i]), v, prev)
+		hasPrev = g.Or(hasPrev, ds.toggles[i])
+	}
+	return newDataStream(api, res, toggles)
+}
+
+// maxOf returns the largest value of T
+func maxOf[T Numeric]() T {
+	var ret any
+	switch any(*new(T)).(type) {
+	case Uint32:
+		ret = ConstUint32(MaxUint32)
+	case Uint64:
+		ret = ConstUint64(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)))
+	case Uint248:
+		ret = newU248(MaxUint248)
+	case Int248:
+		ret = ConstInt248(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 247), big.NewInt(1)))
+	case Uint521:
+		ret = ConstUint521(new(big.Int).Sub(Uint521Field{}.Modulus(), big.NewInt(1)))
+	}
+	return ret.(T)
+}
+
+// minOf returns the smallest value of T
+func minOf[T Numeric]() T {
+	var ret any
+	switch any(*new(T)).(type) {
+	case Uint32:
+		ret = ConstUint32(0)
+	case Uint64:
+		ret = ConstUint64(0)
+	case Uint248:
+		ret = newU248(0)
+	case Int248:
+		ret = ConstInt248(new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 247)))
+	case Uint521:
+		ret = ConstUint521(0)
+	}
+	return ret.(T)
+}
+
+// isLessThan returns 1 if a < b, and 0 otherwise
+func isLessThan[T Numeric](api *CircuitAPI, a, b T) Uint248 {
+	switch a := any(a).(type) {
+	case Uint32:
+		return newU248(api.Uint32.IsLessThan(a, any(b).(Uint32)).Val)
+	case Uint64:
+		return newU248(api.Uint64.IsLessThan(a, any(b).(Uint64)).Val)
+	case Uint248:
+		return api.Uint248.IsLessThan(a, any(b).(Uint248))
+	case Int248:
+		return api.Int248.IsLessThan(a, any(b).(Int248))
+	case Uint521:
+		return api.Uint521.IsLessThan(a, any(b).(Uint521))
+	}
+	panic(fmt.Errorf("unsupported type %T", a))
+}
+
+// checkedAdd returns a + b and asserts the result fits in T. Uint521 values are
+// added modulo 2^521-1.
+func checkedAdd[T Numeric](api *CircuitAPI, a, b T) T {
+	g := api.g
+	var ret any
+	switch a := any(a).(type) {
+	case Uint32:
+		ret = newU32(checkedNative(g, g.Add(a.Val, any(b).(Uint32).Val), 32))
+	case Uint64:
+		ret = newU64(checkedNative(g, g.Add(a.Val, any(b).(Uint64).Val), 64))
+	case Uint248:
+		ret = newU248(checkedNative(g, g.Add(a.Val, any(b).(Uint248).Val), numBitsPerVar))
+	case Int248:
+		ret = api.Int248.Add(a, any(b).(Int248))
+	case Uint521:
+		ret = api.Uint521.Add(a, any(b).(Uint521))
+	}
+	return ret.(T)
+}
+
+// checkedSub returns a - b and asserts the result fits in T, i.e. a >= b for the
+// unsigned types
+func checkedSub[T Numeric](api *CircuitAPI, a, b T) T {
+	g := api.g
+	var ret any
+	switch a := any(a).(type) {
+	case Uint32:
+		ret = newU32(checkedNative(g, g.Sub(a.Val, any(b).(Uint32).Val), 32))
+	case Uint64:
+		ret = newU64(checkedNative(g, g.Sub(a.Val, any(b).(Uint64).Val), 64))
+	case Uint248:
+		ret = newU248(checkedNative(g, g.Sub(a.Val, any(b).(Uint248).Val), numBitsPerVar))
+	case Int248:
+		ret = api.Int248.Sub(a, any(b).(Int248))
+	case Uint521:
+		api.Uint521.AssertIsLessOrEqual(any(b).(Uint521), a)
+		ret = api.Uint521.Sub(a, any(b).(Uint521))
+	}
+	return ret.(T)
 }
 
-// Mean calculates the arithmetic mean over the selected fields of the data stream. Uses Sum.
-func Mean(ds *DataStream[Uint248]) Uint248 {
-	sum := Sum(ds)
-	quo, _ := ds.api.Uint248.Div(sum, Count(ds))
-	return quo
+// checkedNative range checks v to nbBits bits and returns it
+func checkedNative(g frontend.API, v frontend.Variable, nbBits int) frontend.Variable {
+	rangecheck.New(g).Check(v, nbBits)
+	return v
+}
+
+// sumUnsigned sums the valid elements of a data stream of single variable
+// unsigned values and asserts that the sum fits in nbBits bits. The partial sum
+// is range checked every few elements so that it never wraps around the
+// native field modulus.
+func sumUnsigned[T Numeric](ds *DataStream[T], nbBits int) frontend.Variable {
+	g := ds.api.g
+	rangeChecker := rangecheck.New(g)
+	// a checked partial sum plus this many elements stays below 2^251
+	uncheckedLen := 1 << min(251-nbBits-1, 30)
+	var sum frontend.Variable = 0
+	for i, v := range ds.underlying {
+		sum = g.Add(sum, g.Mul(ds.toggles[i], v.Values()[0]))
+		if (i+1)%uncheckedLen == 0 {
+			rangeChecker.Check(sum, nbBits)
+		}
+	}
+	rangeChecker.Check(sum, nbBits)
+	return sum
+}
+
+// nativeSum sums the valid elements of a data stream of Uint32 or Uint64 in the
+// native field without any range check. The sum of fewer than 2^180 elements
+// of at most 64 bits cannot wrap around the native modulus.
+func nativeSum[T Numeric](ds *DataStream[T]) frontend.Variable {
+	g := ds.api.g
+	var sum frontend.Variable = 0
+	for i, v := range ds.underlying {
+		sum = g.Add(sum, g.Mul(ds.toggles[i], v.Values()[0]))
+	}
+	return sum
+}
+
+// sumSigned sums the valid elements of a data stream of Int248 and asserts that
+// the sum fits in int248. Returns the two's complement encoding of the sum.
+func sumSigned(ds *DataStream[Int248]) frontend.Variable {
+	g := ds.api.g
+	rangeChecker := rangecheck.New(g)
+	half := new(big.Int).Lsh(big.NewInt(1), uint(numBitsPerVar-1))
+	// the absolute value of a checked partial sum plus this many elements stays
+	// below 2^250
+	const uncheckedLen = 7
+	// the sum is accumulated as a signed value in the native field, i.e. sum + 2^247
+	// is in [0, 2^248) if the sum fits in int248
+	var sum frontend.Variable = 0
+	for i, v := range ds.underlying {
+		signed := g.Sub(flipSignBit(g, v.Val), half)
+		sum = g.Add(sum, g.Mul(ds.toggles[i], signed))
+		if (i+1)%uncheckedLen == 0 {
+			rangeChecker.Check(g.Add(sum, half), numBitsPerVar)
+		}
+	}
+	key := g.Add(sum, half)
+	rangeChecker.Check(key, numBitsPerVar)
+	return flipSignBit(g, key)
+}
+
+// wideDigitBits is the number of bits per digit of the sums returned by wideSum
+const wideDigitBits = 124
+
+// wideSum sums the 248-bit values of the valid elements without the risk of
+// overflowing. The sum is returned as a list of 124-bit digits, most
+// significant digit first.
+func wideSum(g frontend.API, values, toggles []frontend.Variable) []frontend.Variable {
+	rangeChecker := rangecheck.New(g)
+	full := new(big.Int).Lsh(big.NewInt(1), uint(numBitsPerVar))
+	// lo is kept below 2^248 by moving the carry to hi every few elements, so
+	// lo plus this many elements stays below 2^251
+	const uncheckedLen = 7
+	var hi, lo frontend.Variable = 0, 0
+	carry := func() {
+		out, err := g.Compiler().NewHint(QuoRemHint, 2, lo, full)
+		if err != nil {
+			panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+		}
+		q, r := out[0], out[1]
+		rangeChecker.Check(q, 3)
+		rangeChecker.Check(r, numBitsPerVar)
+		g.AssertIsEqual(g.Add(g.Mul(q, full), r), lo)
+		hi, lo = g.Add(hi, q), r
+	}
+	for i, v := range values {
+		lo = g.Add(lo, g.Mul(toggles[i], v))
+		if (i+1)%uncheckedLen == 0 {
+			carry()
+		}
+	}
+	if len(values)%uncheckedLen != 0 {
+		carry()
+	}
+	// split lo into two digits, hi is small enough to be a single digit
+	out, err := g.Compiler().NewHint(QuoRemHint, 2, lo, new(big.Int).Lsh(big.NewInt(1), wideDigitBits))
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+	}
+	rangeChecker.Check(out[0], wideDigitBits)
+	rangeChecker.Check(out[1], wideDigitBits)
+	g.AssertIsEqual(g.Add(g.Mul(out[0], new(big.Int).Lsh(big.NewInt(1), wideDigitBits)), out[1]), lo)
+	return []frontend.Variable{hi, out[0], out[1]}
+}
+
+// divSmall divides a multi-digit number (most significant digit first, each
+// digit digitBits bits wide) by a divisor of at most 32 bits using long
+// division. The quotient must fit in the digits except for the most significant
+// one, otherwise proving fails.
+func divSmall(g frontend.API, digits []frontend.Variable, divisor frontend.Variable, digitBits int) (quotient, remainder frontend.Variable) {
+	rangeChecker := rangecheck.New(g)
+	base := new(big.Int).Lsh(big.NewInt(1), uint(digitBits))
+	quotient, remainder = 0, 0
+	for i, digit := range digits {
+		cur := g.Add(g.Mul(remainder, base), digit)
+		out, err := g.Compiler().NewHint(QuoRemHint, 2, cur, divisor)
+		if err != nil {
+			panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+		}
+		q, r := out[0], out[1]
+		// the most significant digit of the quotient must be 0
+		if i == 0 && len(digits) > 1 {
+			g.AssertIsEqual(q, 0)
+		}
+		rangeChecker.Check(q, digitBits)
+		rangeChecker.Check(r, 32)
+		rangeChecker.Check(g.Sub(divisor, r, 1), 32)
+		g.AssertIsEqual(g.Add(g.Mul(q, divisor), r), cur)
+		quotient = g.Add(g.Mul(quotient, base), q)
+		remainder = r
+	}
+	return quotient, remainder
 }
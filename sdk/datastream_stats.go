@@ -0,0 +1,248 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// StatsVariable is the set of circuit variable types supported by the
+// statistical reducers
+type StatsVariable interface {
+	CircuitVariable
+	Uint248 | Int248 | Uint521
+}
+
+// maxStatsLen is the maximum data stream length supported by Variance and
+// WeightedMean. It keeps the Uint521 accumulators of squares and products of
+// 248-bit values from wrapping around.
+const maxStatsLen = 4095
+
+// Median returns the median of the valid elements in the data stream. For an
+// even number of elements, the lower one of the two middle elements is returned,
+// so the result is always an element of the data stream. Equivalent to
+// Percentile(ds, 50). Note if the data stream is empty (all data points are
+// toggled off), this function returns 0.
+func Median[T StatsVariable](ds *DataStream[T]) T {
+	return Percentile(ds, 50)
+}
+
+// Percentile returns the p-th percentile (p in [0, 100]) of the valid elements
+// in the data stream using the nearest-rank method, i.e. the ceil(p / 100 *
+// count)-th smallest element. Percentile(ds, 0) returns the minimum and
+// Percentile(ds, 100) returns the maximum. The result is computed by
+// OrderStatisticHint and verified in circuit by counting the elements that are
+// smaller than and equal to it. Note if the data stream is empty (all data
+// points are toggled off), this function returns 0.
+func Percentile[T StatsVariable](ds *DataStream[T], p int) T {
+	if p < 0 || p > 100 {
+		panic(fmt.Errorf("invalid percentile %d: must be in range [0, 100]", p))
+	}
+	g := ds.api.g
+	count := Count(ds).Val
+	// rank = ceil(count * p / 100)
+	out, err := g.Compiler().NewHint(QuoRemHint, 2, g.Add(g.Mul(count, p), 99), 100)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+	}
+	rank, rem := out[0], out[1]
+	g.AssertIsEqual(g.Add(g.Mul(rank, 100), rem), g.Add(g.Mul(count, p), 99))
+	rangeChecker := rangecheck.New(g)
+	rangeChecker.Check(rank, 32)
+	rangeChecker.Check(g.Sub(99, rem), 7)
+	// the 0-based index of the element in the sorted valid elements
+	k := g.Sub(rank, g.Sub(1, g.IsZero(rank)))
+	return orderStatistic(ds, k)
+}
+
+// Variance returns the population variance of the valid elements in the data
+// stream, rounded down. For Int248 the variance is computed on the signed values.
+// Uint521 elements must fit in 248 bits. The data stream can have at most 4095
+// elements. Note if the data stream is empty (all data points are toggled off),
+// this function returns 0.
+func Variance[T StatsVariable](ds *DataStream[T]) Uint521 {
+	if l := len(ds.underlying); l > maxStatsLen {
+		panic(fmt.Errorf("cannot compute variance of DataStream of length %d: must not exceed %d", l, maxStatsLen))
+	}
+	api := ds.api
+	zero := ConstUint521(0)
+	sum, sumSq := zero, zero
+	for i, v := range ds.underlying {
+		// variance is invariant under shifting, so signed values are shifted into
+		// unsigned ones
+		u := api.ToUint521(toUint248Offset(api, v, ds.toggles[i]))
+		valid := newU248(ds.toggles[i])
+		sum = api.Uint521.Add(sum, api.Uint521.Select(valid, u, zero))
+		sumSq = api.Uint521.Add(sumSq, api.Uint521.Select(valid, api.Uint521.Mul(u, u), zero))
+	}
+	count := Count(ds)
+	c := api.ToUint521(count)
+	// variance = (count * sum(x^2) - sum(x)^2) / count^2
+	num := api.Uint521.Sub(api.Uint521.Mul(c, sumSq), api.Uint521.Mul(sum, sum))
+	den := api.Uint521.Select(api.Uint248.IsZero(count), ConstUint521(1), api.Uint521.Mul(c, c))
+	variance, _ := api.Uint521.quoRem(num, den, 2*numBitsPerVar)
+	return variance
+}
+
+// WeightedMean returns sum(value * weight) / sum(weight) over the valid
+// elements in the data stream, rounded down. A typical use is the volume
+// weighted average price, where value returns the price and weight returns the
+// volume of a trade. Uint521 values must fit in 248 bits. The data stream can
+// have at most 4095 elements. Note if the data stream is empty or the weights
+// sum up to 0, this function returns 0.
+func WeightedMean[T CircuitVariable, V StatsVariable](
+	ds *DataStream[T],
+	value func(current T) V,
+	weight GetValueFunc[T],
+) V {
+	if l := len(ds.underlying); l > maxStatsLen {
+		panic(fmt.Errorf("cannot compute weighted mean of DataStream of length %d: must not exceed %d", l, maxStatsLen))
+	}
+	api := ds.api
+	zero := ConstUint521(0)
+	weightedSum, weightSum := zero, zero
+	for i, v := range ds.underlying {
+		u := api.ToUint521(toUint248Offset(api, value(v), ds.toggles[i]))
+		w := api.ToUint521(weight(v))
+		valid := newU248(ds.toggles[i])
+		weightedSum = api.Uint521.Add(weightedSum, api.Uint521.Select(valid, api.Uint521.Mul(u, w), zero))
+		weightSum = api.Uint521.Add(weightSum, api.Uint521.Select(valid, w, zero))
+	}
+	isZero := api.Uint521.IsEqual(weightSum, zero)
+	den := api.Uint521.Select(isZero, ConstUint521(1), weightSum)
+	// the weighted mean never exceeds the largest value, so the quotient fits in
+	// 248 bits
+	q, _ := api.Uint521.quoRem(weightedSum, den, numBitsPerVar)
+	mean := fromUint248Offset[V](api, api.ToUint248(q))
+	return Select(api, isZero, zeroOf[V](), mean)
+}
+
+// orderStatistic returns the k-th (0-based) smallest valid element of the data
+// stream, or 0 if the data stream is empty
+func orderStatistic[T StatsVariable](ds *DataStream[T], k frontend.Variable) T {
+	api := ds.api
+	g := api.g
+	keys := make([][]frontend.Variable, len(ds.underlying))
+	var limbBits int
+	for i, v := range ds.underlying {
+		keys[i], limbBits = orderedKey(api, v)
+	}
+	numLimbs := len(keys[0])
+	inputs := []frontend.Variable{numLimbs, limbBits, k}
+	for _, key := range keys {
+		inputs = append(inputs, key...)
+	}
+	inputs = append(inputs, ds.toggles...)
+	found, err := g.Compiler().NewHint(OrderStatisticHint, numLimbs, inputs...)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize OrderStatisticHint instance: %s", err.Error()))
+	}
+
+	// found is the k-th smallest iff there are at most k valid elements smaller
+	// than it and more than k valid elements smaller than or equal to it. The
+	// latter also guarantees found is one of the valid elements.
+	var countLess, countLessOrEqual frontend.Variable = 0, 0
+	for i, key := range keys {
+		lt, eq := cmpOrderedKeys(g, key, found, limbBits)
+		countLess = g.Add(countLess, g.Mul(ds.toggles[i], lt))
+		countLessOrEqual = g.Add(countLessOrEqual, g.Mul(ds.toggles[i], g.Add(lt, eq)))
+	}
+	nonEmpty := g.Sub(1, g.IsZero(Count(ds).Val))
+	rangeChecker := rangecheck.New(g)
+	rangeChecker.Check(g.Select(nonEmpty, g.Sub(k, countLess), 0), 32)
+	rangeChecker.Check(g.Select(nonEmpty, g.Sub(countLessOrEqual, k, 1), 0), 32)
+
+	return Select(api, newU248(nonEmpty), fromOrderedKey[T](api, found), zeroOf[T]())
+}
+
+// orderedKey maps v to a list of limbs (most significant limb first, each limb
+// limbBits bits wide) so that comparing the limbs lexicographically gives the
+// same result as comparing the values
+func orderedKey[T StatsVariable](api *CircuitAPI, v T) (limbs []frontend.Variable, limbBits int) {
+	switch v := any(v).(type) {
+	case Uint248:
+		return []frontend.Variable{v.Val}, numBitsPerVar
+	case Int248:
+		return []frontend.Variable{flipSignBit(api.g, v.Val)}, numBitsPerVar
+	case Uint521:
+		// limbs are compared as is, so v must be in its canonical form
+		return flipByGroups(api.Uint521.canonicalLimbs(v), 1), int(Uint521Field{}.BitsPerLimb())
+	}
+	panic(fmt.Errorf("unsupported ordered key type %T", v))
+}
+
+// fromOrderedKey is the reverse of orderedKey
+func fromOrderedKey[T StatsVariable](api *CircuitAPI, limbs []frontend.Variable) T {
+	var ret any
+	switch any(*new(T)).(type) {
+	case Uint248:
+		ret = newU248(limbs[0])
+	case Int248:
+		ret = newI248(flipSignBit(api.g, limbs[0]))
+	case Uint521:
+		ret = newU521(api.Uint521.f.NewElement(flipByGroups(limbs, 1)))
+	}
+	return ret.(T)
+}
+
+// cmpOrderedKeys returns whether a < b and whether a == b
+func cmpOrderedKeys(g frontend.API, a, b []frontend.Variable, limbBits int) (lt, eq frontend.Variable) {
+	var res frontend.Variable = 0
+	for i := range a {
+		c := Cmp(g, a[i], b[i], limbBits)
+		if i == 0 {
+			res = c
+		} else {
+			res = g.Select(g.IsZero(res), c, res)
+		}
+	}
+	return g.IsZero(g.Add(res, 1)), g.IsZero(res)
+}
+
+// toUint248Offset converts v to an unsigned value. Int248 values are shifted by
+// 2^247 so that the order and the differences between values are preserved.
+// Uint521 values must fit in 248 bits if toggled on.
+func toUint248Offset[T StatsVariable](api *CircuitAPI, v T, toggle frontend.Variable) Uint248 {
+	switch v := any(v).(type) {
+	case Uint248:
+		return v
+	case Int248:
+		return newU248(flipSignBit(api.g, v.Val))
+	case Uint521:
+		return api.ToUint248(api.Uint521.Select(newU248(toggle), v, ConstUint521(0)))
+	}
+	panic(fmt.Errorf("unsupported type %T", v))
+}
+
+// fromUint248Offset is the reverse of toUint248Offset
+func fromUint248Offset[T StatsVariable](api *CircuitAPI, u Uint248) T {
+	var ret any
+	switch any(*new(T)).(type) {
+	case Uint248:
+		ret = u
+	case Int248:
+		ret = newI248(flipSignBit(api.g, u.Val))
+	case Uint521:
+		ret = api.ToUint521(u)
+	}
+	return ret.(T)
+}
+
+func zeroOf[T Numeric]() T {
+	var ret any
+	switch any(*new(T)).(type) {
+	case Uint32:
+		ret = ConstUint32(0)
+	case Uint64:
+		ret = ConstUint64(0)
+	case Uint248:
+		ret = ConstUint248(0)
+	case Int248:
+		ret = ConstInt248(big.NewInt(0))
+	case Uint521:
+		ret = ConstUint521(0)
+	}
+	return ret.(T)
+}
@@ -0,0 +1,145 @@
+package sdk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestOrderStatisticHint(t *testing.T) {
+	// two 4-bit limbs per key: 0x12, 0x05, 0x31, 0x0f (toggled off), 0x11
+	in := []*big.Int{
+		big.NewInt(2), big.NewInt(4), big.NewInt(2),
+		big.NewInt(1), big.NewInt(2),
+		big.NewInt(0), big.NewInt(5),
+		big.NewInt(3), big.NewInt(1),
+		big.NewInt(0), big.NewInt(15),
+		big.NewInt(1), big.NewInt(1),
+		big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(0), big.NewInt(1),
+	}
+	out := []*big.Int{new(big.Int), new(big.Int)}
+	err := OrderStatisticHint(nil, in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[0].Int64() != 1 || out[1].Int64() != 2 {
+		t.Errorf("expected [1 2], got %v", out)
+	}
+}
+
+func TestStatsReducers(t *testing.T) {
+	two300 := new(big.Int).Lsh(big.NewInt(1), 300)
+	two260 := new(big.Int).Lsh(big.NewInt(1), 260)
+	c := &TestStatsReducersCircuit{
+		U248: DataPoints[Uint248]{
+			Raw:     newU248s(5, 1, 9, 3, 7, 100),
+			Toggles: []frontend.Variable{1, 1, 1, 1, 1, 0},
+		},
+		I248: DataPoints[Int248]{
+			Raw: []Int248{
+				ConstInt248(big.NewInt(-5)), ConstInt248(big.NewInt(3)), ConstInt248(big.NewInt(-1)),
+				ConstInt248(big.NewInt(10)), ConstInt248(big.NewInt(0)), ConstInt248(big.NewInt(-100)),
+			},
+			Toggles: []frontend.Variable{1, 1, 1, 1, 1, 0},
+		},
+		U521: DataPoints[Uint521]{
+			Raw:     []Uint521{ConstUint521(two300), ConstUint521(5), ConstUint521(two260), ConstUint521(7)},
+			Toggles: []frontend.Variable{1, 1, 1, 1},
+		},
+		SmallU521: DataPoints[Uint521]{
+			Raw: []Uint521{
+				ConstUint521(2), ConstUint521(4), ConstUint521(4), ConstUint521(4),
+				ConstUint521(5), ConstUint521(5), ConstUint521(7), ConstUint521(9), ConstUint521(two300),
+			},
+			Toggles: []frontend.Variable{1, 1, 1, 1, 1, 1, 1, 1, 0},
+		},
+		Trades: DataPoints[Tuple2[Uint248, Uint248]]{
+			Raw: []Tuple2[Uint248, Uint248]{
+				{ConstUint248(10), ConstUint248(1)},
+				{ConstUint248(20), ConstUint248(3)},
+				{ConstUint248(30), ConstUint248(0)},
+				{ConstUint248(40), ConstUint248(1)},
+				{ConstUint248(1000), ConstUint248(1000)},
+			},
+			Toggles: []frontend.Variable{1, 1, 1, 1, 0},
+		},
+		Neg: DataPoints[Int248]{
+			Raw:     []Int248{ConstInt248(big.NewInt(-3)), ConstInt248(big.NewInt(4)), ConstInt248(big.NewInt(-8))},
+			Toggles: []frontend.Variable{1, 1, 1},
+		},
+		Empty: DataPoints[Int248]{
+			Raw:     []Int248{ConstInt248(big.NewInt(-3)), ConstInt248(big.NewInt(4))},
+			Toggles: []frontend.Variable{0, 0},
+		},
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+}
+
+type TestStatsReducersCircuit struct {
+	U248      DataPoints[Uint248]
+	I248      DataPoints[Int248]
+	U521      DataPoints[Uint521]
+	SmallU521 DataPoints[Uint521]
+	Trades    DataPoints[Tuple2[Uint248, Uint248]]
+	Neg       DataPoints[Int248]
+	Empty     DataPoints[Int248]
+}
+
+func (c *TestStatsReducersCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	u248, i248, u521 := api.Uint248, api.Int248, api.Uint521
+
+	// valid elements sorted: 1, 3, 5, 7, 9
+	ds := NewDataStream(api, c.U248)
+	u248.AssertIsEqual(Median(ds), ConstUint248(5))
+	u248.AssertIsEqual(Percentile(ds, 0), ConstUint248(1))
+	u248.AssertIsEqual(Percentile(ds, 40), ConstUint248(3))
+	u248.AssertIsEqual(Percentile(ds, 95), ConstUint248(9))
+	u248.AssertIsEqual(Percentile(ds, 100), ConstUint248(9))
+	u521.AssertIsEqual(Variance(ds), ConstUint521(8))
+
+	// valid elements sorted: -5, -1, 0, 3, 10
+	ids := NewDataStream(api, c.I248)
+	i248.AssertIsEqual(Median(ids), ConstInt248(big.NewInt(0)))
+	i248.AssertIsEqual(Percentile(ids, 20), ConstInt248(big.NewInt(-5)))
+	i248.AssertIsEqual(Percentile(ids, 21), ConstInt248(big.NewInt(-1)))
+	i248.AssertIsEqual(Percentile(ids, 100), ConstInt248(big.NewInt(10)))
+	// (5 * 135 - 7^2) / 5^2 = 25.04
+	u521.AssertIsEqual(Variance(ids), ConstUint521(25))
+	mean := WeightedMean(ids, func(v Int248) Int248 { return v }, func(Int248) Uint248 { return ConstUint248(1) })
+	i248.AssertIsEqual(mean, ConstInt248(big.NewInt(1)))
+	// (-3 * 1 + 4 * 2 - 8 * 1) / 4 = -0.75 is rounded down to -1
+	neg := NewDataStream(api, c.Neg)
+	mean = WeightedMean(neg, func(v Int248) Int248 { return v }, func(v Int248) Uint248 {
+		return u248.Select(i248.IsLessThan(v, ConstInt248(big.NewInt(0))), ConstUint248(1), ConstUint248(2))
+	})
+	i248.AssertIsEqual(mean, ConstInt248(big.NewInt(-1)))
+
+	// valid elements sorted: 5, 7, 2^260, 2^300
+	bds := NewDataStream(api, c.U521)
+	u521.AssertIsEqual(Median(bds), ConstUint521(7))
+	u521.AssertIsEqual(Percentile(bds, 75), ConstUint521(new(big.Int).Lsh(big.NewInt(1), 260)))
+	u521.AssertIsEqual(Percentile(bds, 99), ConstUint521(new(big.Int).Lsh(big.NewInt(1), 300)))
+	sds := NewDataStream(api, c.SmallU521)
+	u521.AssertIsEqual(Variance(sds), ConstUint521(4))
+
+	// (10 * 1 + 20 * 3 + 30 * 0 + 40 * 1) / 5
+	trades := NewDataStream(api, c.Trades)
+	vwap := WeightedMean(trades,
+		func(t Tuple2[Uint248, Uint248]) Uint248 { return t.F0 },
+		func(t Tuple2[Uint248, Uint248]) Uint248 { return t.F1 })
+	u248.AssertIsEqual(vwap, ConstUint248(22))
+	vwap521 := WeightedMean(trades,
+		func(t Tuple2[Uint248, Uint248]) Uint521 { return api.ToUint521(t.F0) },
+		func(t Tuple2[Uint248, Uint248]) Uint248 { return ConstUint248(0) })
+	u521.AssertIsEqual(vwap521, ConstUint521(0))
+
+	empty := NewDataStream(api, c.Empty)
+	i248.AssertIsEqual(Median(empty), ConstInt248(big.NewInt(0)))
+	u521.AssertIsEqual(Variance(empty), ConstUint521(0))
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
+	"math"
+	"math/big"
 	"testing"
 )
 
@@ -37,6 +39,78 @@ func (c *TestGroupValuesHintCircuit) Define(api frontend.API) error {
 	return nil
 }
 
+func TestGroupSortHint(t *testing.T) {
+	inputs := []*big.Int{big.NewInt(5), big.NewInt(2), big.NewInt(5), big.NewInt(0), big.NewInt(2)}
+	outputs := make([]*big.Int, len(inputs))
+	for i := range outputs {
+		outputs[i] = new(big.Int)
+	}
+	err := GroupSortHint(nil, inputs, outputs)
+	check(err)
+	expected := []int64{3, 1, 4, 0, 2}
+	for i, o := range outputs {
+		if o.Int64() != expected[i] {
+			t.Errorf("index %d: expected %d, got %d", i, expected[i], o.Int64())
+		}
+	}
+}
+
+func TestGroupBySorted(t *testing.T) {
+	c := &TestGroupBySortedCircuit{
+		In: DataPoints[Tuple2[Uint248, Uint248]]{
+			Raw: []Tuple2[Uint248, Uint248]{
+				{ConstUint248(3), ConstUint248(10)},
+				{ConstUint248(1), ConstUint248(20)},
+				{ConstUint248(3), ConstUint248(30)},
+				{ConstUint248(2), ConstUint248(40)},
+				{ConstUint248(1), ConstUint248(50)},
+				{ConstUint248(2), ConstUint248(60)},
+				{ConstUint248(0), ConstUint248(0)},
+				{ConstUint248(0), ConstUint248(0)},
+			},
+			Toggles: []frontend.Variable{1, 1, 1, 0, 1, 0, 0, 0},
+		},
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+}
+
+type TestGroupBySortedCircuit struct {
+	In DataPoints[Tuple2[Uint248, Uint248]]
+}
+
+func (c *TestGroupBySortedCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	u248 := api.Uint248
+	in := NewDataStream(api, c.In)
+
+	reducer := func(acc Tuple2[Uint248, Uint248], curr Tuple2[Uint248, Uint248]) Tuple2[Uint248, Uint248] {
+		return Tuple2[Uint248, Uint248]{F0: curr.F0, F1: u248.Add(acc.F1, curr.F1)}
+	}
+	init := Tuple2[Uint248, Uint248]{F0: ConstUint248(0), F1: ConstUint248(0)}
+	getGroupField := func(t Tuple2[Uint248, Uint248]) Uint248 { return t.F0 }
+
+	// groups: 0 -> all toggled off, 1 -> [20, 50], 2 -> all toggled off, 3 -> [10, 30]
+	groups, err := GroupBySorted(in, reducer, init, getGroupField)
+	check(err)
+	u248.AssertIsEqual(Count(groups), ConstUint248(2))
+	sums := Map(groups, func(curr Tuple2[Uint248, Uint248]) Uint248 {
+		return u248.Add(u248.Mul(curr.F0, ConstUint248(1000)), curr.F1)
+	})
+	// 1070 + 3040
+	u248.AssertIsEqual(Sum(sums), ConstUint248(4110))
+	u248.AssertIsEqual(Max(sums), ConstUint248(3040))
+
+	filtered, err := GroupByFiltered(in, reducer, init, getGroupField)
+	check(err)
+	u248.AssertIsEqual(Count(filtered), ConstUint248(2))
+	sums = Map(filtered, func(curr Tuple2[Uint248, Uint248]) Uint248 {
+		return u248.Add(u248.Mul(curr.F0, ConstUint248(1000)), curr.F1)
+	})
+	u248.AssertIsEqual(Sum(sums), ConstUint248(4110))
+	return nil
+}
+
 func TestDataStream(t *testing.T) {
 	c := &TestDataStreamCircuit{
 		In: DataPoints[Uint248]{
@@ -286,3 +360,218 @@ func (c *TestComplexCircuit) Define(g frontend.API) error {
 	api.Uint248.AssertIsEqual(rowMin.F1, ConstUint248(200))
 	return nil
 }
+
+func TestNumericReducers(t *testing.T) {
+	two31 := ConstUint32(1 << 31)
+	big250 := new(big.Int).Lsh(big.NewInt(1), 250)
+	c := &TestNumericReducersCircuit{
+		U32: DataPoints[Uint32]{
+			Raw:     []Uint32{two31, two31, ConstUint32(7), ConstUint32(1)},
+			Toggles: []frontend.Variable{1, 0, 1, 1},
+		},
+		U64: DataPoints[Uint64]{
+			Raw:     []Uint64{ConstUint64(10), ConstUint64(3), ConstUint64(6)},
+			Toggles: []frontend.Variable{1, 1, 1},
+		},
+		U248: DataPoints[Uint248]{
+			Raw:     newU248s(MaxUint248, MaxUint248, MaxUint248, 1, 0),
+			Toggles: []frontend.Variable{1, 1, 1, 1, 0},
+		},
+		I248: DataPoints[Int248]{
+			Raw: []Int248{
+				ConstInt248(big.NewInt(-7)), ConstInt248(big.NewInt(2)),
+				ConstInt248(big.NewInt(-3)), ConstInt248(big.NewInt(100)),
+			},
+			Toggles: []frontend.Variable{1, 1, 1, 0},
+		},
+		U521: DataPoints[Uint521]{
+			Raw:     []Uint521{ConstUint521(big250), ConstUint521(big250), ConstUint521(4)},
+			Toggles: []frontend.Variable{1, 1, 1},
+		},
+		Empty: DataPoints[Uint64]{
+			Raw:     []Uint64{ConstUint64(1), ConstUint64(2)},
+			Toggles: []frontend.Variable{0, 0},
+		},
+		WideU32: DataPoints[Uint32]{
+			Raw:     []Uint32{two31, two31, ConstUint32(1<<32 - 1)},
+			Toggles: []frontend.Variable{1, 1, 1},
+		},
+		WideU64: DataPoints[Uint64]{
+			Raw:     []Uint64{ConstUint64(uint64(math.MaxUint64)), ConstUint64(uint64(math.MaxUint64 - 4))},
+			Toggles: []frontend.Variable{1, 1},
+		},
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	// 2^32 + 8 does not fit in Uint32
+	c.U32.Toggles = []frontend.Variable{1, 1, 1, 1}
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected Sum to fail on overflow")
+	}
+}
+
+type TestNumericReducersCircuit struct {
+	U32   DataPoints[Uint32]
+	U64   DataPoints[Uint64]
+	U248  DataPoints[Uint248]
+	I248  DataPoints[Int248]
+	U521  DataPoints[Uint521]
+	Empty DataPoints[Uint64]
+	// sums that overflow their types
+	WideU32 DataPoints[Uint32]
+	WideU64 DataPoints[Uint64]
+}
+
+func (c *TestNumericReducersCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+
+	u32 := NewDataStream(api, c.U32)
+	api.Uint32.AssertIsEqual(Min(u32), ConstUint32(1))
+	api.Uint32.AssertIsEqual(Max(u32), ConstUint32(1<<31))
+	api.Uint32.AssertIsEqual(Sum(u32), ConstUint32(1<<31+8))
+	mean32, rem := MeanWithRemainder(u32)
+	api.Uint32.AssertIsEqual(mean32, ConstUint32(715827885))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(1))
+
+	u64 := NewDataStream(api, c.U64)
+	api.Uint64.AssertIsEqual(Min(u64), ConstUint64(3))
+	api.Uint64.AssertIsEqual(Max(u64), ConstUint64(10))
+	api.Uint64.AssertIsEqual(Sum(u64), ConstUint64(19))
+	mean64, rem := MeanWithRemainder(u64)
+	api.Uint64.AssertIsEqual(mean64, ConstUint64(6))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(1))
+
+	// the sum overflows Uint248, but the mean is still exact
+	u248 := NewDataStream(api, c.U248)
+	api.Uint248.AssertIsEqual(Min(u248), ConstUint248(1))
+	api.Uint248.AssertIsEqual(Max(u248), ConstUint248(MaxUint248))
+	mean248, rem := MeanWithRemainder(u248)
+	// (3 * (2^248 - 1) + 1) / 4 = 3 * 2^246 - 1 remainder 2
+	api.Uint248.AssertIsEqual(mean248, ConstUint248(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(3), 246), big.NewInt(1))))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(2))
+
+	i248 := NewDataStream(api, c.I248)
+	api.Int248.AssertIsEqual(Min(i248), ConstInt248(big.NewInt(-7)))
+	api.Int248.AssertIsEqual(Max(i248), ConstInt248(big.NewInt(2)))
+	api.Int248.AssertIsEqual(Sum(i248), ConstInt248(big.NewInt(-8)))
+	// -8 / 3 = -3 remainder 1
+	meanI248, rem := MeanWithRemainder(i248)
+	api.Int248.AssertIsEqual(meanI248, ConstInt248(big.NewInt(-3)))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(1))
+
+	u521 := NewDataStream(api, c.U521)
+	big250 := new(big.Int).Lsh(big.NewInt(1), 250)
+	api.Uint521.AssertIsEqual(Min(u521), ConstUint521(4))
+	api.Uint521.AssertIsEqual(Max(u521), ConstUint521(big250))
+	api.Uint521.AssertIsEqual(Sum(u521), ConstUint521(new(big.Int).Add(new(big.Int).Lsh(big250, 1), big.NewInt(4))))
+	mean521, rem := MeanWithRemainder(u521)
+	// (2^251 + 4) / 3
+	api.Uint521.AssertIsEqual(mean521, ConstUint521(new(big.Int).Div(new(big.Int).Add(new(big.Int).Lsh(big250, 1), big.NewInt(4)), big.NewInt(3))))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(new(big.Int).Mod(new(big.Int).Add(new(big.Int).Lsh(big250, 1), big.NewInt(4)), big.NewInt(3))))
+
+	empty := NewDataStream(api, c.Empty)
+	api.Uint64.AssertIsEqual(Min(empty), ConstUint64(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1))))
+	api.Uint64.AssertIsEqual(Max(empty), ConstUint64(0))
+	meanEmpty, rem := MeanWithRemainder(empty)
+	api.Uint64.AssertIsEqual(meanEmpty, ConstUint64(0))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(0))
+
+	// (2^32 + 2^32 - 1) / 3 = 2863311530 remainder 1
+	meanWide32, rem := MeanWithRemainder(NewDataStream(api, c.WideU32))
+	api.Uint32.AssertIsEqual(meanWide32, ConstUint32(2863311530))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(1))
+	// (2^65 - 6) / 2 = 2^64 - 3
+	meanWide64, rem := MeanWithRemainder(NewDataStream(api, c.WideU64))
+	api.Uint64.AssertIsEqual(meanWide64, ConstUint64(uint64(math.MaxUint64-2)))
+	api.Uint248.AssertIsEqual(rem, ConstUint248(0))
+	return nil
+}
+
+func TestScan(t *testing.T) {
+	c := &TestScanCircuit{
+		U248: DataPoints[Uint248]{
+			Raw:     newU248s(1, 2, 100, 3, 4),
+			Toggles: []frontend.Variable{1, 1, 0, 1, 1},
+		},
+		I248: DataPoints[Int248]{
+			Raw: []Int248{
+				ConstInt248(big.NewInt(5)), ConstInt248(big.NewInt(-8)),
+				ConstInt248(big.NewInt(99)), ConstInt248(big.NewInt(-2)),
+			},
+			Toggles: []frontend.Variable{1, 1, 0, 1},
+		},
+		U521: DataPoints[Uint521]{
+			Raw:     []Uint521{ConstUint521(3), ConstUint521(1), ConstUint521(10)},
+			Toggles: []frontend.Variable{0, 1, 1},
+		},
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	// the running sums must be non-decreasing to compute Diff of Uint248
+	c.U248.Toggles = []frontend.Variable{1, 1, 1, 1, 1}
+	c.U248.Raw[2] = ConstUint248(0)
+	c.CheckDiffOfRaw = true
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected Diff to fail on a decreasing data stream")
+	}
+}
+
+type TestScanCircuit struct {
+	U248           DataPoints[Uint248]
+	I248           DataPoints[Int248]
+	U521           DataPoints[Uint521]
+	CheckDiffOfRaw bool `gnark:"-"`
+}
+
+func (c *TestScanCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	u248 := api.Uint248
+
+	ds := NewDataStream(api, c.U248)
+	if c.CheckDiffOfRaw {
+		Diff(ds)
+		return nil
+	}
+	// running max: [1, 2, _, 3, 4]
+	maxes := Scan(ds, ConstUint248(0), func(acc Uint248, curr Uint248) Uint248 {
+		return u248.Select(u248.IsGreaterThan(curr, acc), curr, acc)
+	})
+	u248.AssertIsEqual(Count(maxes), ConstUint248(4))
+	u248.AssertIsEqual(GetUnderlying(maxes, 1), ConstUint248(2))
+	u248.AssertIsEqual(GetUnderlying(maxes, 4), ConstUint248(4))
+
+	// [1, 3, _, 6, 10]
+	sums := CumSum(ds)
+	u248.AssertIsEqual(GetUnderlying(sums, 1), ConstUint248(3))
+	u248.AssertIsEqual(GetUnderlying(sums, 3), ConstUint248(6))
+	u248.AssertIsEqual(GetUnderlying(sums, 4), ConstUint248(10))
+	// [_, 2, _, 3, 4]
+	diffs := Diff(sums)
+	u248.AssertIsEqual(Count(diffs), ConstUint248(3))
+	u248.AssertIsEqual(Sum(diffs), ConstUint248(9))
+	u248.AssertIsEqual(Min(diffs), ConstUint248(2))
+
+	// [5, -3, _, -5]
+	ids := NewDataStream(api, c.I248)
+	isums := CumSum(ids)
+	api.Int248.AssertIsEqual(GetUnderlying(isums, 1), ConstInt248(big.NewInt(-3)))
+	api.Int248.AssertIsEqual(GetUnderlying(isums, 3), ConstInt248(big.NewInt(-5)))
+	// [_, -13, _, 6]
+	idiffs := Diff(ids)
+	api.Int248.AssertIsEqual(GetUnderlying(idiffs, 1), ConstInt248(big.NewInt(-13)))
+	api.Int248.AssertIsEqual(GetUnderlying(idiffs, 3), ConstInt248(big.NewInt(6)))
+	api.Int248.AssertIsEqual(Sum(idiffs), ConstInt248(big.NewInt(-7)))
+
+	// [_, 1, 11] -> [_, _, 10]
+	bds := NewDataStream(api, c.U521)
+	bsums := CumSum(bds)
+	api.Uint521.AssertIsEqual(GetUnderlying(bsums, 2), ConstUint521(11))
+	bdiffs := Diff(bsums)
+	u248.AssertIsEqual(Count(bdiffs), ConstUint248(1))
+	api.Uint521.AssertIsEqual(Sum(bdiffs), ConstUint521(10))
+	return nil
+}
@@ -0,0 +1,321 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PackedField describes a field packed in a 32-byte word
+type PackedField struct {
+	// Optional name of the field
+	Name string
+	// The position of the least significant bit of the field in the word
+	Offset int
+	// The number of bits the field occupies, must not exceed 248
+	Width int
+	// Whether the field is a two's complement signed integer
+	Signed bool
+}
+
+// PackedLayout describes how multiple fields are packed in one 32-byte storage
+// word. It can either be constructed directly from offsets and bit widths or
+// parsed from a solidity struct fragment using NewPackedLayout.
+type PackedLayout []PackedField
+
+var packedFieldRegex = regexp.MustCompile(`^(u?int|bytes)(\d*)$`)
+
+// NewPackedLayout parses a comma separated list of solidity types with
+// optional names, e.g. "uint160 sqrtPriceX96, int24 tick, bool unlocked", and
+// lays them out the same way solidity packs state variables and struct members
+// into a storage slot: the first field occupies the lowest-order bits and each
+// following field is placed right after the previous one. Supported types are
+// uintN, intN, bytesN, address, and bool. Types wider than 248 bits occupy a
+// whole slot and should be read directly as Bytes32 instead.
+//
+// https://docs.soliditylang.org/en/v0.8.24/internals/layout_in_storage.html
+func NewPackedLayout(fragment string) (PackedLayout, error) {
+	var layout PackedLayout
+	offset := 0
+	for _, decl := range strings.Split(fragment, ",") {
+		parts := strings.Fields(decl)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("invalid field declaration %q", strings.TrimSpace(decl))
+		}
+		width, signed, err := packedFieldWidth(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		if width > numBitsPerVar {
+			return nil, fmt.Errorf("field type %s is wider than %d bits", parts[0], numBitsPerVar)
+		}
+		if offset+width > 256 {
+			return nil, fmt.Errorf("field type %s does not fit in the remaining %d bits of the slot", parts[0], 256-offset)
+		}
+		f := PackedField{Offset: offset, Width: width, Signed: signed}
+		if len(parts) == 2 {
+			f.Name = parts[1]
+		}
+		layout = append(layout, f)
+		offset += width
+	}
+	return layout, nil
+}
+
+func packedFieldWidth(typ string) (width int, signed bool, err error) {
+	switch typ {
+	case "bool":
+		return 8, false, nil
+	case "address":
+		return 160, false, nil
+	}
+	m := packedFieldRegex.FindStringSubmatch(typ)
+	if m == nil {
+		return 0, false, fmt.Errorf("unsupported field type %s", typ)
+	}
+	if m[2] == "" {
+		if m[1] == "bytes" {
+			return 0, false, fmt.Errorf("unsupported field type %s", typ)
+		}
+		return 256, false, nil
+	}
+	var size int
+	fmt.Sscan(m[2], &size)
+	if m[1] == "bytes" {
+		if size < 1 || size > 32 {
+			return 0, false, fmt.Errorf("invalid field type %s", typ)
+		}
+		return size * 8, false, nil
+	}
+	if size < 8 || size > 256 || size%8 != 0 {
+		return 0, false, fmt.Errorf("invalid field type %s", typ)
+	}
+	return size, m[1] == "int", nil
+}
+
+// IndexOf returns the index of the field with the given name, or -1 if there is
+// no such field
+func (l PackedLayout) IndexOf(name string) int {
+	for i, f := range l {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l PackedLayout) validate() {
+	for _, f := range l {
+		if f.Width <= 0 || f.Width > numBitsPerVar || f.Offset < 0 || f.Offset+f.Width > 256 {
+			panic(fmt.Errorf("invalid packed field %+v: must satisfy 0 < width <= %d and offset + width <= 256", f, numBitsPerVar))
+		}
+	}
+}
+
+// Decode extracts the fields from the 32-byte word v. It is the out-of-circuit
+// counterpart of CircuitAPI.UnpackBytes32. Signed fields are returned as
+// negative numbers if their sign bit is set.
+func (l PackedLayout) Decode(v common.Hash) []*big.Int {
+	l.validate()
+	word := new(big.Int).SetBytes(v[:])
+	ret := make([]*big.Int, len(l))
+	for i, f := range l {
+		fv := new(big.Int).Rsh(word, uint(f.Offset))
+		fv.And(fv, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(f.Width)), big.NewInt(1)))
+		if f.Signed && fv.Bit(f.Width-1) == 1 {
+			fv.Sub(fv, new(big.Int).Lsh(big.NewInt(1), uint(f.Width)))
+		}
+		ret[i] = fv
+	}
+	return ret
+}
+
+// UnpackBytes32 extracts the fields described by layout from the Bytes32 value
+// v, which is typically the value of a storage slot. Signed fields are sign
+// extended to 248 bits, so they can be cast to Int248 using api.ToInt248.
+// Example: reading slot0 of a Uniswap V3 pool
+//
+//	layout, _ := NewPackedLayout("uint160 sqrtPriceX96, int24 tick, uint16 observationIndex, " +
+//		"uint16 observationCardinality, uint16 observationCardinalityNext, uint8 feeProtocol, bool unlocked")
+//	fields := api.UnpackBytes32(slot.Value, layout)
+//	tick := api.ToInt248(fields[layout.IndexOf("tick")])
+func (api *CircuitAPI) UnpackBytes32(v Bytes32, layout PackedLayout) List[Uint248] {
+	layout.validate()
+	bits := v.toBinaryVars(api.g)
+	ret := make(List[Uint248], len(layout))
+	for i, f := range layout {
+		fieldBits := make([]variable, numBitsPerVar)
+		copy(fieldBits, bits[f.Offset:f.Offset+f.Width])
+		for j := f.Width; j < numBitsPerVar; j++ {
+			if f.Signed {
+				fieldBits[j] = fieldBits[f.Width-1]
+			} else {
+				fieldBits[j] = 0
+			}
+		}
+		ret[i] = newU248(api.g.FromBinary(fieldBits...))
+	}
+	return ret
+}
+
+// DecodeABIUint decodes an ABI encoded uintN value, e.g. a log field of type
+// uint128, and asserts that the encoding is valid, i.e. the value fits in
+// bitSize bits. bitSize must not exceed 248.
+func (api *CircuitAPI) DecodeABIUint(word Bytes32, bitSize int) Uint248 {
+	if bitSize <= 0 || bitSize > numBitsPerVar {
+		panic(fmt.Errorf("invalid bit size %d: must be in range (0, %d]", bitSize, numBitsPerVar))
+	}
+	api.g.AssertIsEqual(word.Val[1], 0)
+	rangecheck.New(api.g).Check(word.Val[0], bitSize)
+	return newU248(word.Val[0])
+}
+
+// DecodeABIInt decodes an ABI encoded intN value, e.g. a log field of type
+// int24, and asserts that the encoding is valid, i.e. the word is the sign
+// extension of a bitSize-bit two's complement integer. bitSize must not exceed
+// 248.
+func (api *CircuitAPI) DecodeABIInt(word Bytes32, bitSize int) Int248 {
+	if bitSize <= 0 || bitSize > numBitsPerVar {
+		panic(fmt.Errorf("invalid bit size %d: must be in range (0, %d]", bitSize, numBitsPerVar))
+	}
+	g := api.g
+	v := api.ToInt248(word)
+	signBit := g.ToBinary(word.Val[1], 32*8-numBitsPerVar)[7]
+	// the signed value plus 2^(bitSize-1) must be in range [0, 2^bitSize)
+	shifted := g.Sub(g.Add(v.Val, new(big.Int).Lsh(big.NewInt(1), uint(bitSize-1))),
+		g.Mul(signBit, new(big.Int).Lsh(big.NewInt(1), uint(numBitsPerVar))))
+	rangecheck.New(g).Check(shifted, bitSize)
+	return newI248(v.Val, signBit)
+}
+
+// DecodeABIAddress decodes an ABI encoded address and asserts that the
+// encoding is valid
+func (api *CircuitAPI) DecodeABIAddress(word Bytes32) Uint248 {
+	return api.DecodeABIUint(word, 160)
+}
+
+// DecodeABIBool decodes an ABI encoded bool and asserts that the encoding is
+// valid, i.e. the word is either 0 or 1
+func (api *CircuitAPI) DecodeABIBool(word Bytes32) Uint248 {
+	api.g.AssertIsEqual(word.Val[1], 0)
+	api.g.AssertIsBoolean(word.Val[0])
+	return newU248(word.Val[0])
+}
+
+// DecodeABIOffset decodes the head of a dynamic type (bytes, string, or T[])
+// in ABI encoded event data, which is the byte offset of the dynamic value
+// relative to the start of the data. It returns the field index of the word
+// where the dynamic value starts, i.e. the word storing its length. The n-th
+// content word of the dynamic value is at index + 1 + n. Asserts that the offset
+// is a multiple of 32 and less than 2^32.
+func (api *CircuitAPI) DecodeABIOffset(word Bytes32) Uint248 {
+	g := api.g
+	g.AssertIsEqual(word.Val[1], 0)
+	bits := g.ToBinary(word.Val[0], 32)
+	for _, b := range bits[:5] {
+		g.AssertIsEqual(b, 0)
+	}
+	return newU248(g.FromBinary(bits[5:]...))
+}
+
+// DecodeABIWord decodes an ABI encoded 32-byte word of the given solidity type
+// out of circuit. It is the counterpart of the DecodeABIXXX circuit APIs and
+// checks the encoding the same way. Supported types are uintN, intN, address,
+// and bool. intN values are returned as negative numbers if they are negative.
+func DecodeABIWord(word common.Hash, typ string) (*big.Int, error) {
+	t, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %s: %s", typ, err.Error())
+	}
+	v := new(big.Int).SetBytes(word[:])
+	switch t.T {
+	case abi.UintTy:
+		if v.BitLen() > t.Size {
+			return nil, fmt.Errorf("invalid %s encoding %s: value overflows", typ, word.Hex())
+		}
+		return v, nil
+	case abi.AddressTy:
+		if v.BitLen() > 160 {
+			return nil, fmt.Errorf("invalid %s encoding %s: value overflows", typ, word.Hex())
+		}
+		return v, nil
+	case abi.BoolTy:
+		if v.Cmp(big.NewInt(1)) > 0 {
+			return nil, fmt.Errorf("invalid %s encoding %s: value must be 0 or 1", typ, word.Hex())
+		}
+		return v, nil
+	case abi.IntTy:
+		if v.Bit(255) == 1 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		bound := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+		if v.Cmp(bound) >= 0 || v.Cmp(new(big.Int).Neg(bound)) < 0 {
+			return nil, fmt.Errorf("invalid %s encoding %s: value overflows", typ, word.Hex())
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s: only uintN, intN, address, and bool are supported", typ)
+}
+
+// ABIDynamicFieldIndices locates a dynamic value of the given solidity type in
+// ABI encoded event data, so that its words can be added to a ReceiptData as
+// LogFieldData. headIndex is the field index of the head word which stores the
+// offset of the value. Returns the field index of the length word, which
+// matches the result of CircuitAPI.DecodeABIOffset, and the field indices of
+// the content words. Supported types are bytes, string, and arrays of single
+// word static types such as uint256[] and address[].
+func ABIDynamicFieldIndices(data []byte, headIndex uint, typ string) (lengthIndex uint, contentIndices []uint, err error) {
+	t, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid type %s: %s", typ, err.Error())
+	}
+	wordAt := func(index uint64) (*big.Int, error) {
+		if index*32+32 > uint64(len(data)) {
+			return nil, fmt.Errorf("word %d is out of range of data length %d", index, len(data))
+		}
+		return new(big.Int).SetBytes(data[index*32 : index*32+32]), nil
+	}
+	offset, err := wordAt(uint64(headIndex))
+	if err != nil {
+		return 0, nil, err
+	}
+	if !offset.IsUint64() || offset.Uint64() >= 1<<32 || offset.Uint64()%32 != 0 {
+		return 0, nil, fmt.Errorf("invalid offset %s at field index %d", offset, headIndex)
+	}
+	start := offset.Uint64() / 32
+	length, err := wordAt(start)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length.BitLen() > 32 {
+		return 0, nil, fmt.Errorf("invalid length %s at field index %d", length, start)
+	}
+	var numWords uint64
+	switch t.T {
+	case abi.BytesTy, abi.StringTy:
+		numWords = (length.Uint64() + 31) / 32
+	case abi.SliceTy:
+		switch t.Elem.T {
+		case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy, abi.HashTy:
+			numWords = length.Uint64()
+		default:
+			return 0, nil, fmt.Errorf("unsupported type %s: array elements must be single word static types", typ)
+		}
+	default:
+		return 0, nil, fmt.Errorf("unsupported type %s: only bytes, string, and T[] are supported", typ)
+	}
+	if (start+1+numWords)*32 > uint64(len(data)) {
+		return 0, nil, fmt.Errorf("%s value of %d words at field index %d is out of range of data length %d",
+			typ, numWords, start+1, len(data))
+	}
+	contentIndices = make([]uint, numWords)
+	for i := range contentIndices {
+		contentIndices[i] = uint(start) + 1 + uint(i)
+	}
+	return uint(start), contentIndices, nil
+}
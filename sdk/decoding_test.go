@@ -0,0 +1,201 @@
+package sdk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const slot0Fragment = "uint160 sqrtPriceX96, int24 tick, uint16 observationIndex, " +
+	"uint16 observationCardinality, uint16 observationCardinalityNext, uint8 feeProtocol, bool unlocked"
+
+var (
+	testSqrtPriceX96, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970341", 10)
+	testSlot0Fields     = []*big.Int{
+		testSqrtPriceX96, big.NewInt(-200000), big.NewInt(5), big.NewInt(100), big.NewInt(200), big.NewInt(0x44), big.NewInt(1),
+	}
+)
+
+func testSlot0(t *testing.T) (PackedLayout, common.Hash) {
+	layout, err := NewPackedLayout(slot0Fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	word := new(big.Int)
+	for i, f := range layout {
+		v := new(big.Int).Set(testSlot0Fields[i])
+		if v.Sign() < 0 {
+			v.Add(v, new(big.Int).Lsh(big.NewInt(1), uint(f.Width)))
+		}
+		word.Or(word, v.Lsh(v, uint(f.Offset)))
+	}
+	return layout, common.BigToHash(word)
+}
+
+func TestNewPackedLayout(t *testing.T) {
+	layout, _ := testSlot0(t)
+	expected := []PackedField{
+		{"sqrtPriceX96", 0, 160, false},
+		{"tick", 160, 24, true},
+		{"observationIndex", 184, 16, false},
+		{"observationCardinality", 200, 16, false},
+		{"observationCardinalityNext", 216, 16, false},
+		{"feeProtocol", 232, 8, false},
+		{"unlocked", 240, 8, false},
+	}
+	if len(layout) != len(expected) {
+		t.Fatalf("expected %d fields, got %d", len(expected), len(layout))
+	}
+	for i := range expected {
+		if layout[i] != expected[i] {
+			t.Errorf("field %d: expected %+v, got %+v", i, expected[i], layout[i])
+		}
+	}
+	if layout.IndexOf("tick") != 1 || layout.IndexOf("liquidity") != -1 {
+		t.Error("IndexOf returned wrong index")
+	}
+
+	for _, fragment := range []string{"uint256 x", "uint128 a, uint128 b, bool c", "uint7 x", "string s", "address a b"} {
+		if _, err := NewPackedLayout(fragment); err == nil {
+			t.Errorf("expected error for %q", fragment)
+		}
+	}
+}
+
+func TestPackedLayoutDecode(t *testing.T) {
+	layout, word := testSlot0(t)
+	decoded := layout.Decode(word)
+	for i, v := range decoded {
+		if v.Cmp(testSlot0Fields[i]) != 0 {
+			t.Errorf("field %d: expected %s, got %s", i, testSlot0Fields[i], v)
+		}
+	}
+}
+
+func TestDecodeABIWord(t *testing.T) {
+	minusOne := common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	cases := []struct {
+		word     common.Hash
+		typ      string
+		expected *big.Int
+	}{
+		{common.BigToHash(big.NewInt(255)), "uint8", big.NewInt(255)},
+		{common.BigToHash(big.NewInt(256)), "uint8", nil},
+		{minusOne, "int24", big.NewInt(-1)},
+		{minusOne, "uint256", new(big.Int).SetBytes(minusOne[:])},
+		{common.HexToHash("0xff800000"), "int24", nil},
+		{common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffff800000"), "int24", big.NewInt(-(1 << 23))},
+		{common.BigToHash(big.NewInt(1)), "bool", big.NewInt(1)},
+		{common.BigToHash(big.NewInt(2)), "bool", nil},
+		{minusOne, "address", nil},
+		{minusOne, "bytes32", nil},
+	}
+	for _, c := range cases {
+		v, err := DecodeABIWord(c.word, c.typ)
+		if c.expected == nil {
+			if err == nil {
+				t.Errorf("expected error decoding %s as %s, got %s", c.word.Hex(), c.typ, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("decoding %s as %s: %s", c.word.Hex(), c.typ, err.Error())
+		} else if v.Cmp(c.expected) != 0 {
+			t.Errorf("decoding %s as %s: expected %s, got %s", c.word.Hex(), c.typ, c.expected, v)
+		}
+	}
+}
+
+func TestABIDynamicFieldIndices(t *testing.T) {
+	// abi.encode(uint256 7, string "hello", uint256[] [1, 2])
+	var data []byte
+	for _, w := range []int64{7, 0x60, 0xa0, 5, 0, 2, 1, 2} {
+		data = append(data, common.BigToHash(big.NewInt(w)).Bytes()...)
+	}
+	copy(data[4*32:], "hello")
+
+	lengthIndex, contentIndices, err := ABIDynamicFieldIndices(data, 1, "string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lengthIndex != 3 || len(contentIndices) != 1 || contentIndices[0] != 4 {
+		t.Errorf("string: got length index %d, content indices %v", lengthIndex, contentIndices)
+	}
+	lengthIndex, contentIndices, err = ABIDynamicFieldIndices(data, 2, "uint256[]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lengthIndex != 5 || len(contentIndices) != 2 || contentIndices[0] != 6 || contentIndices[1] != 7 {
+		t.Errorf("uint256[]: got length index %d, content indices %v", lengthIndex, contentIndices)
+	}
+
+	if _, _, err = ABIDynamicFieldIndices(data, 0, "bytes"); err == nil {
+		t.Error("expected error for misaligned offset")
+	}
+	if _, _, err = ABIDynamicFieldIndices(data[:7*32], 2, "uint256[]"); err == nil {
+		t.Error("expected error for truncated data")
+	}
+	if _, _, err = ABIDynamicFieldIndices(data, 1, "uint256"); err == nil {
+		t.Error("expected error for static type")
+	}
+}
+
+func TestDecodingAPIs(t *testing.T) {
+	layout, word := testSlot0(t)
+	c := &TestDecodingAPIsCircuit{
+		layout:  layout,
+		Slot0:   ConstFromBigEndianBytes(word[:]),
+		Uint:    ConstFromBigEndianBytes(common.BigToHash(big.NewInt(1000)).Bytes()),
+		Int:     ConstFromBigEndianBytes(common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffff800000").Bytes()),
+		Bool:    ConstFromBigEndianBytes(common.BigToHash(big.NewInt(1)).Bytes()),
+		Offset:  ConstFromBigEndianBytes(common.BigToHash(big.NewInt(0xa0)).Bytes()),
+		Invalid: ConstFromBigEndianBytes(common.HexToHash("0xff800000").Bytes()),
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	c.Op = "int"
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected invalid int24 encoding to fail")
+	}
+	c.Op = "offset"
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected misaligned offset to fail")
+	}
+}
+
+type TestDecodingAPIsCircuit struct {
+	layout                                  PackedLayout
+	Slot0, Uint, Int, Bool, Offset, Invalid Bytes32
+	Op                                      string
+}
+
+func (c *TestDecodingAPIsCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	fields := api.UnpackBytes32(c.Slot0, c.layout)
+	api.Uint248.AssertIsEqual(fields[0], ConstUint248(testSqrtPriceX96))
+	api.Int248.AssertIsEqual(api.ToInt248(fields[c.layout.IndexOf("tick")]), ConstInt248(big.NewInt(-200000)))
+	for i := 2; i < len(fields); i++ {
+		api.Uint248.AssertIsEqual(fields[i], ConstUint248(testSlot0Fields[i]))
+	}
+
+	api.Uint248.AssertIsEqual(api.DecodeABIUint(c.Uint, 16), ConstUint248(1000))
+	api.Int248.AssertIsEqual(api.DecodeABIInt(c.Int, 24), ConstInt248(big.NewInt(-(1 << 23))))
+	api.Uint248.AssertIsEqual(api.DecodeABIBool(c.Bool), ConstUint248(1))
+	api.Uint248.AssertIsEqual(api.DecodeABIAddress(c.Uint), ConstUint248(1000))
+	api.Uint248.AssertIsEqual(api.DecodeABIOffset(c.Offset), ConstUint248(5))
+
+	switch c.Op {
+	case "int":
+		api.DecodeABIInt(c.Invalid, 24)
+	case "offset":
+		api.DecodeABIOffset(c.Uint)
+	}
+	return nil
+}
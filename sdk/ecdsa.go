@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_emulated"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ECDSASignature is an in-circuit representation of a secp256k1 ECDSA
+// signature in the go-ethereum [R || S || V] format. V is the recovery id, i.e.
+// the parity of the y coordinate of the point R, and must be 0 or 1.
+type ECDSASignature struct {
+	R Bytes32
+	S Bytes32
+	V Uint248
+}
+
+// ECDSAPublicKey is an in-circuit representation of an uncompressed secp256k1
+// public key
+type ECDSAPublicKey struct {
+	X Bytes32
+	Y Bytes32
+}
+
+// ParseECDSASignature converts a 65-byte signature produced by go-ethereum's
+// crypto.Sign (or an eth_sign style signature where V is 27 or 28) to an
+// ECDSASignature. This function does not generate circuit wires and should only
+// be used outside of circuit.
+func ParseECDSASignature(sig []byte) (ECDSASignature, error) {
+	if len(sig) != crypto.SignatureLength {
+		return ECDSASignature{}, fmt.Errorf("invalid signature length %d, expected %d", len(sig), crypto.SignatureLength)
+	}
+	v := sig[crypto.RecoveryIDOffset]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return ECDSASignature{}, fmt.Errorf("invalid signature recovery id %d", sig[crypto.RecoveryIDOffset])
+	}
+	return ECDSASignature{
+		R: ConstFromBigEndianBytes(sig[:32]),
+		S: ConstFromBigEndianBytes(sig[32:64]),
+		V: ConstUint248(v),
+	}, nil
+}
+
+// ConstECDSAPublicKey initializes a constant ECDSAPublicKey. This function does
+// not generate circuit wires and should only be used outside of circuit.
+func ConstECDSAPublicKey(pub *ecdsa.PublicKey) ECDSAPublicKey {
+	return ECDSAPublicKey{
+		X: ConstFromBigEndianBytes(pub.X.Bytes()),
+		Y: ConstFromBigEndianBytes(pub.Y.Bytes()),
+	}
+}
+
+// VerifyECDSA asserts that sig is a valid secp256k1 signature of msgHash by
+// pubkey. msgHash is the 32-byte digest that was signed, e.g. the EIP-191 or
+// EIP-712 hash of the message. sig.V is not used. Like the ecrecover
+// precompile, signatures with a high S value are accepted.
+func (api *CircuitAPI) VerifyECDSA(msgHash Bytes32, sig ECDSASignature, pubkey ECDSAPublicKey) {
+	api.verifyECDSA(msgHash, sig, pubkey)
+}
+
+// RecoverAddress returns the address that signed msgHash with sig, i.e. the
+// in-circuit equivalent of solidity's ecrecover. Unlike ecrecover, proving
+// fails if the signature is invalid instead of returning the zero address.
+// Uses ECRecoverHint
+func (api *CircuitAPI) RecoverAddress(msgHash Bytes32, sig ECDSASignature) Uint248 {
+	g := api.g
+	out, err := g.Compiler().NewHint(ECRecoverHint, 4,
+		msgHash.Val[0], msgHash.Val[1], sig.R.Val[0], sig.R.Val[1], sig.S.Val[0], sig.S.Val[1], sig.V.Val)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize ECRecoverHint instance: %s", err.Error()))
+	}
+	pubkey := ECDSAPublicKey{
+		X: Bytes32{Val: [2]frontend.Variable{out[0], out[1]}},
+		Y: Bytes32{Val: [2]frontend.Variable{out[2], out[3]}},
+	}
+	// the public key is unique once the parity of R's y coordinate is fixed
+	g.AssertIsEqual(api.verifyECDSA(msgHash, sig, pubkey), sig.V.Val)
+	hash := api.Keccak256([]Bytes32{pubkey.X, pubkey.Y}, []int32{256, 256})
+	return api.Uint248.FromBinary(api.Bytes32.ToBinary(hash)[:160]...)
+}
+
+// verifyECDSA asserts that sig is a valid signature of msgHash by pubkey and
+// returns the parity of the y coordinate of the point R
+func (api *CircuitAPI) verifyECDSA(msgHash Bytes32, sig ECDSASignature, pubkey ECDSAPublicKey) frontend.Variable {
+	g := api.g
+	curve, err := sw_emulated.New[emulated.Secp256k1Fp, emulated.Secp256k1Fr](g, sw_emulated.GetSecp256k1Params())
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize secp256k1 curve: %s", err.Error()))
+	}
+	base, err := emulated.NewField[emulated.Secp256k1Fp](g)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize secp256k1 base field: %s", err.Error()))
+	}
+	scalars, err := emulated.NewField[emulated.Secp256k1Fr](g)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize secp256k1 scalar field: %s", err.Error()))
+	}
+
+	// r and s must be in [1, n). The coordinates of the public key must be
+	// canonical so that they hash to the correct address.
+	rBits := sig.R.toBinaryVars(g)
+	r := scalars.FromBits(rBits...)
+	s := scalars.FromBits(sig.S.toBinaryVars(g)...)
+	g.AssertIsEqual(scalars.IsZero(r), 0)
+	g.AssertIsEqual(scalars.IsZero(s), 0)
+	scalars.AssertIsInRange(r)
+	scalars.AssertIsInRange(s)
+	pk := &sw_emulated.AffinePoint[emulated.Secp256k1Fp]{
+		X: *base.FromBits(pubkey.X.toBinaryVars(g)...),
+		Y: *base.FromBits(pubkey.Y.toBinaryVars(g)...),
+	}
+	base.AssertIsInRange(&pk.X)
+	base.AssertIsInRange(&pk.Y)
+	curve.AssertIsOnCurve(pk)
+
+	// R = (m / s) * G + (r / s) * pubkey
+	msg := scalars.FromBits(msgHash.toBinaryVars(g)...)
+	sInv := scalars.Inverse(s)
+	u1 := scalars.Mul(msg, sInv)
+	u2 := scalars.Mul(r, sInv)
+	point := curve.JointScalarMulBase(pk, u2, u1)
+
+	// R.x == r. Since r < n < p, comparing the canonical bits is enough.
+	x := base.Reduce(&point.X)
+	base.AssertIsInRange(x)
+	for i, bit := range base.ToBits(x) {
+		if i < len(rBits) {
+			g.AssertIsEqual(bit, rBits[i])
+		} else {
+			g.AssertIsEqual(bit, 0)
+		}
+	}
+	y := base.Reduce(&point.Y)
+	base.AssertIsInRange(y)
+	return base.ToBits(y)[0]
+}
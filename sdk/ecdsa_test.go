@@ -0,0 +1,77 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestECDSA(t *testing.T) {
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	check(err)
+	hash := accounts.TextHash([]byte("order: sell 1 ETH for 3000 USDC"))
+	sigBytes, err := crypto.Sign(hash, key)
+	check(err)
+	sig, err := ParseECDSASignature(sigBytes)
+	check(err)
+
+	c := &TestECDSACircuit{
+		MsgHash: ConstFromBigEndianBytes(hash),
+		Sig:     sig,
+		PubKey:  ConstECDSAPublicKey(&key.PublicKey),
+		Address: ConstUint248(crypto.PubkeyToAddress(key.PublicKey).Bytes()),
+	}
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	// the other recovery id recovers a different public key
+	c.Sig.V = ConstUint248(1 - sigBytes[crypto.RecoveryIDOffset])
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected recovery with the wrong recovery id to fail")
+	}
+
+	c.Sig.V = sig.V
+	c.MsgHash = ConstFromBigEndianBytes(crypto.Keccak256([]byte("tampered")))
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected verification of a tampered message to fail")
+	}
+}
+
+func TestParseECDSASignature(t *testing.T) {
+	sig := make([]byte, 65)
+	for _, v := range []byte{0, 1, 27, 28} {
+		sig[64] = v
+		parsed, err := ParseECDSASignature(sig)
+		check(err)
+		if parsed.V.String() != ConstUint248(v%27).String() {
+			t.Errorf("expected recovery id %d, got %s", v%27, parsed.V)
+		}
+	}
+	sig[64] = 2
+	if _, err := ParseECDSASignature(sig); err == nil {
+		t.Error("expected recovery id 2 to be rejected")
+	}
+	if _, err := ParseECDSASignature(sig[:64]); err == nil {
+		t.Error("expected a 64-byte signature to be rejected")
+	}
+}
+
+type TestECDSACircuit struct {
+	MsgHash Bytes32
+	Sig     ECDSASignature
+	PubKey  ECDSAPublicKey
+	Address Uint248
+}
+
+func (c *TestECDSACircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	api.VerifyECDSA(c.MsgHash, c.Sig, c.PubKey)
+	api.Uint248.AssertIsEqual(api.RecoverAddress(c.MsgHash, c.Sig), c.Address)
+	return nil
+}
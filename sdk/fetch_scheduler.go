@@ -0,0 +1,171 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/celer-network/goutils/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// the number of retries of a request answered with 429 Too Many Requests
+	maxRateLimitedRetries = 3
+	// how long an endpoint is paused after a 429 without Retry-After
+	defaultRetryAfter = time.Second
+	// an endpoint paused longer is failed over instead of waited for
+	maxRetryAfter = time.Minute
+)
+
+// fetchScheduler schedules the RPC requests of a BrevisApp. It's shared by the
+// apps created from the app with NewBrevisAppFromExisting, so that the limits
+// hold across the data types fetched in parallel and across the apps, e.g. the
+// proofs built concurrently by the prover service.
+//
+// A request waits for the endpoint to be out of its Retry-After pause, then for
+// a token of the RPS limits of the scheduler and of the endpoint, and then for
+// a concurrency slot. A request answered with 429 pauses the endpoint until its
+// Retry-After and is retried, unless the pause is longer than maxRetryAfter.
+type fetchScheduler struct {
+	slots   chan struct{}
+	limiter *rateLimiter
+}
+
+// newFetchScheduler returns a scheduler of at most concurrency requests in
+// flight and rps requests per second in bursts of up to burst requests. A limit
+// that is not positive doesn't limit.
+func newFetchScheduler(concurrency int, rps float64, burst int) *fetchScheduler {
+	s := &fetchScheduler{limiter: newRateLimiter(rps, burst)}
+	if concurrency > 0 {
+		s.slots = make(chan struct{}, concurrency)
+	}
+	return s
+}
+
+// do runs fn, which sends a request to e, once it's scheduled. A nil scheduler
+// only applies the limits of the endpoint.
+func (s *fetchScheduler) do(ctx context.Context, e *rpcEndpoint, fn func(ctx context.Context) error) error {
+	for retries := 0; ; retries++ {
+		if err := e.waitRetryAfter(ctx); err != nil {
+			return err
+		}
+		release, err := s.acquire(ctx, e)
+		if err != nil {
+			return err
+		}
+		err = fn(ctx)
+		release()
+		if !isRateLimited(err) || ctx.Err() != nil {
+			return err
+		}
+		if retries == maxRateLimitedRetries {
+			return err
+		}
+		d := e.pausedFor()
+		if d <= 0 {
+			// not paused by retryAfterTransport, e.g. the endpoint of an
+			// ethclient given by the user
+			d = defaultRetryAfter
+			e.pause(d)
+		}
+		log.Warnf("rpc %s is rate limited, retrying in %s", e.url, d)
+	}
+}
+
+func (s *fetchScheduler) acquire(ctx context.Context, e *rpcEndpoint) (release func(), err error) {
+	if s != nil {
+		if err = s.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err = e.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	if s == nil || s.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	}
+}
+
+func isRateLimited(err error) bool {
+	var httpErr rpc.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests
+}
+
+// pause holds the requests to the endpoint for d
+func (e *rpcEndpoint) pause(d time.Duration) {
+	e.mu.Lock()
+	e.pausedUntil = time.Now().Add(d)
+	e.mu.Unlock()
+}
+
+func (e *rpcEndpoint) pausedFor() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Until(e.pausedUntil)
+}
+
+// waitRetryAfter waits for the pause of the endpoint to end. It fails if the
+// pause is longer than maxRetryAfter, so the request is failed over.
+func (e *rpcEndpoint) waitRetryAfter(ctx context.Context) error {
+	d := e.pausedFor()
+	if d <= 0 {
+		return nil
+	}
+	if d > maxRetryAfter {
+		return fmt.Errorf("rpc %s is rate limited for %s", e.url, d.Round(time.Second))
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryAfterTransport pauses the endpoint by the Retry-After of the responses
+// with status 429, which rpc.HTTPError doesn't carry
+type retryAfterTransport struct {
+	endpoint *rpcEndpoint
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		d, ok := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			d = defaultRetryAfter
+		}
+		t.endpoint.pause(d)
+	}
+	return resp, err
+}
+
+// parseRetryAfter parses a Retry-After of either seconds or an HTTP date
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	at, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Until(at), 0), true
+}
@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFetchSchedulerConcurrency(t *testing.T) {
+	s := newFetchScheduler(2, 0, 0)
+	e := &rpcEndpoint{url: "test"}
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check(s.do(context.Background(), e, func(ctx context.Context) error {
+				mu.Lock()
+				inFlight++
+				maxInFlight = max(maxInFlight, inFlight)
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+	if maxInFlight != 2 {
+		t.Errorf("expected 2 requests in flight at most, got %d", maxInFlight)
+	}
+
+	// the burst is served at once, the next request waits for a token
+	l := newRateLimiter(20, 3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		check(l.wait(context.Background()))
+	}
+	if elapsed := time.Since(start); elapsed >= 40*time.Millisecond {
+		t.Errorf("expected a burst of 3 requests not to wait, took %s", elapsed)
+	}
+	check(l.wait(context.Background()))
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected the request after the burst to wait, took %s", elapsed)
+	}
+}
+
+func TestFetchSchedulerRetryAfter(t *testing.T) {
+	c := newTestChain()
+	handlers := c.handlers(true)
+	handlers["eth_chainId"] = func(params []json.RawMessage) (interface{}, error) {
+		return "0x1", nil
+	}
+	rpc := newFakeRPC(t, handlers)
+	// the number of requests answered with 429
+	var limited atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limited.Add(-1) >= 0 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rpc.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	endpoints, err := dialRPCEndpoints(1, []RpcEndpoint{{Url: srv.URL}}, nil)
+	check(err)
+	f := newChainFetcher(endpoints, 0)
+	f.scheduler = newFetchScheduler(4, 0, 0)
+	limited.Store(1)
+	slot := common.BigToHash(big.NewInt(1))
+	start := time.Now()
+	value, err := f.storageAt(context.Background(), big.NewInt(100), testAccount, slot)
+	check(err)
+	if value != c.storage[slot] {
+		t.Errorf("expected storage value %x, got %x", c.storage[slot], value)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected the retry after the Retry-After of 1s, took %s", elapsed)
+	}
+
+	// the request fails once the retries are used up
+	limited.Store(maxRateLimitedRetries + 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err = f.storageAt(ctx, big.NewInt(100), testAccount, common.BigToHash(big.NewInt(2))); !isRateLimited(err) {
+		t.Errorf("expected the rate limited error, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("unexpected Retry-After of seconds %s %v", d, ok)
+	}
+	at := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(at); !ok || d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("unexpected Retry-After of an HTTP date %s %v", d, ok)
+	}
+	for _, v := range []string{"", "-1", "soon"} {
+		if _, ok := parseRetryAfter(v); ok {
+			t.Errorf("expected Retry-After %q to be invalid", v)
+		}
+	}
+}
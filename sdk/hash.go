@@ -0,0 +1,301 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/brevis-network/zk-hash/keccak"
+	poseidoncircuit "github.com/brevis-network/zk-hash/poseidon/circuit"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	nativemimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/permutation/sha2"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	keccakRateBytes = 136
+	sha256BlockSize = 64
+	// poseidonMaxInputs is the max number of inputs of a single poseidon
+	// permutation
+	poseidonMaxInputs = 16
+)
+
+var sha256InitialHash = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// ConstBytes initializes a constant byte list of size maxLen from data for use
+// with Keccak256Bytes and Sha256Bytes. The bytes after data are filled with 0s.
+// This function does not generate circuit wires and should only be used outside
+// of circuit.
+func ConstBytes(data []byte, maxLen int) (bytes List[Uint248], length Uint248) {
+	if len(data) > maxLen {
+		panic(fmt.Errorf("data of length %d exceeds max length %d", len(data), maxLen))
+	}
+	bytes = make(List[Uint248], maxLen)
+	for i := range bytes {
+		if i < len(data) {
+			bytes[i] = ConstUint248(data[i])
+		} else {
+			bytes[i] = ConstUint248(0)
+		}
+	}
+	return bytes, ConstUint248(len(data))
+}
+
+// Keccak256Bytes computes keccak256(data[:length]). data is a list of bytes
+// whose size is the maximum supported length, and length is the actual number
+// of bytes to hash. The circuit size grows with len(data) regardless of the
+// actual length. Every element of data, including the ones after length, must
+// be a byte. Proving fails if length > len(data). To hash the packed encoding
+// of circuit variables, convert them to bytes with api.Bytes32.ToBytes.
+// Example: keccak256(abi.encodePacked(address, uint256))
+//
+//	addr := api.Bytes32.ToBytes(api.ToBytes32(address))[12:]
+//	amount := api.Bytes32.ToBytes(value)
+//	hash := api.Keccak256Bytes(append(addr, amount...), ConstUint248(52))
+func (api *CircuitAPI) Keccak256Bytes(data List[Uint248], length Uint248) Bytes32 {
+	g := api.g
+	maxRounds := len(data)/keccakRateBytes + 1
+	n := maxRounds * keccakRateBytes
+	api.assertByteLength(data, length)
+	roundIndex := api.quoConst(length.Val, keccakRateBytes)
+	isData, isEnd := api.lengthFlags(length.Val, n)
+	// the last byte of the last round carries the final bit of the 10*1 padding
+	lastIndex := g.Sub(g.Mul(g.Add(roundIndex, 1), keccakRateBytes), 1)
+
+	// keccak takes the bits of each byte in little-endian order
+	bits := make([]frontend.Variable, 0, n*8)
+	for i := 0; i < n; i++ {
+		byteBits := make([]frontend.Variable, 8)
+		if i < len(data) {
+			byteBits = g.ToBinary(data[i].Val, 8)
+		}
+		for j := range byteBits {
+			var bit frontend.Variable = 0
+			if i < len(data) {
+				bit = g.Mul(isData[i], byteBits[j])
+			}
+			if j == 0 {
+				bit = g.Add(bit, isEnd[i])
+			}
+			if j == 7 {
+				bit = g.Add(bit, g.IsZero(g.Sub(lastIndex, i)))
+			}
+			bits = append(bits, bit)
+		}
+	}
+	res := keccak.Keccak256Bits(g, maxRounds, roundIndex, bits)
+	return api.Bytes32.FromBinary(newU248s(flipByGroups(res[:], 8)...)...)
+}
+
+// Sha256Bytes computes sha256(data[:length]). data is a list of bytes whose
+// size is the maximum supported length, and length is the actual number of
+// bytes to hash. The circuit size grows with len(data) regardless of the actual
+// length. Proving fails if length > len(data).
+func (api *CircuitAPI) Sha256Bytes(data List[Uint248], length Uint248) Bytes32 {
+	g := api.g
+	maxBlocks := (len(data)+8)/sha256BlockSize + 1
+	n := maxBlocks * sha256BlockSize
+	api.assertByteLength(data, length)
+	// the padding is 0x80 followed by 0s and the 8-byte big-endian bit length,
+	// so the last block is the one that fits length + 9 bytes
+	lastBlock := api.quoConst(g.Add(length.Val, 8), sha256BlockSize)
+	isData, isEnd := api.lengthFlags(length.Val, n)
+	lenBits := g.ToBinary(g.Mul(length.Val, 8), 64)
+	lenBytes := make([]frontend.Variable, 8)
+	for i := range lenBytes {
+		lenBytes[i] = g.FromBinary(lenBits[8*(7-i) : 8*(8-i)]...)
+	}
+
+	uapi, err := uints.New[uints.U32](g)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize uints api: %s", err.Error()))
+	}
+	var state [8]uints.U32
+	copy(state[:], uints.NewU32Array(sha256InitialHash[:]))
+	digest := make([]frontend.Variable, 32)
+	for i := range digest {
+		digest[i] = 0
+	}
+	for b := 0; b < maxBlocks; b++ {
+		isLast := g.IsZero(g.Sub(lastBlock, b))
+		var block [sha256BlockSize]uints.U8
+		for j := range block {
+			i := b*sha256BlockSize + j
+			var v frontend.Variable = 0
+			if i < len(data) {
+				v = g.Mul(isData[i], data[i].Val)
+			}
+			v = g.Add(v, g.Mul(isEnd[i], 0x80))
+			if j >= sha256BlockSize-8 {
+				v = g.Add(v, g.Mul(isLast, lenBytes[j-sha256BlockSize+8]))
+			}
+			block[j] = uapi.ByteValueOf(v)
+		}
+		state = sha2.Permute(uapi, state, block)
+		for i, word := range state {
+			for j, byt := range uapi.UnpackMSB(word) {
+				digest[4*i+j] = g.Add(digest[4*i+j], g.Mul(isLast, byt.Val))
+			}
+		}
+	}
+	return api.Bytes32.FromBytes(newU248s(digest...)...)
+}
+
+// HashPoseidon computes the BN254 poseidon hash of the values of vars, e.g. to
+// commit to custom inputs. Each variable is flattened to its Values(), and a
+// Uint521 to its canonical limbs. Up to 16 values are hashed at once. Longer
+// inputs are absorbed in chunks, i.e. h = poseidon(v0, ..., v15), then
+// h = poseidon(h, next 15 values) until all values are consumed. The Go
+// function PoseidonHash produces the same result outside of circuit.
+func (api *CircuitAPI) HashPoseidon(vars ...CircuitVariable) Bytes32 {
+	values := api.hashValues(vars)
+	if len(values) == 0 {
+		panic("cannot hash empty input with poseidon")
+	}
+	n := min(len(values), poseidonMaxInputs)
+	h := poseidoncircuit.Poseidon(api.g, values[:n])
+	for values = values[n:]; len(values) > 0; values = values[n:] {
+		n = min(len(values), poseidonMaxInputs-1)
+		h = poseidoncircuit.Poseidon(api.g, append([]frontend.Variable{h}, values[:n]...))
+	}
+	return api.Bytes32.FromFV(h)
+}
+
+// HashMiMC computes the BN254 MiMC hash of the values of vars. The variables
+// are flattened the same way as in HashPoseidon. The Go function MiMCHash
+// produces the same result outside of circuit.
+func (api *CircuitAPI) HashMiMC(vars ...CircuitVariable) Bytes32 {
+	hasher, err := mimc.NewMiMC(api.g)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize mimc hasher: %s", err.Error()))
+	}
+	hasher.Write(api.hashValues(vars)...)
+	return api.Bytes32.FromFV(hasher.Sum())
+}
+
+func (api *CircuitAPI) hashValues(vars []CircuitVariable) []frontend.Variable {
+	var values []frontend.Variable
+	for _, v := range vars {
+		switch v := v.(type) {
+		case Uint521:
+			values = append(values, api.Uint521.canonicalLimbs(v)...)
+		case List[Uint521]:
+			for _, item := range v {
+				values = append(values, api.Uint521.canonicalLimbs(item)...)
+			}
+		default:
+			values = append(values, v.Values()...)
+		}
+	}
+	return values
+}
+
+// PoseidonHash computes the same hash as CircuitAPI.HashPoseidon outside of
+// circuit. vars must be constants, e.g. the custom inputs assigned to the
+// circuit.
+func PoseidonHash(vars ...CircuitVariable) (common.Hash, error) {
+	values := constHashValues(vars)
+	if len(values) == 0 {
+		return common.Hash{}, fmt.Errorf("cannot hash empty input with poseidon")
+	}
+	n := min(len(values), poseidonMaxInputs)
+	h, err := DoHashWithPoseidonBn254(values[:n])
+	for values = values[n:]; err == nil && len(values) > 0; values = values[n:] {
+		n = min(len(values), poseidonMaxInputs-1)
+		h, err = DoHashWithPoseidonBn254(append([]*big.Int{h}, values[:n]...))
+	}
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to hash with poseidon bn254: %s", err.Error())
+	}
+	return common.BigToHash(h), nil
+}
+
+// MiMCHash computes the same hash as CircuitAPI.HashMiMC outside of circuit.
+// vars must be constants, e.g. the custom inputs assigned to the circuit.
+func MiMCHash(vars ...CircuitVariable) (common.Hash, error) {
+	hasher := nativemimc.NewMiMC()
+	for _, v := range constHashValues(vars) {
+		if v.Cmp(fr.Modulus()) >= 0 {
+			return common.Hash{}, fmt.Errorf("value %s is not less than the bn254 scalar field modulus", v)
+		}
+		hasher.Write(common.BigToHash(v).Bytes())
+	}
+	return common.BytesToHash(hasher.Sum(nil)), nil
+}
+
+func constHashValues(vars []CircuitVariable) []*big.Int {
+	var values []*big.Int
+	for _, v := range vars {
+		var vs []frontend.Variable
+		switch v := v.(type) {
+		// Values() of Uint521 only works in circuit
+		case Uint521:
+			vs = constUint521Limbs(v)
+		case List[Uint521]:
+			for _, item := range v {
+				vs = append(vs, constUint521Limbs(item)...)
+			}
+		default:
+			vs = v.Values()
+		}
+		for _, value := range vs {
+			values = append(values, fromInterface(value))
+		}
+	}
+	return values
+}
+
+// constUint521Limbs returns the canonical limbs of a constant Uint521
+func constUint521Limbs(v Uint521) []frontend.Variable {
+	f := Uint521Field{}
+	n, _ := new(big.Int).SetString(v.String(), 10)
+	var limbs []frontend.Variable
+	for _, limb := range decomposeBig(n, f.BitsPerLimb(), f.NbLimbs()) {
+		limbs = append(limbs, limb)
+	}
+	return limbs
+}
+
+// assertByteLength asserts length <= len(data)
+func (api *CircuitAPI) assertByteLength(data List[Uint248], length Uint248) {
+	rangeChecker := rangecheck.New(api.g)
+	rangeChecker.Check(length.Val, 32)
+	rangeChecker.Check(api.g.Sub(len(data), length.Val), 32)
+}
+
+// quoConst returns v / d for a constant d. v must be at most 32 bits wide.
+func (api *CircuitAPI) quoConst(v frontend.Variable, d int) frontend.Variable {
+	g := api.g
+	out, err := g.Compiler().NewHint(QuoRemHint, 2, v, d)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+	}
+	q, r := out[0], out[1]
+	g.AssertIsEqual(g.Add(g.Mul(q, d), r), v)
+	rangeChecker := rangecheck.New(g)
+	rangeChecker.Check(q, 32)
+	rangeChecker.Check(r, 32)
+	rangeChecker.Check(g.Sub(d-1, r), 32) // r < d
+	return q
+}
+
+// lengthFlags returns for each of the n positions whether it is before length
+// and whether it is exactly at length. length must be less than n.
+func (api *CircuitAPI) lengthFlags(length frontend.Variable, n int) (isData, isEnd []frontend.Variable) {
+	g := api.g
+	isData = make([]frontend.Variable, n)
+	isEnd = make([]frontend.Variable, n)
+	var before frontend.Variable = 1
+	for i := 0; i < n; i++ {
+		isEnd[i] = g.IsZero(g.Sub(length, i))
+		before = g.Sub(before, isEnd[i])
+		isData[i] = before
+	}
+	return isData, isEnd
+}
@@ -0,0 +1,139 @@
+package sdk
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestHashBytes(t *testing.T) {
+	const maxLen = 140
+	data := make([]byte, maxLen)
+	for i := range data {
+		data[i] = byte(i*7 + 3)
+	}
+	// covers the empty input and the lengths around the sha256 block and keccak
+	// round boundaries
+	for _, l := range []int{0, 55, 56, 135, 136, maxLen} {
+		bytes, length := ConstBytes(data[:l], maxLen)
+		sha := sha256.Sum256(data[:l])
+		c := &TestHashBytesCircuit{
+			Data:   bytes,
+			Length: length,
+			Keccak: ConstFromBigEndianBytes(crypto.Keccak256(data[:l])),
+			Sha256: ConstFromBigEndianBytes(sha[:]),
+		}
+		err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatalf("length %d: %s", l, err.Error())
+		}
+	}
+
+	bytes, _ := ConstBytes(data, maxLen)
+	zero := ConstFromBigEndianBytes(nil)
+	c := &TestHashBytesCircuit{Data: bytes, Length: ConstUint248(maxLen + 1), Keccak: zero, Sha256: zero}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected length > max length to fail")
+	}
+}
+
+type TestHashBytesCircuit struct {
+	Data           List[Uint248]
+	Length         Uint248
+	Keccak, Sha256 Bytes32
+}
+
+func (c *TestHashBytesCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	api.Bytes32.AssertIsEqual(api.Keccak256Bytes(c.Data, c.Length), c.Keccak)
+	api.Bytes32.AssertIsEqual(api.Sha256Bytes(c.Data, c.Length), c.Sha256)
+	return nil
+}
+
+func TestBytes32ToBytes(t *testing.T) {
+	word := common.HexToHash("0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	addr := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	packed := append(addr.Bytes(), word.Bytes()...)
+	c := &TestBytes32ToBytesCircuit{
+		Word:   ConstFromBigEndianBytes(word[:]),
+		Addr:   ConstUint248(addr.Big()),
+		Packed: ConstFromBigEndianBytes(crypto.Keccak256(packed)),
+	}
+	err := test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+}
+
+type TestBytes32ToBytesCircuit struct {
+	Word   Bytes32
+	Addr   Uint248
+	Packed Bytes32
+}
+
+func (c *TestBytes32ToBytesCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	bytes := api.Bytes32.ToBytes(c.Word)
+	for i, b := range bytes {
+		api.Uint248.AssertIsEqual(b, ConstUint248(i+1))
+	}
+	api.Bytes32.AssertIsEqual(api.Bytes32.FromBytes(bytes...), c.Word)
+	api.Bytes32.AssertIsEqual(api.Bytes32.FromBytes(bytes[30:]...), ConstFromBigEndianBytes([]byte{0x1f, 0x20}))
+
+	// keccak256(abi.encodePacked(address, bytes32))
+	preimage := append(api.Bytes32.ToBytes(api.ToBytes32(c.Addr))[12:], bytes...)
+	api.Bytes32.AssertIsEqual(api.Keccak256Bytes(preimage, ConstUint248(len(preimage))), c.Packed)
+	return nil
+}
+
+func TestHashPoseidonAndMiMC(t *testing.T) {
+	list := make(List[Uint248], 20)
+	for i := range list {
+		list[i] = ConstUint248(i * 1000)
+	}
+	c := &TestHashPoseidonAndMiMCCircuit{
+		A:    ConstUint248(123),
+		B:    ConstInt248(big.NewInt(-5)),
+		C:    ConstFromBigEndianBytes(common.Hex2Bytes("ff00000000000000000000000000000000000000000000000000000000000001")),
+		D:    ConstUint521(new(big.Int).Sub(Uint521Field{}.Modulus(), big.NewInt(2))),
+		List: list,
+	}
+	vars := []CircuitVariable{c.A, c.B, c.C, c.D, c.List}
+	short, err := PoseidonHash(c.A, c.C)
+	check(err)
+	long, err := PoseidonHash(vars...)
+	check(err)
+	mimcHash, err := MiMCHash(vars...)
+	check(err)
+	c.Short = ConstFromBigEndianBytes(short.Bytes())
+	c.Long = ConstFromBigEndianBytes(long.Bytes())
+	c.MiMC = ConstFromBigEndianBytes(mimcHash.Bytes())
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	if _, err = PoseidonHash(); err == nil {
+		t.Error("expected hashing empty input to fail")
+	}
+}
+
+type TestHashPoseidonAndMiMCCircuit struct {
+	A                 Uint248
+	B                 Int248
+	C                 Bytes32
+	D                 Uint521
+	List              List[Uint248]
+	Short, Long, MiMC Bytes32
+}
+
+func (c *TestHashPoseidonAndMiMCCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	api.Bytes32.AssertIsEqual(api.HashPoseidon(c.A, c.C), c.Short)
+	api.Bytes32.AssertIsEqual(api.HashPoseidon(c.A, c.B, c.C, c.D, c.List), c.Long)
+	api.Bytes32.AssertIsEqual(api.HashMiMC(c.A, c.B, c.C, c.D, c.List), c.MiMC)
+	return nil
+}
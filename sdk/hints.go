@@ -7,6 +7,8 @@ import (
 	"sync"
 
 	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 var registerOnce sync.Once
@@ -20,7 +22,8 @@ func registerHints() {
 }
 
 func GetHints() []solver.Hint {
-	return []solver.Hint{QuoRemHint, SqrtHint, SortHint, GroupValuesHint, CmpHint}
+	return []solver.Hint{QuoRemHint, SqrtHint, SortHint, GroupValuesHint, GroupSortHint, CmpHint,
+		OrderStatisticHint, Uint521QuoRemHint, Uint521SqrtHint, Uint256QuoRemHint, ECRecoverHint}
 }
 
 func QuoRemHint(_ *big.Int, in, out []*big.Int) error {
@@ -62,3 +65,139 @@ func SortHint(_ *big.Int, in, out []*big.Int) error {
 	copy(out, l)
 	return nil
 }
+
+// Uint521QuoRemHint computes the quotient and remainder of two Uint521 values.
+// It is meant to be called through emulated.Field.NewHint
+func Uint521QuoRemHint(_ *big.Int, nativeIn, nativeOut []*big.Int) error {
+	return emulated.UnwrapHint(nativeIn, nativeOut, func(mod *big.Int, in, out []*big.Int) error {
+		if len(in) != 2 {
+			return fmt.Errorf("Uint521QuoRemHint: input len must be 2")
+		}
+		if len(out) != 2 {
+			return fmt.Errorf("Uint521QuoRemHint: output len must be 2")
+		}
+		// inputs are not necessarily reduced
+		a := new(big.Int).Mod(in[0], mod)
+		b := new(big.Int).Mod(in[1], mod)
+		if b.Sign() == 0 {
+			return fmt.Errorf("Uint521QuoRemHint: division by zero")
+		}
+		out[0].QuoRem(a, b, out[1])
+		return nil
+	})
+}
+
+// Uint521SqrtHint computes the integer square root of a Uint521 value. It is
+// meant to be called through emulated.Field.NewHint
+func Uint521SqrtHint(_ *big.Int, nativeIn, nativeOut []*big.Int) error {
+	return emulated.UnwrapHint(nativeIn, nativeOut, func(mod *big.Int, in, out []*big.Int) error {
+		if len(in) != 1 {
+			return fmt.Errorf("Uint521SqrtHint: input len must be 1")
+		}
+		if len(out) != 1 {
+			return fmt.Errorf("Uint521SqrtHint: output len must be 1")
+		}
+		out[0].Sqrt(new(big.Int).Mod(in[0], mod))
+		return nil
+	})
+}
+
+// Uint256QuoRemHint computes the quotient and remainder of two Uint256 values.
+// Inputs are laid out as [a.lo, a.hi, b.lo, b.hi] and outputs as [q.lo, q.hi,
+// r.lo, r.hi]. Outputs zeros if b is 0
+func Uint256QuoRemHint(_ *big.Int, in, out []*big.Int) error {
+	if len(in) != 4 {
+		return fmt.Errorf("Uint256QuoRemHint: input len must be 4")
+	}
+	if len(out) != 4 {
+		return fmt.Errorf("Uint256QuoRemHint: output len must be 4")
+	}
+	a := new(big.Int).Add(new(big.Int).Lsh(in[1], uint256LimbBits), in[0])
+	b := new(big.Int).Add(new(big.Int).Lsh(in[3], uint256LimbBits), in[2])
+	q, r := new(big.Int), new(big.Int)
+	if b.Sign() != 0 {
+		q.QuoRem(a, b, r)
+	}
+	ql, rl := decomposeAndSlice(q, uint256LimbBits, 2), decomposeAndSlice(r, uint256LimbBits, 2)
+	out[0].Set(ql[0])
+	out[1].Set(ql[1])
+	out[2].Set(rl[0])
+	out[3].Set(rl[1])
+	return nil
+}
+
+// OrderStatisticHint finds the k-th (0-based) smallest key among the toggled on
+// keys. Each key is made of numLimbs limbs of limbBits bits, most significant
+// limb first. Inputs are laid out as [numLimbs, limbBits, k, keys..., toggles...]
+// and the limbs of the found key are returned. Outputs zeros if there are not
+// enough toggled on keys.
+func OrderStatisticHint(_ *big.Int, in, out []*big.Int) error {
+	if len(in) < 3 {
+		return fmt.Errorf("OrderStatisticHint: input len must be at least 3")
+	}
+	numLimbs, limbBits, k := int(in[0].Int64()), uint(in[1].Int64()), int(in[2].Int64())
+	if len(out) != numLimbs {
+		return fmt.Errorf("OrderStatisticHint: output len must be %d", numLimbs)
+	}
+	rest := in[3:]
+	if len(rest)%(numLimbs+1) != 0 {
+		return fmt.Errorf("OrderStatisticHint: invalid input len %d", len(in))
+	}
+	n := len(rest) / (numLimbs + 1)
+	keys, toggles := rest[:n*numLimbs], rest[n*numLimbs:]
+
+	var valid []*big.Int
+	for i := 0; i < n; i++ {
+		if toggles[i].Sign() == 0 {
+			continue
+		}
+		key := new(big.Int)
+		for _, limb := range keys[i*numLimbs : (i+1)*numLimbs] {
+			key.Lsh(key, limbBits).Add(key, limb)
+		}
+		valid = append(valid, key)
+	}
+	for i := range out {
+		out[i].SetUint64(0)
+	}
+	if k >= len(valid) {
+		return nil
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i].Cmp(valid[j]) < 0 })
+	limbs := decomposeAndSlice(valid[k], limbBits, uint(numLimbs))
+	for i := range out {
+		out[i].Set(limbs[numLimbs-1-i])
+	}
+	return nil
+}
+
+// ECRecoverHint recovers the public key from a secp256k1 signature. Inputs are
+// laid out as [msgHash.lo, msgHash.hi, r.lo, r.hi, s.lo, s.hi, v] and outputs
+// as [x.lo, x.hi, y.lo, y.hi], where lo and hi are split the same way as in
+// Bytes32
+func ECRecoverHint(_ *big.Int, in, out []*big.Int) error {
+	if len(in) != 7 {
+		return fmt.Errorf("ECRecoverHint: input len must be 7")
+	}
+	if len(out) != 4 {
+		return fmt.Errorf("ECRecoverHint: output len must be 4")
+	}
+	toBytes := func(lo, hi *big.Int) []byte {
+		v := new(big.Int).Lsh(hi, uint(numBitsPerVar))
+		return v.Add(v, lo).FillBytes(make([]byte, 32))
+	}
+	sig := make([]byte, 0, crypto.SignatureLength)
+	sig = append(sig, toBytes(in[2], in[3])...)
+	sig = append(sig, toBytes(in[4], in[5])...)
+	sig = append(sig, byte(in[6].Uint64()))
+	pub, err := crypto.SigToPub(toBytes(in[0], in[1]), sig)
+	if err != nil {
+		return fmt.Errorf("ECRecoverHint: %s", err.Error())
+	}
+	for i, coord := range []*big.Int{pub.X, pub.Y} {
+		v := ConstFromBigEndianBytes(coord.Bytes())
+		out[2*i].Set(fromInterface(v.Val[0]))
+		out[2*i+1].Set(fromInterface(v.Val[1]))
+	}
+	return nil
+}
@@ -18,6 +18,46 @@ type AppCircuit interface {
 	Allocate() (maxReceipts, maxStorage, maxTransactions int)
 }
 
+// AccountAllocator is an optional interface an AppCircuit implements to
+// allocate space for account data. Circuits that don't implement it are
+// allocated no accounts.
+//
+// EXPERIMENTAL: the accounts are fetched from the RPC and only committed by the
+// app circuit. Neither brevis gateway nor the aggregation circuits prove them,
+// so app circuits allocating accounts cannot be sent to brevis gateway yet.
+// Building their input needs BrevisAppConfig.ExperimentalAccountsAndHeaders.
+type AccountAllocator interface {
+	AllocateAccounts() (maxAccounts int)
+}
+
+// BlockHeaderAllocator is an optional interface an AppCircuit implements to
+// allocate space for block headers. Circuits that don't implement it are
+// allocated no block headers.
+//
+// EXPERIMENTAL: like AccountAllocator, the block headers are not proven by
+// brevis and need BrevisAppConfig.ExperimentalAccountsAndHeaders.
+type BlockHeaderAllocator interface {
+	AllocateBlockHeaders() (maxBlockHeaders int)
+}
+
+// AppAllocation returns the max number of each data type allocated by app
+func AppAllocation(app AppCircuit) (maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders int) {
+	maxReceipts, maxStorage, maxTxs = app.Allocate()
+	if a, ok := app.(AccountAllocator); ok {
+		maxAccounts = a.AllocateAccounts()
+	}
+	if h, ok := app.(BlockHeaderAllocator); ok {
+		maxHeaders = h.AllocateBlockHeaders()
+	}
+	return
+}
+
+// appDataPoints returns the total number of data points allocated by app
+func appDataPoints(app AppCircuit) int {
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := AppAllocation(app)
+	return DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
+}
+
 type HostCircuit struct {
 	api frontend.API
 
@@ -26,10 +66,10 @@ type HostCircuit struct {
 }
 
 func DefaultHostCircuit(app AppCircuit) *HostCircuit {
-	maxReceipts, maxStorage, maxTxs := app.Allocate()
-	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := AppAllocation(app)
+	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
 	h := &HostCircuit{
-		Input: defaultCircuitInput(maxReceipts, maxStorage, maxTxs, dataPoints),
+		Input: defaultCircuitInput(maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders, dataPoints),
 		Guest: app,
 	}
 	return h
@@ -83,6 +123,25 @@ func (c *HostCircuit) Define(gapi frontend.API) error {
 	return nil
 }
 
+// commitLocalInput commits points to inputCommits from index j and returns the
+// index after them. The toggled off ones are committed to dummy.
+func commitLocalInput[T localData](api frontend.API, hasher poseidon.PoseidonCircuit, inputCommits []frontend.Variable, j int,
+	points DataPoints[T], dummy frontend.Variable) int {
+	for i, d := range points.Raw {
+		packed := d.pack(api)
+		hasher.Reset()
+		if len(packed) > 16 {
+			panic(fmt.Sprintf("input is more than 16: %d", len(packed)))
+		}
+		for _, v := range packed {
+			hasher.Write(v)
+		}
+		inputCommits[j] = api.Select(points.Toggles[i], hasher.Sum(), dummy)
+		j++
+	}
+	return j
+}
+
 func (c *HostCircuit) commitInput() error {
 	err := c.validateInput()
 	if err != nil {
@@ -93,8 +152,7 @@ func (c *HostCircuit) commitInput() error {
 		return fmt.Errorf("error creating poseidon hasher instance: %s", err.Error())
 	}
 
-	maxReceipts, maxStorage, maxTxs := c.Guest.Allocate()
-	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	dataPoints := appDataPoints(c.Guest)
 
 	inputCommits := make([]frontend.Variable, dataPoints)
 	receipts := c.Input.Receipts
@@ -143,6 +201,11 @@ func (c *HostCircuit) commitInput() error {
 		inputCommits[j] = c.api.Select(txs.Toggles[i], sum, c.Input.DummyTransactionInputCommitment)
 		j++
 	}
+	// there is no dummy account or block header commitment in the circuit input
+	// as they are not proven by the aggregation service. the commitment of the
+	// default value is used instead.
+	j = commitLocalInput(c.api, hasher, inputCommits, j, c.Input.Accounts, localDummyInputCommitment(defaultAccount()))
+	j = commitLocalInput(c.api, hasher, inputCommits, j, c.Input.BlockHeaders, localDummyInputCommitment(defaultBlockHeader()))
 
 	// adding constraint for input commitments (both effective commitments and dummies)
 	for i := 0; i < c.dataLen(); i++ {
@@ -212,18 +275,17 @@ func assertInputUniqueness(api frontend.API, in []frontend.Variable, shouldCheck
 
 func (c *HostCircuit) dataLen() int {
 	d := c.Input
-	return len(d.Receipts.Raw) + len(d.StorageSlots.Raw) + len(d.Transactions.Raw)
+	return len(d.Receipts.Raw) + len(d.StorageSlots.Raw) + len(d.Transactions.Raw) + len(d.Accounts.Raw) + len(d.BlockHeaders.Raw)
 }
 
 func (c *HostCircuit) validateInput() error {
-	maxReceipts, maxStorage, maxTxs := c.Guest.Allocate()
-	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	dataPoints := appDataPoints(c.Guest)
 	d := c.Input
-	inputLen := len(d.Receipts.Raw) + len(d.StorageSlots.Raw) + len(d.Transactions.Raw)
+	inputLen := c.dataLen()
 	if inputLen > dataPoints {
 		return fmt.Errorf("input len must be less than %d", dataPoints)
 	}
-	maxReceipts, maxSlots, maxTransactions := c.Guest.Allocate()
+	maxReceipts, maxSlots, maxTransactions, maxAccounts, maxHeaders := AppAllocation(c.Guest)
 	if len(d.Receipts.Raw) != len(d.Receipts.Toggles) || len(d.Receipts.Raw) != maxReceipts {
 		return fmt.Errorf("receipt input/toggle len mismatch: len(d.Receipts.Raw) %d vs len(d.Receipts.Toggles) %d vs maxReceipts %d",
 			len(d.Receipts.Raw), len(d.Receipts.Toggles), maxReceipts)
@@ -236,6 +298,14 @@ func (c *HostCircuit) validateInput() error {
 		return fmt.Errorf("transaction input/toggle len mismatch: len(d.Transactions.Raw) %d vs len(d.Transactions.Toggles) %d vs maxTransactions %d",
 			len(d.Transactions.Raw), len(d.Transactions.Toggles), maxTransactions)
 	}
+	if len(d.Accounts.Raw) != len(d.Accounts.Toggles) || len(d.Accounts.Raw) != maxAccounts {
+		return fmt.Errorf("account input/toggle len mismatch: len(d.Accounts.Raw) %d vs len(d.Accounts.Toggles) %d vs maxAccounts %d",
+			len(d.Accounts.Raw), len(d.Accounts.Toggles), maxAccounts)
+	}
+	if len(d.BlockHeaders.Raw) != len(d.BlockHeaders.Toggles) || len(d.BlockHeaders.Raw) != maxHeaders {
+		return fmt.Errorf("block header input/toggle len mismatch: len(d.BlockHeaders.Raw) %d vs len(d.BlockHeaders.Toggles) %d vs maxHeaders %d",
+			len(d.BlockHeaders.Raw), len(d.BlockHeaders.Toggles), maxHeaders)
+	}
 	return nil
 }
 
@@ -0,0 +1,214 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// EventFields builds the LogFieldData of an event's arguments by name, so that
+// the EventID, IsTopic and FieldIndex don't have to be computed by hand.
+type EventFields struct {
+	event abi.Event
+}
+
+// NewEventFields returns the EventFields of event. Anonymous events are not
+// supported since they don't have an event id.
+func NewEventFields(event abi.Event) (*EventFields, error) {
+	if event.Anonymous {
+		return nil, fmt.Errorf("anonymous event %s is not supported", event.Name)
+	}
+	return &EventFields{event: event}, nil
+}
+
+// ParseEventFields parses a Solidity event signature and returns its
+// EventFields. The signature must name the arguments used in Field, e.g.
+//
+//	Transfer(address indexed from, address indexed to, uint256 value)
+//
+// The "event" keyword and a trailing ";" are optional. Tuple arguments are not
+// supported, use NewEventFields with an abi.Event instead.
+func ParseEventFields(signature string) (*EventFields, error) {
+	event, err := parseEventSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventFields(event)
+}
+
+// Event returns the event whose fields are built
+func (e *EventFields) Event() abi.Event {
+	return e.event
+}
+
+// Field returns the LogFieldData of the argument named name in the log at
+// logPos of a receipt. Only arguments whose value is a single 32-byte word in
+// the log are supported. Contract and Value are left empty to be filled in
+// when the receipt is queried.
+func (e *EventFields) Field(logPos uint, name string) (LogFieldData, error) {
+	pos, err := e.position(name)
+	if err != nil {
+		return LogFieldData{}, err
+	}
+	return LogFieldData{
+		EventID:    e.event.ID,
+		LogPos:     logPos,
+		IsTopic:    pos.IsTopic,
+		FieldIndex: pos.FieldIndex,
+	}, nil
+}
+
+// Fields returns the LogFieldData of the arguments named names in the log at
+// logPos of a receipt. See Field.
+func (e *EventFields) Fields(logPos uint, names ...string) ([]LogFieldData, error) {
+	var fields []LogFieldData
+	for _, name := range names {
+		field, err := e.Field(logPos, name)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// LogFieldByRef returns the LogFieldData of the log at logPos from a field
+// reference of the form "<event signature>.<argument name>", e.g.
+//
+//	Transfer(address indexed from, address indexed to, uint256 value).value
+//
+// It is used where only a string can be passed, e.g. in the event_id of the
+// prover's Field.
+func LogFieldByRef(logPos uint, ref string) (LogFieldData, error) {
+	i := strings.LastIndex(ref, ").")
+	if i < 0 {
+		return LogFieldData{}, fmt.Errorf("invalid log field reference %q, expected <event signature>.<argument name>", ref)
+	}
+	e, err := ParseEventFields(ref[:i+1])
+	if err != nil {
+		return LogFieldData{}, err
+	}
+	return e.Field(logPos, strings.TrimSpace(ref[i+2:]))
+}
+
+// position returns the position of the named argument in the logs of the
+// event. An indexed argument is a topic at 1 + its position among the indexed
+// arguments. A non-indexed argument is at the index of its 32-byte word in the
+// head of the ABI-encoded data.
+func (e *EventFields) position(name string) (LogFieldPos, error) {
+	topic, word := uint(1), uint(0)
+	for _, arg := range e.event.Inputs {
+		if arg.Name == name {
+			if isDynamicType(arg.Type) {
+				if arg.Indexed {
+					return LogFieldPos{}, fmt.Errorf("argument %s of event %s has dynamic type %s, its topic is the keccak256 hash of the value",
+						name, e.event.Name, arg.Type.String())
+				}
+				return LogFieldPos{}, fmt.Errorf("argument %s of event %s has dynamic type %s, its value is not in the head of the log data",
+					name, e.event.Name, arg.Type.String())
+			}
+			if !isWordType(arg.Type) {
+				return LogFieldPos{}, fmt.Errorf("argument %s of event %s has type %s, only 32-byte static types are supported",
+					name, e.event.Name, arg.Type.String())
+			}
+			if arg.Indexed {
+				return LogFieldPos{IsTopic: true, FieldIndex: topic}, nil
+			}
+			return LogFieldPos{IsTopic: false, FieldIndex: word}, nil
+		}
+		if arg.Indexed {
+			topic++
+		} else {
+			word += headWords(arg.Type)
+		}
+	}
+	return LogFieldPos{}, fmt.Errorf("argument %s not found in event %s", name, e.event.Name)
+}
+
+func parseEventSignature(signature string) (abi.Event, error) {
+	sig := strings.TrimSuffix(strings.TrimSpace(signature), ";")
+	sig = strings.TrimSpace(strings.TrimPrefix(sig, "event "))
+	open, end := strings.Index(sig, "("), strings.LastIndex(sig, ")")
+	if open <= 0 || end != len(sig)-1 {
+		return abi.Event{}, fmt.Errorf("invalid event signature %q", signature)
+	}
+	name := strings.TrimSpace(sig[:open])
+	params := strings.TrimSpace(sig[open+1 : end])
+	if strings.ContainsAny(params, "()") {
+		return abi.Event{}, fmt.Errorf("tuple arguments in event signature %q are not supported", signature)
+	}
+
+	var inputs abi.Arguments
+	if params != "" {
+		for _, param := range strings.Split(params, ",") {
+			tokens := strings.Fields(param)
+			if len(tokens) == 0 || len(tokens) > 3 {
+				return abi.Event{}, fmt.Errorf("invalid argument %q in event signature %q", param, signature)
+			}
+			typ, err := abi.NewType(tokens[0], "", nil)
+			if err != nil {
+				return abi.Event{}, fmt.Errorf("invalid argument type %q in event signature %q: %s", tokens[0], signature, err.Error())
+			}
+			arg := abi.Argument{Type: typ}
+			rest := tokens[1:]
+			if len(rest) > 0 && rest[0] == "indexed" {
+				arg.Indexed = true
+				rest = rest[1:]
+			}
+			if len(rest) > 1 {
+				return abi.Event{}, fmt.Errorf("invalid argument %q in event signature %q", param, signature)
+			}
+			if len(rest) == 1 {
+				arg.Name = rest[0]
+			}
+			inputs = append(inputs, arg)
+		}
+	}
+	return abi.NewEvent(name, name, false, inputs), nil
+}
+
+// isWordType returns whether a value of type t is encoded as a single 32-byte
+// word, both in the topics and in the data of a log
+func isWordType(t abi.Type) bool {
+	switch t.T {
+	case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy, abi.HashTy, abi.FunctionTy:
+		return true
+	}
+	return false
+}
+
+// headWords returns the number of 32-byte words a value of type t takes in the
+// head of ABI-encoded data. Dynamic types are encoded as an offset to the tail.
+func headWords(t abi.Type) uint {
+	if isDynamicType(t) {
+		return 1
+	}
+	switch t.T {
+	case abi.ArrayTy:
+		return uint(t.Size) * headWords(*t.Elem)
+	case abi.TupleTy:
+		var n uint
+		for _, elem := range t.TupleElems {
+			n += headWords(*elem)
+		}
+		return n
+	}
+	return 1
+}
+
+func isDynamicType(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy:
+		return true
+	case abi.ArrayTy:
+		return isDynamicType(*t.Elem)
+	case abi.TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
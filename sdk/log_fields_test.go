@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestEventFields(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testSwapABI))
+	check(err)
+
+	swap, err := NewEventFields(parsed.Events["Swap"])
+	check(err)
+	fields, err := swap.Fields(3, "recipient", "amount1", "tick")
+	check(err)
+	expected := []LogFieldPos{{LogPos: 3, IsTopic: true, FieldIndex: 2}, {LogPos: 3, FieldIndex: 1}, {LogPos: 3, FieldIndex: 4}}
+	for i, f := range fields {
+		if f.EventID != parsed.Events["Swap"].ID {
+			t.Errorf("field %d: expected event id %x, got %x", i, parsed.Events["Swap"].ID, f.EventID)
+		}
+		if (LogFieldPos{LogPos: f.LogPos, IsTopic: f.IsTopic, FieldIndex: f.FieldIndex}) != expected[i] {
+			t.Errorf("field %d: expected %+v, got %+v", i, expected[i], f)
+		}
+	}
+
+	// the string takes one word for its offset and the array takes three
+	routed, err := NewEventFields(parsed.Events["Routed"])
+	check(err)
+	fields, err = routed.Fields(0, "amount", "id")
+	check(err)
+	if fields[0].IsTopic || fields[0].FieldIndex != 4 || !fields[1].IsTopic || fields[1].FieldIndex != 1 {
+		t.Errorf("unexpected fields of Routed: %+v", fields)
+	}
+
+	if _, err = routed.Field(0, "memo"); err == nil {
+		t.Error("expected a string argument to be rejected")
+	}
+	if _, err = routed.Field(0, "path"); err == nil {
+		t.Error("expected an array argument to be rejected")
+	}
+	if _, err = swap.Field(0, "amount2"); err == nil {
+		t.Error("expected an unknown argument to be rejected")
+	}
+}
+
+func TestParseEventFields(t *testing.T) {
+	transfer, err := ParseEventFields("event Transfer(address indexed from, address indexed to, uint256 value);")
+	check(err)
+	if transfer.Event().ID != crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")) {
+		t.Errorf("unexpected event id %x", transfer.Event().ID)
+	}
+	value, err := transfer.Field(1, "value")
+	check(err)
+	if value.IsTopic || value.FieldIndex != 0 || value.LogPos != 1 {
+		t.Errorf("unexpected field %+v", value)
+	}
+
+	ref, err := LogFieldByRef(1, "Transfer(address indexed from, address indexed to, uint256 value).to")
+	check(err)
+	if !ref.IsTopic || ref.FieldIndex != 2 || ref.EventID != transfer.Event().ID {
+		t.Errorf("unexpected field %+v", ref)
+	}
+
+	for _, sig := range []string{
+		"Transfer",
+		"Transfer(address indexed from",
+		"Transfer(unit256 value)",
+		"Transfer(address indexed from to)",
+		"Swap((uint256,uint256) amounts)",
+	} {
+		if _, err = ParseEventFields(sig); err == nil {
+			t.Errorf("expected signature %q to be rejected", sig)
+		}
+	}
+	if _, err = LogFieldByRef(0, "Transfer(address indexed from)"); err == nil {
+		t.Error("expected a reference without argument name to be rejected")
+	}
+}
@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LogFilter selects the event logs to be added as receipts by
+// BrevisApp.AddReceiptsFromLogs
+type LogFilter struct {
+	// The block range to search, both inclusive. A nil FromBlock or ToBlock
+	// means the latest block.
+	FromBlock *big.Int
+	ToBlock   *big.Int
+	// The contracts that emit the event. Logs from any contract are matched if
+	// empty.
+	Addresses []common.Address
+	// The event to match. topics[0] of the matched logs is Event.ID
+	Event abi.Event
+	// Optional filters on the indexed arguments of the event, i.e. topics[1:].
+	// Same as in eth_getLogs, an empty position matches any value.
+	Topics [][]common.Hash
+	// The names of the event arguments to be used as the fields of each receipt
+	Fields []string
+}
+
+// AddReceiptsFromLogs runs eth_getLogs with the filter and adds one receipt per
+// matched log with the fields selected by name. The receipts are added in the
+// order of the logs on chain. It returns the added ReceiptData.
+//
+// No receipt is added if the matched logs don't fit in the receipts allocated
+// by app, i.e. the allocated max receipts minus the receipts already added.
+func (q *BrevisApp) AddReceiptsFromLogs(app AppCircuit, filter LogFilter) ([]ReceiptData, error) {
+	return q.AddReceiptsFromLogsCtx(context.Background(), app, filter)
+}
+
+// AddReceiptsFromLogsCtx is AddReceiptsFromLogs with a context that bounds the
+// eth_getLogs and receipt queries
+func (q *BrevisApp) AddReceiptsFromLogsCtx(ctx context.Context, app AppCircuit, filter LogFilter) ([]ReceiptData, error) {
+	if len(filter.Fields) == 0 {
+		return nil, fmt.Errorf("no fields selected for event %s", filter.Event.Name)
+	}
+	if len(filter.Fields) > NumMaxLogFields {
+		return nil, fmt.Errorf("maximum number of log fields in one receipt is %d, got %d", NumMaxLogFields, len(filter.Fields))
+	}
+	eventFields, err := NewEventFields(filter.Event)
+	if err != nil {
+		return nil, err
+	}
+	// resolved at log pos 0, the actual log pos is set for each log
+	selected, err := eventFields.Fields(0, filter.Fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []types.Log
+	query := ethereum.FilterQuery{
+		FromBlock: filter.FromBlock,
+		ToBlock:   filter.ToBlock,
+		Addresses: filter.Addresses,
+		Topics:    append([][]common.Hash{{filter.Event.ID}}, filter.Topics...),
+	}
+	err = q.chain().failover(ctx, func(ctx context.Context, e *rpcEndpoint) (err error) {
+		logs, err = e.ec.FilterLogs(ctx, query)
+		return
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get logs of event %s: %s", filter.Event.Name, err.Error())
+	}
+
+	maxReceipts, _, _ := app.Allocate()
+	available := maxReceipts - len(q.receipts.ordered) - len(q.receipts.special)
+	var matched []types.Log
+	for _, l := range logs {
+		if !l.Removed {
+			matched = append(matched, l)
+		}
+	}
+	if len(matched) > available {
+		return nil, fmt.Errorf("%d logs of event %s found but only %d receipts are available, check your AppCircuit.Allocate() method",
+			len(matched), filter.Event.Name, available)
+	}
+
+	// the first log index of each receipt, used to locate the logs in their receipts
+	firstLogIndex := make(map[common.Hash]uint)
+	var receipts []ReceiptData
+	for _, l := range matched {
+		first, ok := firstLogIndex[l.TxHash]
+		if !ok {
+			receipt, err := q.chain().receipt(ctx, l.TxHash)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get receipt of tx %s: %s", l.TxHash.Hex(), err.Error())
+			}
+			if len(receipt.Logs) == 0 {
+				return nil, fmt.Errorf("receipt of tx %s has no logs", l.TxHash.Hex())
+			}
+			first = receipt.Logs[0].Index
+			firstLogIndex[l.TxHash] = first
+		}
+		if l.Index < first {
+			return nil, fmt.Errorf("log %d not found in receipt of tx %s", l.Index, l.TxHash.Hex())
+		}
+		logPos := l.Index - first
+
+		var fields []LogFieldData
+		for _, field := range selected {
+			var value common.Hash
+			if field.IsTopic {
+				if int(field.FieldIndex) >= len(l.Topics) {
+					return nil, fmt.Errorf("invalid field index %d for tx %s log %d, which topics length is %d",
+						field.FieldIndex, l.TxHash.Hex(), l.Index, len(l.Topics))
+				}
+				value = l.Topics[field.FieldIndex]
+			} else {
+				if int(field.FieldIndex)*32+32 > len(l.Data) {
+					return nil, fmt.Errorf("invalid field index %d for tx %s log %d, which data length is %d",
+						field.FieldIndex, l.TxHash.Hex(), l.Index, len(l.Data))
+				}
+				value = common.BytesToHash(l.Data[field.FieldIndex*32 : field.FieldIndex*32+32])
+			}
+			field.Contract = l.Address
+			field.LogPos = logPos
+			field.Value = value
+			fields = append(fields, field)
+		}
+		receipts = append(receipts, ReceiptData{
+			TxHash:   l.TxHash,
+			BlockNum: new(big.Int).SetUint64(l.BlockNumber),
+			Fields:   fields,
+		})
+	}
+
+	for _, r := range receipts {
+		q.AddReceipt(r)
+	}
+	return receipts, nil
+}
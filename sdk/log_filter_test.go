@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const testSwapABI = `[{"anonymous":false,"inputs":[
+	{"indexed":true,"name":"sender","type":"address"},
+	{"indexed":true,"name":"recipient","type":"address"},
+	{"indexed":false,"name":"amount0","type":"int256"},
+	{"indexed":false,"name":"amount1","type":"int256"},
+	{"indexed":false,"name":"sqrtPriceX96","type":"uint160"},
+	{"indexed":false,"name":"liquidity","type":"uint128"},
+	{"indexed":false,"name":"tick","type":"int24"}],
+	"name":"Swap","type":"event"},
+	{"anonymous":false,"inputs":[
+	{"indexed":false,"name":"memo","type":"string"},
+	{"indexed":false,"name":"path","type":"address[3]"},
+	{"indexed":true,"name":"id","type":"uint256"},
+	{"indexed":false,"name":"amount","type":"uint256"}],
+	"name":"Routed","type":"event"}]`
+
+func TestAddReceiptsFromLogs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testSwapABI))
+	check(err)
+	swap := parsed.Events["Swap"]
+	pool := common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640")
+	sender := common.HexToHash("0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD")
+	recipient := common.HexToHash("0x1111111254EEB25477B68fb85Ed929f73A960582")
+	data, err := swap.Inputs.NonIndexed().Pack(big.NewInt(-1000), big.NewInt(2000), big.NewInt(3), big.NewInt(4), big.NewInt(-5))
+	check(err)
+
+	// tx1 emits a transfer and a swap, tx2 emits a swap
+	tx1, tx2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+	transfer := &types.Log{Address: pool, Topics: []common.Hash{{0xdd}}, TxHash: tx1, BlockNumber: 100, Index: 7}
+	swap1 := &types.Log{Address: pool, Topics: []common.Hash{swap.ID, sender, recipient}, Data: data, TxHash: tx1, BlockNumber: 100, Index: 8}
+	swap2 := &types.Log{Address: pool, Topics: []common.Hash{swap.ID, sender, recipient}, Data: data, TxHash: tx2, BlockNumber: 101, Index: 0}
+	receipts := map[common.Hash]*types.Receipt{
+		tx1: {TxHash: tx1, Status: 1, Logs: []*types.Log{transfer, swap1}},
+		tx2: {TxHash: tx2, Status: 1, Logs: []*types.Log{swap2}},
+	}
+
+	rpc := newFakeRPC(t, map[string]func(params []json.RawMessage) (interface{}, error){
+		"eth_getLogs": func(params []json.RawMessage) (interface{}, error) {
+			return []*types.Log{swap1, swap2}, nil
+		},
+		"eth_getTransactionReceipt": func(params []json.RawMessage) (interface{}, error) {
+			var hash common.Hash
+			check(json.Unmarshal(params[0], &hash))
+			return receipts[hash], nil
+		},
+	})
+	ec, err := ethclient.Dial(rpc.URL)
+	check(err)
+	q := &BrevisApp{ec: ec}
+
+	added, err := q.AddReceiptsFromLogs(testAllocation{receipts: 32}, LogFilter{
+		FromBlock: big.NewInt(100),
+		ToBlock:   big.NewInt(101),
+		Addresses: []common.Address{pool},
+		Event:     swap,
+		Fields:    []string{"recipient", "amount1"},
+	})
+	check(err)
+	if len(added) != 2 || len(q.receipts.ordered) != 2 {
+		t.Fatalf("expected 2 receipts to be added, got %d", len(added))
+	}
+	if added[0].Fields[0].LogPos != 1 || added[1].Fields[0].LogPos != 0 {
+		t.Errorf("expected log positions 1 and 0, got %d and %d", added[0].Fields[0].LogPos, added[1].Fields[0].LogPos)
+	}
+	if added[0].Fields[0].Value != recipient || !added[0].Fields[0].IsTopic {
+		t.Errorf("expected recipient topic, got %+v", added[0].Fields[0])
+	}
+	if added[1].Fields[1].Value != common.BigToHash(big.NewInt(2000)) || added[1].Fields[1].FieldIndex != 1 {
+		t.Errorf("expected amount1 data field, got %+v", added[1].Fields[1])
+	}
+	if rpc.count("eth_getTransactionReceipt") != 2 {
+		t.Errorf("expected one receipt fetch per tx, got %d", rpc.count("eth_getTransactionReceipt"))
+	}
+
+	// only one more receipt fits in the allocation
+	q.receipts = rawData[ReceiptData]{}
+	for i := 0; i < 31; i++ {
+		q.AddReceipt(ReceiptData{TxHash: common.BigToHash(big.NewInt(int64(i)))})
+	}
+	_, err = q.AddReceiptsFromLogs(testAllocation{receipts: 32}, LogFilter{Event: swap, Fields: []string{"amount0"}})
+	if err == nil {
+		t.Error("expected logs exceeding the allocation to be rejected")
+	}
+	if len(q.receipts.ordered) != 31 {
+		t.Errorf("expected no receipt to be added, got %d", len(q.receipts.ordered)-31)
+	}
+}
+
+type testAllocation struct {
+	receipts, storage, txs int
+}
+
+func (a testAllocation) Allocate() (maxReceipts, maxStorage, maxTransactions int) {
+	return a.receipts, a.storage, a.txs
+}
+
+func (a testAllocation) Define(api *CircuitAPI, in DataInput) error { return nil }
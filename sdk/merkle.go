@@ -0,0 +1,399 @@
+package sdk
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/brevis-network/zk-hash/poseidon"
+	"github.com/brevis-network/zk-hash/utils"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MerkleHash specifies how the leaves and the nodes of a Merkle tree are hashed
+type MerkleHash int
+
+const (
+	// MerkleKeccakSortedPair hashes a leaf to keccak256(leaf) and two children
+	// to keccak256(min(a, b) || max(a, b)). This is the scheme of
+	// OpenZeppelin's MerkleProof and StandardMerkleTree, where the leaf is
+	// keccak256(abi.encode(values)). A leaf is hashed from 32 bytes and a node
+	// from 64 bytes, so no node passes for a leaf. Since the position of a node
+	// is not committed, trees of this kind only support inclusion proofs, and
+	// their leaves may be of different depths.
+	MerkleKeccakSortedPair MerkleHash = iota
+	// MerkleKeccak hashes a leaf to keccak256(0x00 || leaf) and two children to
+	// keccak256(0x01 || left || right)
+	MerkleKeccak
+	// MerklePoseidon hashes a leaf to poseidon(0, leaf) and two children to
+	// poseidon(1, left, right) over the BN254 scalar field. Leaves must be less
+	// than the BN254 scalar field modulus.
+	MerklePoseidon
+	// MerkleKeccakSortedPairRawLeaf is MerkleKeccakSortedPair with the leaf used
+	// as the node as is, like OpenZeppelin's MerkleProof.verify in airdrops
+	// such as Uniswap's merkle-distributor, where the leaf is
+	// keccak256(abi.encodePacked(index, account, amount)). Nothing tells a node
+	// from a leaf, so the circuit must compute the leaf from its values rather
+	// than take it from the proof as is.
+	MerkleKeccakSortedPairRawLeaf
+)
+
+// isSortedPair returns whether the children of the nodes are sorted instead of
+// placed by the index of the leaf
+func (h MerkleHash) isSortedPair() bool {
+	return h == MerkleKeccakSortedPair || h == MerkleKeccakSortedPairRawLeaf
+}
+
+// the prefixes separating the leaves from the nodes of MerkleKeccak and
+// MerklePoseidon trees
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleProof is the custom input proving that Leaf is in a Merkle tree.
+// Siblings holds the sibling nodes from the leaf level up. For
+// MerkleKeccakSortedPair, Siblings is padded to the max depth supported by the
+// circuit, of which only the first Depth are used. The same goes for
+// MerkleKeccakSortedPairRawLeaf. For the other kinds, the
+// depth of the tree is fixed by the circuit to len(Siblings) and Depth must be
+// equal to it. Index is the position of the leaf among the leaves and is
+// ignored by the sorted pair kinds. Use MerkleTree.Proof to build one.
+type MerkleProof struct {
+	Leaf     Bytes32
+	Index    Uint248
+	Siblings List[Bytes32]
+	Depth    Uint248
+}
+
+// MerkleRoot computes the root of the Merkle tree from proof
+func (api *CircuitAPI) MerkleRoot(hash MerkleHash, proof MerkleProof) Bytes32 {
+	g := api.g
+	maxDepth := len(proof.Siblings)
+	var isUsed []frontend.Variable
+	if hash.isSortedPair() {
+		rangeChecker := rangecheck.New(g)
+		rangeChecker.Check(proof.Depth.Val, 32)
+		rangeChecker.Check(g.Sub(maxDepth, proof.Depth.Val), 32)
+		isUsed, _ = api.lengthFlags(proof.Depth.Val, maxDepth+1)
+	} else {
+		g.AssertIsEqual(proof.Depth.Val, maxDepth)
+	}
+	indexBits := make([]frontend.Variable, maxDepth)
+	if maxDepth > 0 {
+		indexBits = g.ToBinary(proof.Index.Val, maxDepth)
+	} else {
+		g.AssertIsEqual(proof.Index.Val, 0)
+	}
+
+	if hash == MerklePoseidon {
+		// rules out the aliases of the leaf that are not less than the modulus
+		api.Bytes32.AssertIsEqual(api.Bytes32.FromFV(merkleNodeToFV(g, proof.Leaf)), proof.Leaf)
+	}
+	node := api.hashMerkleLeaf(hash, proof.Leaf)
+	for i, sibling := range proof.Siblings {
+		parent := api.hashMerklePair(hash, node, sibling, indexBits[i])
+		if isUsed == nil {
+			node = parent
+			continue
+		}
+		// the index must be less than 2^Depth
+		g.AssertIsEqual(g.Mul(g.Sub(1, isUsed[i]), indexBits[i]), 0)
+		node = api.Bytes32.Select(newU248(isUsed[i]), parent, node)
+	}
+	return node
+}
+
+// AssertMerkleInclusion asserts that proof.Leaf is in the Merkle tree of root
+func (api *CircuitAPI) AssertMerkleInclusion(hash MerkleHash, root Bytes32, proof MerkleProof) {
+	api.Bytes32.AssertIsEqual(api.MerkleRoot(hash, proof), root)
+}
+
+// AssertMerkleNonInclusion asserts that value is not in the Merkle tree of
+// root, given that the leaves of the tree are sorted in strictly ascending
+// order. low and high prove the inclusion of two adjacent leaves such that
+// low.Leaf < value < high.Leaf. To prove that a value is not in the tree when
+// it is less than the smallest or greater than the largest leaf, the tree
+// should include the sentinel leaves 0 and the maximum value. Use
+// MerkleTree.NonInclusionProof to build low and high. Panics for
+// the sorted pair kinds since they do not commit to the leaf positions.
+func (api *CircuitAPI) AssertMerkleNonInclusion(hash MerkleHash, root, value Bytes32, low, high MerkleProof) {
+	if hash.isSortedPair() {
+		panic("non-inclusion proofs are not supported by sorted pair trees")
+	}
+	if len(low.Siblings) != len(high.Siblings) {
+		panic(fmt.Sprintf("inconsistent depths of the low (%d) and high (%d) proofs", len(low.Siblings), len(high.Siblings)))
+	}
+	g := api.g
+	api.AssertMerkleInclusion(hash, root, low)
+	api.AssertMerkleInclusion(hash, root, high)
+	g.AssertIsEqual(g.Add(low.Index.Val, 1), high.Index.Val)
+	u256 := api.Uint256
+	g.AssertIsEqual(u256.IsLessThan(api.ToUint256(low.Leaf), api.ToUint256(value)).Val, 1)
+	g.AssertIsEqual(u256.IsLessThan(api.ToUint256(value), api.ToUint256(high.Leaf)).Val, 1)
+}
+
+// hashMerkleLeaf returns the node of leaf in the tree
+func (api *CircuitAPI) hashMerkleLeaf(hash MerkleHash, leaf Bytes32) Bytes32 {
+	switch hash {
+	case MerkleKeccakSortedPair:
+		return api.Keccak256([]Bytes32{leaf}, []int32{256})
+	case MerkleKeccakSortedPairRawLeaf:
+		return leaf
+	case MerkleKeccak:
+		prefix := ConstFromBigEndianBytes([]byte{merkleLeafPrefix})
+		return api.Keccak256([]Bytes32{prefix, leaf}, []int32{8, 256})
+	case MerklePoseidon:
+		return api.hashMerklePoseidon(merkleLeafPrefix, merkleNodeToFV(api.g, leaf))
+	}
+	panic(fmt.Sprintf("unsupported merkle hash %d", hash))
+}
+
+// hashMerklePair returns the parent of node and sibling. isRight is 1 if node
+// is the right child
+func (api *CircuitAPI) hashMerklePair(hash MerkleHash, node, sibling Bytes32, isRight frontend.Variable) Bytes32 {
+	switch hash {
+	case MerkleKeccakSortedPair, MerkleKeccakSortedPairRawLeaf:
+		lt := api.Uint256.IsLessThan(api.ToUint256(node), api.ToUint256(sibling))
+		a, b := api.Bytes32.Select(lt, node, sibling), api.Bytes32.Select(lt, sibling, node)
+		return api.Keccak256([]Bytes32{a, b}, []int32{256, 256})
+	case MerkleKeccak:
+		s := newU248(isRight)
+		left, right := api.Bytes32.Select(s, sibling, node), api.Bytes32.Select(s, node, sibling)
+		prefix := ConstFromBigEndianBytes([]byte{merkleNodePrefix})
+		return api.Keccak256([]Bytes32{prefix, left, right}, []int32{8, 256, 256})
+	case MerklePoseidon:
+		n, s := merkleNodeToFV(api.g, node), merkleNodeToFV(api.g, sibling)
+		return api.hashMerklePoseidon(merkleNodePrefix, api.g.Select(isRight, s, n), api.g.Select(isRight, n, s))
+	}
+	panic(fmt.Sprintf("unsupported merkle hash %d", hash))
+}
+
+func (api *CircuitAPI) hashMerklePoseidon(prefix int, values ...frontend.Variable) Bytes32 {
+	hasher, err := poseidon.NewBn254PoseidonCircuit(api.g)
+	if err != nil {
+		panic(fmt.Errorf("error creating poseidon hasher instance: %s", err.Error()))
+	}
+	hasher.Write(prefix)
+	for _, v := range values {
+		hasher.Write(v)
+	}
+	return api.Bytes32.FromFV(hasher.Sum())
+}
+
+// merkleNodeToFV packs v to a native field element. Values not less than the
+// modulus wrap around.
+func merkleNodeToFV(g frontend.API, v Bytes32) frontend.Variable {
+	return g.Add(v.Val[0], g.Mul(v.Val[1], new(big.Int).Lsh(big.NewInt(1), uint(numBitsPerVar))))
+}
+
+// MerkleTree builds Merkle trees outside of circuit and produces the proofs
+// consumed by AssertMerkleInclusion and AssertMerkleNonInclusion.
+type MerkleTree struct {
+	hash   MerkleHash
+	leaves []common.Hash
+	// nodes holds the whole tree. For MerkleKeccakSortedPair, it is laid out
+	// the same as OpenZeppelin's makeMerkleTree, i.e. the children of node i
+	// are 2i+1 and 2i+2, and the node of leaf i is at len(nodes)-1-i.
+	// Otherwise, nodes[0] holds the nodes of the leaves and nodes[k] holds the
+	// k-th level above them. For MerkleKeccakSortedPairRawLeaf, nodes[0] is
+	// the sorted leaves and a node without a sibling moves up a level as is.
+	nodes [][]common.Hash
+}
+
+// NewMerkleTree builds a Merkle tree from leaves in the given order. For
+// MerkleKeccakSortedPair, the leaves are keccak256(abi.encode(values)) and the
+// tree is the same as the one built by OpenZeppelin's StandardMerkleTree with
+// the leaves in the same order. For MerkleKeccakSortedPairRawLeaf, the tree is
+// the same as the one built by Uniswap's merkle-distributor, i.e. of the leaves
+// sorted with the duplicates removed. For the other kinds, the number of leaves
+// must be a power of 2.
+func NewMerkleTree(hash MerkleHash, leaves []common.Hash) (*MerkleTree, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("cannot build merkle tree with no leaves")
+	}
+	t := &MerkleTree{hash: hash, leaves: append([]common.Hash{}, leaves...)}
+	switch hash {
+	case MerkleKeccakSortedPair:
+		tree := make([]common.Hash, 2*len(leaves)-1)
+		for i, leaf := range leaves {
+			tree[len(tree)-1-i] = crypto.Keccak256Hash(leaf[:])
+		}
+		for i := len(tree) - 1 - len(leaves); i >= 0; i-- {
+			tree[i] = hashSortedPair(tree[2*i+1], tree[2*i+2])
+		}
+		t.nodes = [][]common.Hash{tree}
+	case MerkleKeccakSortedPairRawLeaf:
+		level := append([]common.Hash{}, leaves...)
+		slices.SortFunc(level, compareHashes)
+		level = slices.Compact(level)
+		t.nodes = append(t.nodes, level)
+		for len(level) > 1 {
+			next := make([]common.Hash, (len(level)+1)/2)
+			for i := range next {
+				next[i] = level[2*i]
+				if 2*i+1 < len(level) {
+					next[i] = hashSortedPair(level[2*i], level[2*i+1])
+				}
+			}
+			t.nodes = append(t.nodes, next)
+			level = next
+		}
+	case MerkleKeccak, MerklePoseidon:
+		if !CheckNumberPowerOfTwo(len(leaves)) {
+			return nil, fmt.Errorf("number of leaves %d is not a power of 2", len(leaves))
+		}
+		level := make([]common.Hash, len(leaves))
+		for i, leaf := range leaves {
+			if hash == MerklePoseidon && leaf.Big().Cmp(ecc.BN254.ScalarField()) >= 0 {
+				return nil, fmt.Errorf("leaf %x is not less than the bn254 scalar field modulus", leaf)
+			}
+			node, err := t.hashNode(merkleLeafPrefix, leaf)
+			if err != nil {
+				return nil, err
+			}
+			level[i] = node
+		}
+		t.nodes = append(t.nodes, level)
+		for len(level) > 1 {
+			next := make([]common.Hash, len(level)/2)
+			for i := range next {
+				parent, err := t.hashNode(merkleNodePrefix, level[2*i], level[2*i+1])
+				if err != nil {
+					return nil, err
+				}
+				next[i] = parent
+			}
+			t.nodes = append(t.nodes, next)
+			level = next
+		}
+	default:
+		return nil, fmt.Errorf("unsupported merkle hash %d", hash)
+	}
+	return t, nil
+}
+
+// Root returns the root of the tree
+func (t *MerkleTree) Root() common.Hash {
+	if t.hash == MerkleKeccakSortedPair {
+		return t.nodes[0][0]
+	}
+	return t.nodes[len(t.nodes)-1][0]
+}
+
+// Proof builds the inclusion proof of the leaf at index. maxDepth is the depth
+// supported by the circuit, i.e. the length of MerkleProof.Siblings. Except for
+// the sorted pair kinds, it must be the depth of the tree.
+func (t *MerkleTree) Proof(index, maxDepth int) (MerkleProof, error) {
+	if index < 0 || index >= len(t.leaves) {
+		return MerkleProof{}, fmt.Errorf("leaf index %d out of range [0, %d)", index, len(t.leaves))
+	}
+	leaf := t.leaves[index]
+	var siblings []common.Hash
+	switch t.hash {
+	case MerkleKeccakSortedPair:
+		tree := t.nodes[0]
+		for i := len(tree) - 1 - index; i > 0; i = (i - 1) / 2 {
+			if i%2 == 1 {
+				siblings = append(siblings, tree[i+1])
+			} else {
+				siblings = append(siblings, tree[i-1])
+			}
+		}
+		// the position is not part of the proof
+		index = 0
+	case MerkleKeccakSortedPairRawLeaf:
+		i, _ := slices.BinarySearchFunc(t.nodes[0], leaf, compareHashes)
+		for k := 0; k < len(t.nodes)-1; k, i = k+1, i/2 {
+			if i^1 < len(t.nodes[k]) {
+				siblings = append(siblings, t.nodes[k][i^1])
+			}
+		}
+		index = 0
+	default:
+		for k, i := 0, index; k < len(t.nodes)-1; k, i = k+1, i/2 {
+			siblings = append(siblings, t.nodes[k][i^1])
+		}
+	}
+	if len(siblings) > maxDepth {
+		return MerkleProof{}, fmt.Errorf("proof depth %d exceeds max depth %d", len(siblings), maxDepth)
+	}
+	if !t.hash.isSortedPair() && len(siblings) != maxDepth {
+		return MerkleProof{}, fmt.Errorf("tree depth %d is not the depth %d of the circuit", len(siblings), maxDepth)
+	}
+	proof := MerkleProof{
+		Leaf:     ConstFromBigEndianBytes(leaf.Bytes()),
+		Index:    ConstUint248(index),
+		Siblings: make(List[Bytes32], maxDepth),
+		Depth:    ConstUint248(len(siblings)),
+	}
+	for i := range proof.Siblings {
+		proof.Siblings[i] = ConstFromBigEndianBytes(nil)
+		if i < len(siblings) {
+			proof.Siblings[i] = ConstFromBigEndianBytes(siblings[i].Bytes())
+		}
+	}
+	return proof, nil
+}
+
+// NonInclusionProof builds the proofs of the two adjacent leaves low and high
+// such that low < value < high. The leaves must be sorted in strictly ascending
+// order. maxDepth is the depth of the tree supported by the circuit.
+func (t *MerkleTree) NonInclusionProof(value common.Hash, maxDepth int) (low, high MerkleProof, err error) {
+	if t.hash.isSortedPair() {
+		return low, high, fmt.Errorf("non-inclusion proofs are not supported by sorted pair trees")
+	}
+	for i := 0; i+1 < len(t.leaves); i++ {
+		if bytes.Compare(t.leaves[i][:], t.leaves[i+1][:]) >= 0 {
+			return low, high, fmt.Errorf("leaves are not sorted in strictly ascending order at index %d", i)
+		}
+	}
+	for i := 0; i+1 < len(t.leaves); i++ {
+		if bytes.Compare(t.leaves[i][:], value[:]) < 0 && bytes.Compare(value[:], t.leaves[i+1][:]) < 0 {
+			if low, err = t.Proof(i, maxDepth); err != nil {
+				return
+			}
+			high, err = t.Proof(i+1, maxDepth)
+			return
+		}
+	}
+	return low, high, fmt.Errorf("value %x is in the tree or out of the range of the leaves", value)
+}
+
+// hashNode hashes the prefixed values of a leaf or a node of MerkleKeccak and
+// MerklePoseidon trees
+func (t *MerkleTree) hashNode(prefix byte, values ...common.Hash) (common.Hash, error) {
+	if t.hash != MerklePoseidon {
+		data := [][]byte{{prefix}}
+		for _, v := range values {
+			data = append(data, v[:])
+		}
+		return crypto.Keccak256Hash(data...), nil
+	}
+	hasher := utils.NewPoseidonBn254()
+	hasher.Write(big.NewInt(int64(prefix)))
+	for _, v := range values {
+		hasher.Write(v.Big())
+	}
+	sum, err := hasher.Sum()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("fail to hash merkle node with poseidon bn254: %s", err.Error())
+	}
+	return common.BigToHash(sum), nil
+}
+
+func compareHashes(a, b common.Hash) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func hashSortedPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
@@ -0,0 +1,287 @@
+package sdk
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	testMerkleMaxDepth = 4
+	// the depth of the MerkleKeccak and MerklePoseidon trees of 4 leaves
+	testMerkleDepth = 2
+)
+
+func TestMerkleTree(t *testing.T) {
+	// leaves of different depths in a tree of 5 leaves
+	leaves := make([]common.Hash, 5)
+	for i := range leaves {
+		leaves[i] = crypto.Keccak256Hash(big.NewInt(int64(i)).Bytes())
+	}
+	tree, err := NewMerkleTree(MerkleKeccakSortedPair, leaves)
+	check(err)
+	for i, leaf := range leaves {
+		proof, err := tree.Proof(i, testMerkleMaxDepth)
+		check(err)
+		// the same as OpenZeppelin's MerkleProof.processProof
+		node := crypto.Keccak256Hash(leaf[:])
+		for j := 0; j < int(fromInterface(proof.Depth.Val).Int64()); j++ {
+			node = hashSortedPair(node, common.HexToHash(proof.Siblings[j].String()))
+		}
+		if node != tree.Root() {
+			t.Errorf("proof of leaf %d does not match the root", i)
+		}
+	}
+
+	tree, err = NewMerkleTree(MerkleKeccak, leaves[:2])
+	check(err)
+	root := crypto.Keccak256Hash([]byte{1}, crypto.Keccak256([]byte{0}, leaves[0][:]), crypto.Keccak256([]byte{0}, leaves[1][:]))
+	if tree.Root() != root {
+		t.Errorf("expected keccak root %x, got %x", root, tree.Root())
+	}
+
+	poseidonLeaves := []common.Hash{common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2)),
+		common.BigToHash(big.NewInt(3)), common.BigToHash(big.NewInt(4))}
+	tree, err = NewMerkleTree(MerklePoseidon, poseidonLeaves)
+	check(err)
+	if _, err = NewMerkleTree(MerklePoseidon, leaves[:3]); err == nil {
+		t.Error("expected tree of 3 leaves to be rejected")
+	}
+	if _, err = tree.Proof(0, testMerkleMaxDepth); err == nil {
+		t.Error("expected proof of a tree shallower than the circuit to be rejected")
+	}
+	if _, _, err = tree.NonInclusionProof(poseidonLeaves[1], testMerkleDepth); err == nil {
+		t.Error("expected non-inclusion proof of a leaf to fail")
+	}
+}
+
+func TestMerkleProofs(t *testing.T) {
+	var leaves []common.Hash
+	for i := 0; i < 5; i++ {
+		leaves = append(leaves, crypto.Keccak256Hash([]byte{byte(i)}))
+	}
+	ozTree, err := NewMerkleTree(MerkleKeccakSortedPair, leaves)
+	check(err)
+	sorted := []common.Hash{{}, common.BigToHash(big.NewInt(100)), common.BigToHash(big.NewInt(200)),
+		common.BigToHash(new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1)))}
+	keccakTree, err := NewMerkleTree(MerkleKeccak, sorted)
+	check(err)
+	poseidonTree, err := NewMerkleTree(MerklePoseidon, sorted)
+	check(err)
+	absent := common.BigToHash(big.NewInt(150))
+
+	c := &TestMerkleCircuit{
+		OZRoot:       ConstFromBigEndianBytes(ozTree.Root().Bytes()),
+		KeccakRoot:   ConstFromBigEndianBytes(keccakTree.Root().Bytes()),
+		PoseidonRoot: ConstFromBigEndianBytes(poseidonTree.Root().Bytes()),
+		Absent:       ConstFromBigEndianBytes(absent.Bytes()),
+	}
+	c.OZShallow, err = ozTree.Proof(0, testMerkleMaxDepth)
+	check(err)
+	c.OZDeep, err = ozTree.Proof(4, testMerkleMaxDepth)
+	check(err)
+	c.KeccakLow, c.KeccakHigh, err = keccakTree.NonInclusionProof(absent, testMerkleDepth)
+	check(err)
+	c.PoseidonLow, c.PoseidonHigh, err = poseidonTree.NonInclusionProof(absent, testMerkleDepth)
+	check(err)
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	// the value is included
+	c.Absent = c.KeccakHigh.Leaf
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected non-inclusion of an included leaf to fail")
+	}
+
+	c.Absent = ConstFromBigEndianBytes(absent.Bytes())
+	c.OZDeep.Leaf = ConstFromBigEndianBytes(crypto.Keccak256([]byte{5}))
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected inclusion of an absent leaf to fail")
+	}
+}
+
+type TestMerkleCircuit struct {
+	OZRoot, KeccakRoot, PoseidonRoot Bytes32
+	OZShallow, OZDeep                MerkleProof
+	KeccakLow, KeccakHigh            MerkleProof
+	PoseidonLow, PoseidonHigh        MerkleProof
+	Absent                           Bytes32
+}
+
+func (c *TestMerkleCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	api.AssertMerkleInclusion(MerkleKeccakSortedPair, c.OZRoot, c.OZShallow)
+	api.AssertMerkleInclusion(MerkleKeccakSortedPair, c.OZRoot, c.OZDeep)
+	api.AssertMerkleNonInclusion(MerkleKeccak, c.KeccakRoot, c.Absent, c.KeccakLow, c.KeccakHigh)
+	api.AssertMerkleNonInclusion(MerklePoseidon, c.PoseidonRoot, c.Absent, c.PoseidonLow, c.PoseidonHigh)
+	return nil
+}
+
+func TestMerkleShallowProofs(t *testing.T) {
+	// a tree whose internal nodes n0 and n1 of the level above the leaves are
+	// such that n0 < leaf 2 < n1
+	var leaves []common.Hash
+	var tree *MerkleTree
+	var err error
+	for k := int64(1); ; k++ {
+		leaves = []common.Hash{{}, common.BigToHash(big.NewInt(k)), common.BigToHash(new(big.Int).Lsh(big.NewInt(1), 255)),
+			common.BigToHash(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)))}
+		tree, err = NewMerkleTree(MerkleKeccak, leaves)
+		check(err)
+		n0, n1 := tree.nodes[1][0], tree.nodes[1][1]
+		if n0.Big().Cmp(leaves[2].Big()) < 0 && leaves[2].Big().Cmp(n1.Big()) < 0 {
+			break
+		}
+	}
+	// the proofs of the internal nodes as leaves of a tree of depth 1
+	shallowProof := func(index int) MerkleProof {
+		return MerkleProof{
+			Leaf:     ConstFromBigEndianBytes(tree.nodes[1][index].Bytes()),
+			Index:    ConstUint248(index),
+			Siblings: List[Bytes32]{ConstFromBigEndianBytes(tree.nodes[1][index^1].Bytes()), ConstFromBigEndianBytes(nil)},
+			Depth:    ConstUint248(1),
+		}
+	}
+	c := &TestMerkleNonInclusionCircuit{
+		Root:  ConstFromBigEndianBytes(tree.Root().Bytes()),
+		Value: ConstFromBigEndianBytes(leaves[2].Bytes()),
+		Low:   shallowProof(0),
+		High:  shallowProof(1),
+	}
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected non-inclusion of a leaf by internal nodes to fail")
+	}
+	c.Low.Depth, c.High.Depth = ConstUint248(testMerkleDepth), ConstUint248(testMerkleDepth)
+	c.Low.Siblings[1], c.High.Siblings[1] = c.Low.Siblings[0], c.High.Siblings[0]
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected non-inclusion of a leaf by internal nodes at the depth of the tree to fail")
+	}
+
+	// an internal node of an OpenZeppelin tree of 4 leaves
+	ozTree, err := NewMerkleTree(MerkleKeccakSortedPair, leaves)
+	check(err)
+	ozProof, err := ozTree.Proof(0, testMerkleMaxDepth)
+	check(err)
+	ozProof.Leaf = ConstFromBigEndianBytes(ozTree.nodes[0][1].Bytes())
+	ozProof.Siblings[0] = ConstFromBigEndianBytes(ozTree.nodes[0][2].Bytes())
+	ozProof.Depth = ConstUint248(1)
+	c2 := &TestMerkleInclusionCircuit{Root: ConstFromBigEndianBytes(ozTree.Root().Bytes()), Proof: ozProof}
+	err = test.IsSolved(c2, c2, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected inclusion of an internal node to fail")
+	}
+}
+
+type TestMerkleNonInclusionCircuit struct {
+	Root, Value Bytes32
+	Low, High   MerkleProof
+}
+
+func (c *TestMerkleNonInclusionCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	api.AssertMerkleNonInclusion(MerkleKeccak, c.Root, c.Value, c.Low, c.High)
+	return nil
+}
+
+type TestMerkleInclusionCircuit struct {
+	Root  Bytes32
+	Proof MerkleProof
+}
+
+func (c *TestMerkleInclusionCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	api.AssertMerkleInclusion(MerkleKeccakSortedPair, c.Root, c.Proof)
+	return nil
+}
+
+// testClaim is a claim of Uniswap's merkle-distributor, whose leaf is
+// keccak256(abi.encodePacked(index, account, amount))
+type testClaim struct {
+	index   int64
+	account common.Address
+	amount  int64
+}
+
+func (c testClaim) leaf() common.Hash {
+	return crypto.Keccak256Hash(common.BigToHash(big.NewInt(c.index)).Bytes(), c.account.Bytes(),
+		common.BigToHash(big.NewInt(c.amount)).Bytes())
+}
+
+func TestMerkleDistributor(t *testing.T) {
+	claims := []testClaim{
+		{0, common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"), 200},
+		{1, common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"), 300},
+		{2, common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"), 250},
+	}
+	var leaves []common.Hash
+	for _, c := range claims {
+		leaves = append(leaves, c.leaf())
+	}
+	tree, err := NewMerkleTree(MerkleKeccakSortedPairRawLeaf, leaves)
+	check(err)
+	// merkle-distributor pairs the sorted leaves from the left and moves the
+	// last one up as is
+	sorted := append([]common.Hash{}, leaves...)
+	slices.SortFunc(sorted, compareHashes)
+	root := hashSortedPair(hashSortedPair(sorted[0], sorted[1]), sorted[2])
+	if tree.Root() != root {
+		t.Fatalf("expected merkle-distributor root %x, got %x", root, tree.Root())
+	}
+	for i, leaf := range leaves {
+		proof, err := tree.Proof(i, testMerkleMaxDepth)
+		check(err)
+		// the same as OpenZeppelin's MerkleProof.verify
+		node := leaf
+		for j := 0; j < int(fromInterface(proof.Depth.Val).Int64()); j++ {
+			node = hashSortedPair(node, common.HexToHash(proof.Siblings[j].String()))
+		}
+		if node != root {
+			t.Errorf("proof of claim %d does not match the root", i)
+		}
+	}
+	if _, err = NewMerkleTree(MerkleKeccakSortedPairRawLeaf, append(leaves, leaves[0])); err != nil {
+		t.Errorf("expected duplicate leaves to be removed, got %v", err)
+	}
+
+	c := &TestMerkleClaimCircuit{
+		Root:    ConstFromBigEndianBytes(root.Bytes()),
+		Index:   ConstUint248(claims[1].index),
+		Account: ConstUint248(claims[1].account.Big()),
+		Amount:  ConstUint248(claims[1].amount),
+	}
+	c.Proof, err = tree.Proof(1, testMerkleMaxDepth)
+	check(err)
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	check(err)
+
+	c.Amount = ConstUint248(claims[1].amount + 1)
+	err = test.IsSolved(c, c, ecc.BN254.ScalarField())
+	if err == nil {
+		t.Error("expected a claim of another amount to fail")
+	}
+}
+
+type TestMerkleClaimCircuit struct {
+	Root                   Bytes32
+	Index, Account, Amount Uint248
+	Proof                  MerkleProof
+}
+
+func (c *TestMerkleClaimCircuit) Define(g frontend.API) error {
+	api := NewCircuitAPI(g)
+	leaf := api.Keccak256([]Bytes32{api.ToBytes32(c.Index), api.ToBytes32(c.Account), api.ToBytes32(c.Amount)},
+		[]int32{256, 160, 256})
+	api.Bytes32.AssertIsEqual(c.Proof.Leaf, leaf)
+	api.AssertMerkleInclusion(MerkleKeccakSortedPairRawLeaf, c.Root, c.Proof)
+	return nil
+}
@@ -35,8 +35,8 @@ func CompileWithHashInfo(app AppCircuit, compileOutDir, srsDir string, hashInfo
 		return nil, nil, nil, nil, err
 	}
 
-	maxReceipts, maxStorage, maxTxs := app.Allocate()
-	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := AppAllocation(app)
+	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
 
 	fmt.Println(">> setup")
 	pk, vk, vkHash, err := Setup(ccs, srsDir, maxReceipts, maxStorage, dataPoints, hashInfo)
@@ -191,8 +191,8 @@ func ReadSetupFromWithHashInfo(app AppCircuit, compileOutDir string, hashInfo *B
 		return nil, nil, nil, nil, err
 	}
 
-	maxReceipts, maxStorage, maxTxs := app.Allocate()
-	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := AppAllocation(app)
+	dataPoints := DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
 
 	vk, vkHash, err := ReadVkFrom(filepath.Join(compileOutDir, "vk"), maxReceipts, maxStorage, dataPoints, hashInfo)
 	return ccs, pk, vk, vkHash, err
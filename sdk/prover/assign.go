@@ -132,6 +132,8 @@ func convertType(expect, actual reflect.Value, name string) (reflect.Value, refl
 		actual = reflect.ValueOf(actual.Interface().(sdk.Uint32))
 	case sdk.Uint64Type:
 		actual = reflect.ValueOf(actual.Interface().(sdk.Uint64))
+	case sdk.Uint256Type:
+		actual = reflect.ValueOf(actual.Interface().(sdk.Uint256))
 	default:
 		return reflect.Value{}, reflect.Value{}, fmt.Errorf("mismatch types: json has %s but %s has %s", at, name, et)
 	}
@@ -184,6 +186,8 @@ func parseCircuitValue(value interface{}) (interface{}, error) {
 		return sdk.ConstUint32(data), nil
 	case sdk.Uint64Type:
 		return sdk.ConstUint64(data), nil
+	case sdk.Uint256Type:
+		return sdk.ConstUint256(data), nil
 	case sdk.Int248Type:
 		// json.Unmarshal automatically removes "" (quotes) around numbers. we need to check again here
 		str, ok := data.(string)
@@ -134,3 +134,27 @@ const testJson = `{
     ]
 }
 `
+
+func TestAssignCustomInputUint256(t *testing.T) {
+	customInput := &sdkproto.CustomInput{JsonBytes: `{
+	"u256Var": { "type": "Uint256", "data": "115792089237316195423570985008687907853269984665640564039457584007913129639935" },
+	"u256Arr": [
+		{ "type": "Uint256", "data": "1" },
+		{ "type": "Uint256", "data": "340282366920938463463374607431768211456" }
+	]
+}`}
+	assigned, err := assignCustomInput(&AppCircuitUint256{}, customInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := assigned.(*AppCircuitUint256)
+	assert.Equal(t, "115792089237316195423570985008687907853269984665640564039457584007913129639935", c.U256Var.String())
+	assert.Equal(t, big.NewInt(1), c.U256Arr[0].Val[0])
+	assert.Equal(t, big.NewInt(1), c.U256Arr[1].Val[1])
+}
+
+type AppCircuitUint256 struct {
+	dummyImpl
+	U256Var sdk.Uint256
+	U256Arr [2]sdk.Uint256
+}
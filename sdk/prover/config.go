@@ -1,6 +1,11 @@
 package prover
 
-import "os"
+import (
+	"os"
+	"time"
+
+	"github.com/brevis-network/brevis-sdk/sdk"
+)
 
 type ServiceConfig struct {
 	// ProverId is a unique identifier for this prover, defaults to hostname
@@ -39,6 +44,12 @@ type ServiceConfig struct {
 	// ConcurrentFetchLimit limits the number of concurrent on-chain fetches, defaults to 20
 	ConcurrentFetchLimit int `mapstructure:"concurrent_fetch_limit" json:"concurrent_fetch_limit"`
 
+	// FetchRateLimit limits the RPC requests of each source chain to
+	// FetchRateLimit per second in bursts of up to FetchBurst requests, see
+	// sdk.BrevisAppConfig. No limit if 0
+	FetchRateLimit float64 `mapstructure:"fetch_rate_limit" json:"fetch_rate_limit"`
+	FetchBurst     int     `mapstructure:"fetch_burst" json:"fetch_burst"`
+
 	// ConcurrentProveLimit limits the number of concurrent prove actions, defaults to 1
 	ConcurrentProveLimit int `mapstructure:"concurrent_prove_limit" json:"concurrent_prove_limit"`
 }
@@ -59,6 +70,20 @@ type SourceChainConfig struct {
 	ChainId uint64 `mapstructure:"chain_id" json:"chain_id"`
 	// RpcUrl will be used to query on-chain data by sending rpc call.
 	RpcUrl string `mapstructure:"rpc_url" json:"rpc_url"`
+	// RpcEndpoints are the failovers of RpcUrl, tried in order. RpcUrl can be
+	// left empty to use only these endpoints.
+	RpcEndpoints []sdk.RpcEndpoint `mapstructure:"rpc_endpoints" json:"rpc_endpoints"`
+	// RpcQuorum is the number of endpoints that must return the same receipt or
+	// storage value before it is used. Defaults to 1, i.e. no cross-checking
+	RpcQuorum int `mapstructure:"rpc_quorum" json:"rpc_quorum"`
+	// VerifyFetchedData verifies the data fetched from the RPC against the roots
+	// of their block headers before proving, see sdk.BrevisAppConfig
+	VerifyFetchedData bool `mapstructure:"verify_fetched_data" json:"verify_fetched_data"`
+	// CacheFinality decides when the cached data of the chain can no longer be
+	// reorged, see sdk.FinalityPolicy
+	CacheFinality sdk.FinalityPolicy `mapstructure:"cache_finality" json:"cache_finality"`
+	// RpcCallTimeout bounds each RPC call of the chain, see sdk.BrevisAppConfig
+	RpcCallTimeout time.Duration `mapstructure:"rpc_call_timeout" json:"rpc_call_timeout"`
 }
 
 type SourceChainConfigs []*SourceChainConfig
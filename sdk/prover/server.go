@@ -219,11 +219,18 @@ func newServer(appCircuit sdk.AppCircuit, config ServiceConfig, srcChainConfigs
 		appConfig := &sdk.BrevisAppConfig{
 			SrcChainId:           srcChainId,
 			RpcUrl:               srcChainConfig.RpcUrl,
+			RpcEndpoints:         srcChainConfig.RpcEndpoints,
+			RpcQuorum:            srcChainConfig.RpcQuorum,
+			VerifyFetchedData:    srcChainConfig.VerifyFetchedData,
+			CacheFinality:        srcChainConfig.CacheFinality,
+			RpcCallTimeout:       srcChainConfig.RpcCallTimeout,
 			GatewayUrl:           config.GatewayUrl,
 			OutDir:               config.SetupDir,
 			PersistenceType:      config.DataPersistenceType,
 			PersistenceOptions:   config.DataPersistenceOptions,
 			ConcurrentFetchLimit: config.ConcurrentFetchLimit,
+			FetchRateLimit:       config.FetchRateLimit,
+			FetchBurst:           config.FetchBurst,
 		}
 		template, err := sdk.NewBrevisAppWithConfig(appConfig)
 		if err != nil {
@@ -272,7 +279,7 @@ func (s *server) Prove(ctx context.Context, req *sdkproto.ProveRequest) (*sdkpro
 			log.Errorf("failed to close dataStore: %s", err.Error())
 		}
 	}()
-	input, guest, witnessStr, protoErr := s.buildInput(brevisApp, req)
+	input, guest, witnessStr, protoErr := s.buildInput(ctx, brevisApp, req)
 	if protoErr != nil {
 		return errRes(protoErr)
 	}
@@ -333,7 +340,7 @@ func (s *server) ProveAsync(ctx context.Context, req *sdkproto.ProveRequest) (*s
 		if err != nil {
 			return errRes(newErr(sdkproto.ErrCode_ERROR_DEFAULT, "failed to build circuit input stage 1: %s", err.Error()))
 		}
-		inputStage1, err := s.buildInputStage1(brevisApp, req)
+		inputStage1, err := s.buildInputStage1(ctx, brevisApp, req)
 		if err != nil {
 			return errRes(newErr(sdkproto.ErrCode_ERROR_DEFAULT, "failed to build circuit input stage 1: %s", err.Error()))
 		}
@@ -439,7 +446,7 @@ func (s *server) DeleteProof(ctx context.Context, req *sdkproto.DeleteProofReque
 	return &sdkproto.DeleteProofResponse{}, nil
 }
 
-func (s *server) buildInputStage1(brevisApp *sdk.BrevisApp, req *sdkproto.ProveRequest) (input *sdk.CircuitInput, err error) {
+func (s *server) buildInputStage1(ctx context.Context, brevisApp *sdk.BrevisApp, req *sdkproto.ProveRequest) (input *sdk.CircuitInput, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("panic, recovered value: %v", r)
@@ -468,20 +475,20 @@ func (s *server) buildInputStage1(brevisApp *sdk.BrevisApp, req *sdkproto.ProveR
 		}
 		brevisApp.AddTransaction(sdkTx, int(transaction.Index))
 	}
-	inputStage1, err := brevisApp.BuildCircuitInputStage1(s.appCircuit)
+	inputStage1, err := brevisApp.BuildCircuitInputStage1Ctx(ctx, s.appCircuit)
 	if err != nil {
 		return nil, fmt.Errorf("BuildCircuitInputStage1 err: %w", err)
 	}
 	return &inputStage1, nil
 }
 
-func (s *server) buildInputStage2(brevisApp *sdk.BrevisApp, req *sdkproto.ProveRequest, inputStage1 *sdk.CircuitInput) (*sdk.CircuitInput, sdk.AppCircuit, string, error) {
+func (s *server) buildInputStage2(ctx context.Context, brevisApp *sdk.BrevisApp, req *sdkproto.ProveRequest, inputStage1 *sdk.CircuitInput) (*sdk.CircuitInput, sdk.AppCircuit, string, error) {
 	guest, err := assignCustomInput(s.appCircuit, req.CustomInput)
 	if err != nil {
 		return nil, nil, "", fmt.Errorf("assignCustomInput err: %w", err)
 	}
 
-	input, err := brevisApp.BuildCircuitInputStage2(guest, *inputStage1)
+	input, err := brevisApp.BuildCircuitInputStage2Ctx(ctx, guest, *inputStage1)
 	if err != nil {
 		return nil, nil, "", fmt.Errorf("BuildCircuitInputStage2 err: %w", err)
 	}
@@ -502,18 +509,18 @@ func (s *server) buildInputStage2(brevisApp *sdk.BrevisApp, req *sdkproto.ProveR
 	return &input, guest, witness, nil
 }
 
-func (s *server) buildInput(brevisApp *sdk.BrevisApp, req *sdkproto.ProveRequest) (*sdk.CircuitInput, sdk.AppCircuit, string, *sdkproto.Err) {
+func (s *server) buildInput(ctx context.Context, brevisApp *sdk.BrevisApp, req *sdkproto.ProveRequest) (*sdk.CircuitInput, sdk.AppCircuit, string, *sdkproto.Err) {
 	makeErr := func(code sdkproto.ErrCode, format string, args ...any) (*sdk.CircuitInput, sdk.AppCircuit, string, *sdkproto.Err) {
 		log.Errorf(format, args...)
 		log.Errorln()
 		return nil, nil, "", newErr(code, format, args...)
 	}
 
-	inputStage1, err := s.buildInputStage1(brevisApp, req)
+	inputStage1, err := s.buildInputStage1(ctx, brevisApp, req)
 	if err != nil {
 		return makeErr(sdkproto.ErrCode_ERROR_INVALID_INPUT, "buildInputStage1 err: %s", err.Error())
 	}
-	input, appCircuit, witness, err := s.buildInputStage2(brevisApp, req, inputStage1)
+	input, appCircuit, witness, err := s.buildInputStage2(ctx, brevisApp, req, inputStage1)
 	if err != nil {
 		return makeErr(sdkproto.ErrCode_ERROR_INVALID_INPUT, "buildInputStage2 err: %s", err.Error())
 	}
@@ -551,7 +558,7 @@ func (s *server) buildInputAndProve(proofId string, proveRequest *ProveRequest,
 		s.markProofFailed(proofId, proveRequest, err)
 		return
 	}
-	inputStage1, err := s.buildInputStage1(brevisApp, requestProto)
+	inputStage1, err := s.buildInputStage1(context.Background(), brevisApp, requestProto)
 	if err != nil {
 		s.markProofFailed(proofId, proveRequest, err)
 		return
@@ -567,7 +574,8 @@ func (s *server) buildInputStage2AndProve(proofId string, brevisApp *sdk.BrevisA
 		}
 	}()
 
-	input, guest, witnessStr, err := s.buildInputStage2(brevisApp, requestProto, inputStage1)
+	// the job outlives the request that started it
+	input, guest, witnessStr, err := s.buildInputStage2(context.Background(), brevisApp, requestProto, inputStage1)
 	if err != nil {
 		s.markProofFailed(proofId, proveRequest, err)
 		return
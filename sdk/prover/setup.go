@@ -37,8 +37,8 @@ func readOnly(circuit sdk.AppCircuit, setupDir string, hashInfo *sdk.BrevisHashI
 	})
 	errG.Go(func() error {
 		log.Debugln(">> load vk pk")
-		maxReceipts, maxStorage, maxTxs := circuit.Allocate()
-		dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+		maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := sdk.AppAllocation(circuit)
+		dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
 		pk, vk, vkHash, err = readSetup(filepath.Join(setupDir, "pk"), filepath.Join(setupDir, "vk"), maxReceipts, maxStorage, dataPoints, hashInfo)
 		if err != nil {
 			return fmt.Errorf("fail to find pk vk, err: %w", err)
@@ -75,8 +75,8 @@ func readOrSetup(circuit sdk.AppCircuit, setupDir, srsDir string, hashInfo *sdk.
 	ccsDigest := crypto.Keccak256(ccsBytes.Bytes())
 	log.Debugf("circuit digest 0x%x", ccsDigest)
 
-	maxReceipts, maxStorage, maxTxs := circuit.Allocate()
-	dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := sdk.AppAllocation(circuit)
+	dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
 
 	pkFilepath := filepath.Join(setupDir, fmt.Sprintf("0x%x", ccsDigest), "pk")
 	vkFilepath := filepath.Join(setupDir, fmt.Sprintf("0x%x", ccsDigest), "vk")
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/celer-network/goutils/log"
 	"math/big"
+	"strings"
 
 	"github.com/brevis-network/brevis-sdk/sdk"
 	"github.com/brevis-network/brevis-sdk/sdk/proto/commonproto"
@@ -46,8 +47,8 @@ func buildFullAppCircuitInfo(app sdk.AppCircuit, in sdk.CircuitInput, vk, vkHash
 		toggles[i] = fmt.Sprintf("%x", value) == "1"
 	}
 
-	maxReceipts, maxStorage, maxTxs := app.Allocate()
-	dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := sdk.AppAllocation(app)
+	dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
 
 	return &commonproto.AppCircuitInfo{
 		OutputCommitment:     hexutil.Encode(in.OutputCommitment.Hash().Bytes()),
@@ -72,8 +73,8 @@ func buildPartialAppCircuitInfoForGatewayRequest(app sdk.AppCircuit, in *sdk.Cir
 		toggles[i] = fmt.Sprintf("%x", value) == "1"
 	}
 
-	maxReceipts, maxStorage, maxTxs := app.Allocate()
-	dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs)
+	maxReceipts, maxStorage, maxTxs, maxAccounts, maxHeaders := sdk.AppAllocation(app)
+	dataPoints := sdk.DataPointsNextPowerOf2(maxReceipts + maxStorage + maxTxs + maxAccounts + maxHeaders)
 
 	return &commonproto.AppCircuitInfo{
 		Toggles:          toggles,
@@ -148,7 +149,17 @@ func convertProtoReceiptToSdkReceipt(in *sdkproto.ReceiptData) (sdk.ReceiptData,
 	}, nil
 }
 
+// convertProtoFieldToSdkLogField converts a proto field. If the event id is a
+// field reference "<event signature>.<argument name>" instead of a hex event id,
+// is_topic and field_index are resolved from the event's ABI and ignored.
 func convertProtoFieldToSdkLogField(in *sdkproto.Field) (sdk.LogFieldData, error) {
+	if strings.Contains(in.EventId, "(") {
+		field, err := sdk.LogFieldByRef(uint(in.LogPos), in.EventId)
+		if err != nil {
+			return sdk.LogFieldData{}, fmt.Errorf("invalid field of log %d: %w", in.LogPos, err)
+		}
+		return field, nil
+	}
 	return sdk.LogFieldData{
 		LogPos:     uint(in.LogPos),
 		IsTopic:    in.IsTopic,
@@ -0,0 +1,32 @@
+package prover
+
+import (
+	"testing"
+
+	"github.com/brevis-network/brevis-sdk/sdk/proto/sdkproto"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertProtoFieldToSdkLogField(t *testing.T) {
+	field, err := convertProtoFieldToSdkLogField(&sdkproto.Field{LogPos: 2, IsTopic: true, FieldIndex: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), field.LogPos)
+	assert.True(t, field.IsTopic)
+	assert.Equal(t, uint(1), field.FieldIndex)
+
+	// is_topic and field_index are resolved from the field reference
+	field, err = convertProtoFieldToSdkLogField(&sdkproto.Field{
+		LogPos:  2,
+		EventId: "Transfer(address indexed from, address indexed to, uint256 value).value",
+		IsTopic: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), field.LogPos)
+	assert.False(t, field.IsTopic)
+	assert.Equal(t, uint(0), field.FieldIndex)
+	assert.Equal(t, crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")), field.EventID)
+
+	_, err = convertProtoFieldToSdkLogField(&sdkproto.Field{EventId: "Transfer(bytes data).data"})
+	assert.Error(t, err)
+}
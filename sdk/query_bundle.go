@@ -0,0 +1,263 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/brevis-network/brevis-sdk/sdk/proto/gwproto"
+	"github.com/brevis-network/brevis-sdk/store"
+)
+
+// QueryBundleVersion is the version of the bundles exported by
+// ExportQueryBundle. Bundles of other versions can't be imported.
+const QueryBundleVersion = 1
+
+// QueryBundle is a self-contained record of the queries of a BrevisApp and
+// everything else BuildCircuitInput used, so that the identical CircuitInput
+// can be rebuilt offline, e.g. to reproduce a failed proof. See
+// ExportQueryBundle and NewBrevisAppFromQueryBundle.
+type QueryBundle struct {
+	Version    int    `json:"version"`
+	SrcChainId uint64 `json:"src_chain_id"`
+	// Allocation is the allocation of the AppCircuit the input was built with
+	Allocation QueryBundleAllocation `json:"allocation"`
+	// CustomInput is the custom input of the AppCircuit as given to
+	// ExportQueryBundle, kept as is
+	CustomInput json.RawMessage `json:"custom_input,omitempty"`
+	// Mock is whether the queries are mock data, see AddMockReceipt
+	Mock bool `json:"mock,omitempty"`
+
+	// The queries with all their fields resolved
+	Receipts     []QueryBundleEntry[ReceiptData]     `json:"receipts,omitempty"`
+	Storage      []QueryBundleEntry[StorageData]     `json:"storage,omitempty"`
+	Transactions []QueryBundleEntry[TransactionData] `json:"transactions,omitempty"`
+	Accounts     []QueryBundleEntry[AccountData]     `json:"accounts,omitempty"`
+	BlockHeaders []QueryBundleEntry[BlockHeaderData] `json:"block_headers,omitempty"`
+
+	// DummyInput is the dummy input commitments given by the gateway
+	DummyInput QueryBundleDummyInput `json:"dummy_input"`
+	// Digests are the circuit digests of the app
+	Digests *BrevisHashInfo `json:"digests,omitempty"`
+}
+
+type QueryBundleAllocation struct {
+	MaxReceipts int `json:"max_receipts"`
+	MaxStorage  int `json:"max_storage"`
+	MaxTxs      int `json:"max_txs"`
+	MaxAccounts int `json:"max_accounts"`
+	MaxHeaders  int `json:"max_headers"`
+	DataPoints  int `json:"data_points"`
+}
+
+// QueryBundleEntry is a query and the index it's pinned at, if any
+type QueryBundleEntry[T queryData] struct {
+	Index *int `json:"index,omitempty"`
+	Data  T    `json:"data"`
+}
+
+type QueryBundleDummyInput struct {
+	Receipt string `json:"receipt"`
+	Storage string `json:"storage"`
+	Tx      string `json:"tx"`
+}
+
+// ExportQueryBundle exports the queries of the app with the allocation, the
+// dummy input commitments and the digests of the last BuildCircuitInput.
+// customInput is the custom input of the AppCircuit in a form the caller can
+// assign again, e.g. the custom input JSON of the prover service.
+func (q *BrevisApp) ExportQueryBundle(customInput json.RawMessage) (*QueryBundle, error) {
+	return q.ExportQueryBundleCtx(context.Background(), customInput)
+}
+
+// ExportQueryBundleCtx is ExportQueryBundle with a context that bounds the
+// queries resolved again. They are usually resolved from the dataStore.
+func (q *BrevisApp) ExportQueryBundleCtx(ctx context.Context, customInput json.RawMessage) (*QueryBundle, error) {
+	if !q.buildInputCalled || q.dummyInput == nil {
+		return nil, fmt.Errorf("BuildCircuitInput must be called before exporting the queries")
+	}
+	b := &QueryBundle{
+		Version:    QueryBundleVersion,
+		SrcChainId: q.srcChainId,
+		Allocation: QueryBundleAllocation{
+			MaxReceipts: q.maxReceipts,
+			MaxStorage:  q.maxStorage,
+			MaxTxs:      q.maxTxs,
+			MaxAccounts: q.maxAccounts,
+			MaxHeaders:  q.maxHeaders,
+			DataPoints:  q.dataPoints,
+		},
+		CustomInput: customInput,
+		DummyInput: QueryBundleDummyInput{
+			Receipt: q.dummyInput.Receipt,
+			Storage: q.dummyInput.Storage,
+			Tx:      q.dummyInput.Tx,
+		},
+		Digests: q.BrevisHashInfo,
+	}
+	var err error
+	if q.mockDataLength() > 0 {
+		// mock data is used as is except for the txs
+		b.Mock = true
+		b.Receipts = bundleEntries(q.mockReceipts)
+		b.Storage = bundleEntries(q.mockStorage)
+		b.Accounts = bundleEntries(q.mockAccounts)
+		b.BlockHeaders = bundleEntries(q.mockHeaders)
+	} else {
+		if b.Receipts, err = resolveBundleEntries(ctx, q.receipts, q.resolveReceipt); err != nil {
+			return nil, err
+		}
+		if b.Storage, err = resolveBundleEntries(ctx, q.storageVals, q.resolveStorageSlot); err != nil {
+			return nil, err
+		}
+		if b.Accounts, err = resolveBundleEntries(ctx, q.accounts, q.resolveAccount); err != nil {
+			return nil, err
+		}
+		if b.BlockHeaders, err = resolveBundleEntries(ctx, q.headers, q.resolveBlockHeader); err != nil {
+			return nil, err
+		}
+	}
+	if b.Transactions, err = resolveBundleEntries(ctx, q.txs, q.resolveTx); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// bundleEntries returns the pinned data in the order of their indices, then
+// the ordered data
+func bundleEntries[T queryData](data rawData[T]) []QueryBundleEntry[T] {
+	var entries []QueryBundleEntry[T]
+	indices := make([]int, 0, len(data.special))
+	for index := range data.special {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	for _, index := range indices {
+		entries = append(entries, QueryBundleEntry[T]{Index: &index, Data: data.special[index]})
+	}
+	for _, d := range data.ordered {
+		entries = append(entries, QueryBundleEntry[T]{Data: d})
+	}
+	return entries
+}
+
+func resolveBundleEntries[T queryData](
+	ctx context.Context, data rawData[T], resolve func(context.Context, T) (T, error)) ([]QueryBundleEntry[T], error) {
+	entries := bundleEntries(data)
+	for i, e := range entries {
+		d, err := resolve(ctx, e.Data)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve query %d of the bundle: %w", i, err)
+		}
+		entries[i].Data = d
+	}
+	return entries, nil
+}
+
+// WriteQueryBundle writes the bundle as JSON
+func WriteQueryBundle(w io.Writer, b *QueryBundle) error {
+	return json.NewEncoder(w).Encode(b)
+}
+
+// ReadQueryBundle reads a bundle written by WriteQueryBundle
+func ReadQueryBundle(r io.Reader) (*QueryBundle, error) {
+	var b QueryBundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("cannot decode query bundle: %w", err)
+	}
+	if b.Version != QueryBundleVersion {
+		return nil, fmt.Errorf("unsupported query bundle version %d, expected %d", b.Version, QueryBundleVersion)
+	}
+	return &b, nil
+}
+
+// NewBrevisAppFromQueryBundle creates an offline BrevisApp with the queries of
+// the bundle. Its BuildCircuitInput, given the AppCircuit of the bundle
+// assigned with the custom input of the bundle, rebuilds the identical
+// CircuitInput without any RPC or gateway call. The app can't send requests.
+func NewBrevisAppFromQueryBundle(b *QueryBundle) (*BrevisApp, error) {
+	if b.Version != QueryBundleVersion {
+		return nil, fmt.Errorf("unsupported query bundle version %d, expected %d", b.Version, QueryBundleVersion)
+	}
+	dataStore, err := store.InitStore("syncmap", "")
+	if err != nil {
+		return nil, fmt.Errorf("InitStore err: %w", err)
+	}
+	allocation := b.Allocation
+	q := &BrevisApp{
+		srcChainId:           b.SrcChainId,
+		receipts:             rawData[ReceiptData]{},
+		storageVals:          rawData[StorageData]{},
+		txs:                  rawData[TransactionData]{},
+		accounts:             rawData[AccountData]{},
+		headers:              rawData[BlockHeaderData]{},
+		concurrentFetchLimit: defaultConcurrentFetchLimit,
+		dataStore:            dataStore,
+		BrevisHashInfo:       b.Digests,
+		dummyInput: &gwproto.CircuitDummyInputResponse{
+			Receipt: b.DummyInput.Receipt,
+			Storage: b.DummyInput.Storage,
+			Tx:      b.DummyInput.Tx,
+		},
+		bundleAllocation: &allocation,
+		// the bundle could only be exported with the accounts and block headers
+		// allowed
+		experimentalAccountsAndHeaders: allocation.MaxAccounts > 0 || allocation.MaxHeaders > 0,
+	}
+	for i, e := range b.Transactions {
+		if !e.Data.isReadyToSave() {
+			return nil, fmt.Errorf("transaction %d of the bundle is not resolved", i)
+		}
+		addBundleEntry(&q.txs, e)
+	}
+	if b.Mock {
+		for _, e := range b.Receipts {
+			addBundleEntry(&q.mockReceipts, e)
+		}
+		for _, e := range b.Storage {
+			addBundleEntry(&q.mockStorage, e)
+		}
+		for _, e := range b.Accounts {
+			addBundleEntry(&q.mockAccounts, e)
+		}
+		for _, e := range b.BlockHeaders {
+			addBundleEntry(&q.mockHeaders, e)
+		}
+		return q, nil
+	}
+	for i, e := range b.Receipts {
+		if !e.Data.isReadyToSave() {
+			return nil, fmt.Errorf("receipt %d of the bundle is not resolved", i)
+		}
+		addBundleEntry(&q.receipts, e)
+	}
+	for i, e := range b.Storage {
+		if !e.Data.isReadyToSave() {
+			return nil, fmt.Errorf("storage %d of the bundle is not resolved", i)
+		}
+		addBundleEntry(&q.storageVals, e)
+	}
+	for i, e := range b.Accounts {
+		if !e.Data.isReadyToSave() {
+			return nil, fmt.Errorf("account %d of the bundle is not resolved", i)
+		}
+		addBundleEntry(&q.accounts, e)
+	}
+	for i, e := range b.BlockHeaders {
+		if !e.Data.isReadyToSave() {
+			return nil, fmt.Errorf("block header %d of the bundle is not resolved", i)
+		}
+		addBundleEntry(&q.headers, e)
+	}
+	return q, nil
+}
+
+func addBundleEntry[T queryData](data *rawData[T], e QueryBundleEntry[T]) {
+	if e.Index != nil {
+		data.add(e.Data, *e.Index)
+	} else {
+		data.add(e.Data)
+	}
+}
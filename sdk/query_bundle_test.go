@@ -0,0 +1,57 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brevis-network/brevis-sdk/sdk/proto/gwproto"
+)
+
+func TestQueryBundle(t *testing.T) {
+	c := newTestChain()
+	q, rpc := newTestFetchApp(t, c, true)
+	// given by the gateway otherwise
+	q.dummyInput = &gwproto.CircuitDummyInputResponse{Receipt: "0x01", Storage: "0x02", Tx: "0x03"}
+	app := testAllocation{receipts: 32, storage: 32, txs: 32}
+	in, err := q.BuildCircuitInput(app)
+	check(err)
+
+	customInput := json.RawMessage(`{"fields":[]}`)
+	bundle, err := q.ExportQueryBundle(customInput)
+	check(err)
+	var buf bytes.Buffer
+	check(WriteQueryBundle(&buf, bundle))
+	requests := rpc.httpRequests()
+
+	imported, err := ReadQueryBundle(&buf)
+	check(err)
+	if !bytes.Equal(imported.CustomInput, customInput) || len(imported.Receipts) != 3 || len(imported.Storage) != 3 {
+		t.Fatalf("unexpected imported bundle %+v", imported)
+	}
+	offline, err := NewBrevisAppFromQueryBundle(imported)
+	check(err)
+	rebuilt, err := offline.BuildCircuitInput(app)
+	check(err)
+	if !reflect.DeepEqual(in, rebuilt) {
+		t.Error("expected the rebuilt circuit input to be identical")
+	}
+	if n := rpc.httpRequests(); n != requests {
+		t.Errorf("expected no requests to rebuild the circuit input, got %d", n-requests)
+	}
+
+	offline, err = NewBrevisAppFromQueryBundle(imported)
+	check(err)
+	if _, err = offline.BuildCircuitInput(testAllocation{receipts: 64, storage: 32, txs: 32}); err == nil {
+		t.Error("expected an allocation other than the bundle's to be rejected")
+	}
+	imported.Receipts[0].Data.BlockTimestamp = 0
+	if _, err = NewBrevisAppFromQueryBundle(imported); err == nil {
+		t.Error("expected an unresolved receipt to be rejected")
+	}
+	if _, err = ReadQueryBundle(strings.NewReader(`{"version":2}`)); err == nil {
+		t.Error("expected an unsupported version to be rejected")
+	}
+}
@@ -0,0 +1,283 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/celer-network/goutils/log"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// bounds the probing of an endpoint when it's dialed
+	probeTimeout = 30 * time.Second
+	// the endpoints dialed again within probeTTL, e.g. by the apps created for
+	// each request of the prover service, are not probed again
+	probeTTL = 10 * time.Minute
+)
+
+// the capabilities probed by chain id and url
+var probedCaps sync.Map
+
+type probedCapsEntry struct {
+	caps *RpcCapabilities
+	at   time.Time
+}
+
+// RpcCapabilities are the capabilities of an RPC endpoint, probed when the
+// endpoint is dialed
+type RpcCapabilities struct {
+	Url string
+	// Archive is whether the endpoint serves the state of all blocks
+	Archive bool
+	// OldestStateBlock is the oldest block whose state the endpoint served
+	// when probed. 0 if the endpoint is an archive node or if unknown, e.g. if
+	// a probe failed for another reason than the state being missing. It moves
+	// forward as a pruning node prunes its state
+	OldestStateBlock uint64
+	// GetProof is whether eth_getProof is supported, needed by
+	// BrevisAppConfig.VerifyFetchedData
+	GetProof bool
+	// Debug is whether the debug_* namespace is enabled
+	Debug bool
+	// Batch is whether batch requests are supported
+	Batch bool
+	// BlockReceipts is whether eth_getBlockReceipts is supported
+	BlockReceipts bool
+}
+
+// ErrArchiveNodeRequired is wrapped by the errors of queries of a state that
+// the RPC endpoints no longer keep, see ArchiveNodeError
+var ErrArchiveNodeRequired = errors.New("archive node required")
+
+// ArchiveNodeError is returned by the storage and account queries of a block
+// whose state the RPC endpoints have pruned. The query needs an endpoint of an
+// archive node.
+type ArchiveNodeError struct {
+	BlockNum uint64
+	// OldestStateBlock is the oldest block whose state the endpoints served
+	// when probed, 0 if unknown
+	OldestStateBlock uint64
+	// Err is the error of the RPC
+	Err error
+}
+
+func (e *ArchiveNodeError) Error() string {
+	msg := fmt.Sprintf("state of block %d is not available from the rpc endpoints, the query needs an archive node", e.BlockNum)
+	if e.OldestStateBlock > 0 {
+		msg += fmt.Sprintf(" (the oldest state served is of block %d)", e.OldestStateBlock)
+	}
+	return fmt.Sprintf("%s: %s", msg, e.Err)
+}
+
+func (e *ArchiveNodeError) Unwrap() []error {
+	return []error{ErrArchiveNodeRequired, e.Err}
+}
+
+// the errors of the clients for a pruned state
+var missingStateErrs = []string{
+	"missing trie node",
+	"historical state",
+	"state is not available",
+	"state not available",
+	"no state available",
+	"old data not available",
+	"pruned",
+}
+
+// the errors of the clients and providers for the limits of the endpoint, e.g.
+// of the request rate or of the execution time
+var limitErrs = []string{
+	"limit",
+	"timeout",
+	"timed out",
+	"too many",
+	"capacity",
+}
+
+// the EIP-1474 error code of an exceeded limit
+const limitExceededErrCode = -32005
+
+// isMissingStateErr returns whether err says that the state is missing
+func isMissingStateErr(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range missingStateErrs {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isQueryErr returns whether err is an error response of the node to the query
+// itself, as opposed to transport errors and errors of the limits of the
+// endpoint
+func isQueryErr(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() == limitExceededErrCode {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, s := range limitErrs {
+		if strings.Contains(msg, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// archiveNodeError returns an ArchiveNodeError if err of a state query at
+// block blkNum is caused by the endpoints not keeping the state of the block,
+// or nil otherwise. That is, if err says that the state is missing, or if the
+// block is older than the state the endpoints served when probed and err is an
+// opaque error of the node to the query.
+func (f *chainFetcher) archiveNodeError(blkNum *big.Int, err error) error {
+	// the oldest state served by the endpoints, 0 unless all of them are
+	// probed pruning nodes
+	var oldest uint64
+	for _, e := range f.endpoints {
+		if e.caps == nil || e.caps.OldestStateBlock == 0 {
+			oldest = 0
+			break
+		}
+		if oldest == 0 || e.caps.OldestStateBlock < oldest {
+			oldest = e.caps.OldestStateBlock
+		}
+	}
+	pruned := isMissingStateErr(err) || (oldest > 0 && blkNum.Uint64() < oldest && isQueryErr(err))
+	if !pruned {
+		return nil
+	}
+	return &ArchiveNodeError{BlockNum: blkNum.Uint64(), OldestStateBlock: oldest, Err: err}
+}
+
+// capabilities returns the capabilities of the endpoint, probed unless the
+// endpoint of the same url was probed for the chain within probeTTL
+func (e *rpcEndpoint) capabilities(chainId uint64, s *fetchScheduler) *RpcCapabilities {
+	key := fmt.Sprintf("%d-%s", chainId, e.url)
+	if v, ok := probedCaps.Load(key); ok && time.Since(v.(probedCapsEntry).at) < probeTTL {
+		return v.(probedCapsEntry).caps
+	}
+	caps := e.probe(s)
+	if caps != nil {
+		probedCaps.Store(key, probedCapsEntry{caps: caps, at: time.Now()})
+	}
+	return caps
+}
+
+// probe probes the capabilities of the endpoint with the requests scheduled by
+// s. A capability that can't be probed is reported as unsupported. It returns
+// nil if the endpoint can't be probed at all.
+func (e *rpcEndpoint) probe(s *fetchScheduler) *RpcCapabilities {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	var latest hexutil.Uint64
+	if err := e.probeCall(ctx, s, &latest, "eth_blockNumber"); err != nil {
+		log.Warnf("cannot probe rpc %s: %s", e.url, err)
+		return nil
+	}
+	caps := &RpcCapabilities{Url: e.url}
+	block := hexutil.EncodeUint64(uint64(latest))
+
+	elems := []rpc.BatchElem{
+		{Method: "eth_blockNumber", Result: new(hexutil.Uint64)},
+		{Method: "eth_chainId", Result: new(hexutil.Big)},
+	}
+	err := s.do(ctx, e, func(ctx context.Context) error {
+		return e.client.BatchCallContext(ctx, elems)
+	})
+	caps.Batch = err == nil && elems[0].Error == nil && elems[1].Error == nil
+	caps.BlockReceipts = e.supports(ctx, s, "eth_getBlockReceipts", block)
+	caps.GetProof = e.supports(ctx, s, "eth_getProof", common.Address{}, []common.Hash{}, block)
+	caps.Debug = e.supports(ctx, s, "debug_getRawHeader", block)
+	caps.Archive, caps.OldestStateBlock = e.probeState(ctx, s, uint64(latest))
+
+	log.Infof("rpc %s: archive %t, oldest state block %d, eth_getProof %t, debug %t, batch %t, eth_getBlockReceipts %t",
+		e.url, caps.Archive, caps.OldestStateBlock, caps.GetProof, caps.Debug, caps.Batch, caps.BlockReceipts)
+	return caps
+}
+
+func (e *rpcEndpoint) probeCall(ctx context.Context, s *fetchScheduler, result interface{}, method string, args ...interface{}) error {
+	return s.do(ctx, e, func(ctx context.Context) error {
+		return e.client.CallContext(ctx, result, method, args...)
+	})
+}
+
+// supports returns whether the method is served. Errors other than the method
+// not being found, e.g. of the arguments, mean it's served.
+func (e *rpcEndpoint) supports(ctx context.Context, s *fetchScheduler, method string, args ...interface{}) bool {
+	var raw json.RawMessage
+	err := e.probeCall(ctx, s, &raw, method, args...)
+	if err == nil {
+		return true
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	return rpcErr.ErrorCode() != -32601 && !strings.Contains(msg, "not found") &&
+		!strings.Contains(msg, "not supported") && !strings.Contains(msg, "does not exist")
+}
+
+// probeState returns whether the endpoint serves the state of block 1, and if
+// not, the oldest block whose state it serves by a binary search. The oldest
+// block is 0 if unknown. Only the errors saying that the state is missing mean
+// that a block has no state, the search gives up on any other error.
+func (e *rpcEndpoint) probeState(ctx context.Context, s *fetchScheduler, latest uint64) (archive bool, oldest uint64) {
+	hasState := func(num uint64) (bool, error) {
+		var balance hexutil.Big
+		err := e.probeCall(ctx, s, &balance, "eth_getBalance", common.Address{}, hexutil.EncodeUint64(num))
+		if err != nil && isMissingStateErr(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if latest <= 1 {
+		return true, 0
+	}
+	ok, err := hasState(1)
+	if err != nil {
+		return false, 0
+	}
+	if ok {
+		return true, 0
+	}
+	if ok, err = hasState(latest); err != nil || !ok {
+		return false, 0
+	}
+	// block lo has no state, block hi has
+	lo, hi := uint64(1), latest
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if ok, err = hasState(mid); err != nil {
+			return false, 0
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return false, hi
+}
+
+// RpcCapabilities returns the capabilities of the app's RPC endpoints probed
+// when they were dialed
+func (q *BrevisApp) RpcCapabilities() []RpcCapabilities {
+	var caps []RpcCapabilities
+	for _, e := range q.rpcEndpoints {
+		if e.caps != nil {
+			caps = append(caps, *e.caps)
+		}
+	}
+	return caps
+}
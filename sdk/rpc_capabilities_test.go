@@ -0,0 +1,150 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// prunedHandlers serve the state of the blocks from oldest to latest only
+func prunedHandlers(c *testChain, oldest, latest uint64) map[string]func(params []json.RawMessage) (interface{}, error) {
+	handlers := c.handlers(false)
+	handlers["eth_chainId"] = func(params []json.RawMessage) (interface{}, error) {
+		return "0x1", nil
+	}
+	handlers["eth_blockNumber"] = func(params []json.RawMessage) (interface{}, error) {
+		return hexutil.EncodeUint64(latest), nil
+	}
+	handlers["eth_getBalance"] = func(params []json.RawMessage) (interface{}, error) {
+		var num hexutil.Uint64
+		check(json.Unmarshal(params[1], &num))
+		if uint64(num) < oldest {
+			return nil, fmt.Errorf("missing trie node")
+		}
+		return "0x0", nil
+	}
+	getStorage := handlers["eth_getStorageAt"]
+	handlers["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		var num hexutil.Uint64
+		check(json.Unmarshal(params[2], &num))
+		if uint64(num) < oldest {
+			// an opaque error of some client
+			return nil, fmt.Errorf("internal error")
+		}
+		return getStorage(params)
+	}
+	return handlers
+}
+
+func TestProbeRpcCapabilities(t *testing.T) {
+	c := newTestChain()
+	rpc := newFakeRPC(t, prunedHandlers(c, 90, 101))
+	endpoints, err := dialRPCEndpoints(1, []RpcEndpoint{{Url: rpc.URL}}, nil)
+	check(err)
+	q := &BrevisApp{ec: endpoints[0].ec, rpcEndpoints: endpoints, concurrentFetchLimit: 4, srcChainId: 1}
+	caps := q.RpcCapabilities()
+	expected := RpcCapabilities{Url: rpc.URL, OldestStateBlock: 90, GetProof: true, Batch: true}
+	if len(caps) != 1 || caps[0] != expected {
+		t.Errorf("expected capabilities %+v, got %+v", expected, caps)
+	}
+	if !q.chain().blockReceiptsUnsupported {
+		t.Error("expected eth_getBlockReceipts to be known unsupported")
+	}
+
+	// the opaque error of a pruned block is explained by the probed state
+	_, err = q.getStorageValue(context.Background(), big.NewInt(50), testAccount, common.Hash{})
+	var archiveErr *ArchiveNodeError
+	if !errors.As(err, &archiveErr) || archiveErr.BlockNum != 50 || archiveErr.OldestStateBlock != 90 {
+		t.Errorf("expected an archive node error of block 50, got %v", err)
+	}
+	if _, err = q.getStorageValue(context.Background(), big.NewInt(100), testAccount, common.Hash{}); err != nil {
+		t.Errorf("expected the state of block 100 to be served, got %v", err)
+	}
+
+	// the endpoint is not probed again when dialed again
+	probes := rpc.count("eth_getBalance")
+	endpoints, err = dialRPCEndpoints(1, []RpcEndpoint{{Url: rpc.URL}}, nil)
+	check(err)
+	if n := rpc.count("eth_getBalance"); n != probes || endpoints[0].caps == nil || *endpoints[0].caps != expected {
+		t.Errorf("expected the probed capabilities to be reused, %d probes sent", n-probes)
+	}
+}
+
+func TestProbeRpcCapabilitiesThrottled(t *testing.T) {
+	c := newTestChain()
+	handlers := prunedHandlers(c, 90, 101)
+	getBalance := handlers["eth_getBalance"]
+	probes := 0
+	handlers["eth_getBalance"] = func(params []json.RawMessage) (interface{}, error) {
+		// throttled in the middle of the search
+		if probes++; probes == 4 {
+			return nil, fmt.Errorf("request rate limit exceeded")
+		}
+		return getBalance(params)
+	}
+	rpc := newFakeRPC(t, handlers)
+	endpoints, err := dialRPCEndpoints(1, []RpcEndpoint{{Url: rpc.URL}}, nil)
+	check(err)
+	caps := endpoints[0].caps
+	if caps == nil || caps.Archive || caps.OldestStateBlock != 0 {
+		t.Errorf("expected the oldest state block to be unknown, got %+v", caps)
+	}
+	if n := rpc.count("eth_getBalance"); n != 4 {
+		t.Errorf("expected the search to give up after the throttled probe, %d probes sent", n)
+	}
+}
+
+func TestArchiveNodeError(t *testing.T) {
+	c := newTestChain()
+	handlers := c.handlers(true)
+	handlers["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("missing trie node 1a2b (path ) state 0x3c4d is not available")
+	}
+	q := newTestVerifyApp(t, handlers)
+	q.verifyFetchedData = false
+
+	// not probed, the error of the RPC tells
+	_, err := q.buildStorageSlot(context.Background(), StorageData{BlockNum: big.NewInt(100), Address: testAccount, Slot: common.Hash{}})
+	if !errors.Is(err, ErrArchiveNodeRequired) {
+		t.Errorf("expected an archive node error, got %v", err)
+	}
+
+	handlers["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("execution timeout")
+	}
+	_, err = q.buildStorageSlot(context.Background(), StorageData{BlockNum: big.NewInt(100), Address: testAccount, Slot: common.Hash{1}})
+	if err == nil || errors.Is(err, ErrArchiveNodeRequired) {
+		t.Errorf("expected an error unrelated to archive nodes, got %v", err)
+	}
+
+	// the errors of the limits of the endpoints are not blamed on the state of
+	// the blocks older than the probed oldest state
+	f := q.chain()
+	for _, e := range f.endpoints {
+		e.caps = &RpcCapabilities{Url: e.url, OldestStateBlock: 90}
+	}
+	if err = f.archiveNodeError(big.NewInt(50), &jsonError{Code: -32005, Message: "daily request count exceeded"}); err != nil {
+		t.Errorf("expected a limit error unrelated to archive nodes, got %v", err)
+	}
+	if err = f.archiveNodeError(big.NewInt(50), &jsonError{Code: -32000, Message: "execution timeout"}); err != nil {
+		t.Errorf("expected a timeout unrelated to archive nodes, got %v", err)
+	}
+	if err = f.archiveNodeError(big.NewInt(50), &jsonError{Code: -32000, Message: "internal error"}); !errors.Is(err, ErrArchiveNodeRequired) {
+		t.Errorf("expected an opaque error of a pruned block to be an archive node error, got %v", err)
+	}
+}
+
+// jsonError is a JSON-RPC error response
+type jsonError struct {
+	Code    int
+	Message string
+}
+
+func (e *jsonError) Error() string  { return e.Message }
+func (e *jsonError) ErrorCode() int { return e.Code }
@@ -0,0 +1,357 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/celer-network/goutils/log"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RpcEndpoint is a JSON-RPC endpoint of the source chain
+type RpcEndpoint struct {
+	Url string `mapstructure:"url" json:"url"`
+	// RateLimit is the maximum number of requests per second sent to the
+	// endpoint, a batch request counts as one. No limit if 0
+	RateLimit float64 `mapstructure:"rate_limit" json:"rate_limit"`
+}
+
+// rpcEndpoints returns the endpoints of a config, RpcUrl first if set
+func rpcEndpoints(rpcUrl string, endpoints []RpcEndpoint) []RpcEndpoint {
+	var l []RpcEndpoint
+	if rpcUrl != "" {
+		l = append(l, RpcEndpoint{Url: rpcUrl})
+	}
+	return append(l, endpoints...)
+}
+
+// the error of the fetches of an app without RPC, e.g. imported from a query
+// bundle
+var errNoRPCEndpoint = errors.New("no rpc endpoint")
+
+type rpcEndpoint struct {
+	url     string
+	client  *rpc.Client
+	ec      *ethclient.Client
+	limiter *rateLimiter
+	// nil if not probed, e.g. the endpoint of an ethclient given by the user
+	caps *RpcCapabilities
+
+	mu sync.Mutex
+	// the Retry-After of the endpoint's last 429 response, see fetchScheduler
+	pausedUntil time.Time
+}
+
+// dialRPCEndpoints dials the endpoints, checks that they serve the chain
+// srcChainId and probes their capabilities with the requests scheduled by
+// scheduler. An endpoint that can't be reached is kept as a failover, but at
+// least one endpoint must be reachable.
+func dialRPCEndpoints(srcChainId uint64, configs []RpcEndpoint, scheduler *fetchScheduler) ([]*rpcEndpoint, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no rpc endpoint configured")
+	}
+	var endpoints []*rpcEndpoint
+	reachable := false
+	for _, config := range configs {
+		e := &rpcEndpoint{url: config.Url, limiter: newRateLimiter(config.RateLimit, 1)}
+		httpClient := &http.Client{Transport: &retryAfterTransport{endpoint: e}}
+		client, err := rpc.DialOptions(context.Background(), config.Url, rpc.WithHTTPClient(httpClient))
+		if err != nil {
+			return nil, fmt.Errorf("rpc.Dial rpcUrl: %s err: %w", config.Url, err)
+		}
+		e.client, e.ec = client, ethclient.NewClient(client)
+		chainId, err := e.ec.ChainID(context.Background())
+		if err != nil {
+			log.Warnf("cannot get chain id of rpc %s, keeping it as a failover: %s", config.Url, err)
+		} else if chainId.Uint64() != srcChainId {
+			return nil, fmt.Errorf("invalid src chain id %d rpcUrl %s pair", srcChainId, config.Url)
+		} else {
+			reachable = true
+			e.caps = e.capabilities(srcChainId, scheduler)
+		}
+		endpoints = append(endpoints, e)
+	}
+	if !reachable {
+		return nil, fmt.Errorf("none of the %d rpc endpoints of chain %d is reachable", len(endpoints), srcChainId)
+	}
+	return endpoints, nil
+}
+
+// send sends the requests in one batch, or as a single call if there is only
+// one, not scheduled. Like in rpc.Client.BatchCallContext, the returned error is a transport
+// error and errors of the requests are set in the elems.
+func (e *rpcEndpoint) send(ctx context.Context, elems []rpc.BatchElem, timeout time.Duration) error {
+	ctx, cancel := withCallTimeout(ctx, timeout)
+	defer cancel()
+	if len(elems) == 1 {
+		err := e.client.CallContext(ctx, elems[0].Result, elems[0].Method, elems[0].Args...)
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			elems[0].Error = err
+			return nil
+		}
+		return err
+	}
+	return e.client.BatchCallContext(ctx, elems)
+}
+
+// digestFunc returns the digest of an RPC result that is compared across
+// endpoints in quorum reads
+type digestFunc func(raw json.RawMessage) (common.Hash, error)
+
+// batchCall sends the requests to the endpoints in order, each endpoint being
+// the failover of the previous ones: a request is sent to the next endpoint if
+// the previous one failed or returned null, which is what a lagging endpoint
+// returns for data it doesn't have yet.
+//
+// If digest is not nil and the fetcher has a quorum, a request is only done
+// once quorum endpoints returned results of the same digest. Otherwise the
+// first result is used.
+//
+// Like rpc.Client.BatchCallContext, it returns an error only if no endpoint
+// could be reached, errors of the requests are set in the elems.
+func (f *chainFetcher) batchCall(ctx context.Context, elems []rpc.BatchElem, batchSize int, digest digestFunc) error {
+	if len(elems) == 0 {
+		return nil
+	}
+	if len(f.endpoints) == 0 {
+		return errNoRPCEndpoint
+	}
+	quorum := 1
+	if digest != nil && f.quorum > 1 {
+		quorum = f.quorum
+	}
+	type vote struct {
+		raw   json.RawMessage
+		count int
+	}
+	votes := make([]map[common.Hash]*vote, len(elems))
+	results := make([]json.RawMessage, len(elems))
+	errs := make([]error, len(elems))
+	var lastErr error
+	reached := false
+	for _, e := range f.endpoints {
+		var pending []int
+		for i := range elems {
+			if results[i] == nil {
+				pending = append(pending, i)
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		reqs := make([]rpc.BatchElem, len(pending))
+		for j, i := range pending {
+			reqs[j] = rpc.BatchElem{Method: elems[i].Method, Args: elems[i].Args, Result: new(json.RawMessage)}
+		}
+		var err error
+		size := batchSize
+		if e.caps != nil && !e.caps.Batch {
+			size = 1
+		}
+		for start := 0; start < len(reqs) && err == nil; start += size {
+			batch := reqs[start:min(start+size, len(reqs))]
+			err = f.scheduler.do(ctx, e, func(ctx context.Context) error {
+				return e.send(ctx, batch, f.callTimeout)
+			})
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Warnf("rpc %s failed, trying the next endpoint: %s", e.url, err)
+			lastErr = err
+			continue
+		}
+		reached = true
+		for j, i := range pending {
+			raw := *reqs[j].Result.(*json.RawMessage)
+			if reqs[j].Error != nil {
+				errs[i] = reqs[j].Error
+				continue
+			}
+			if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+				errs[i] = ethereum.NotFound
+				continue
+			}
+			if quorum == 1 {
+				results[i] = raw
+				continue
+			}
+			d, err := digest(raw)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			if votes[i] == nil {
+				votes[i] = make(map[common.Hash]*vote)
+			}
+			v, ok := votes[i][d]
+			if !ok {
+				v = &vote{raw: raw}
+				votes[i][d] = v
+			}
+			v.count++
+			if v.count >= quorum {
+				results[i] = v.raw
+			}
+		}
+	}
+	if !reached {
+		return lastErr
+	}
+	for i := range elems {
+		switch {
+		case results[i] != nil:
+			elems[i].Error = json.Unmarshal(results[i], elems[i].Result)
+		case len(votes[i]) > 0:
+			elems[i].Error = fmt.Errorf("no quorum of %d rpc endpoints on %s %v: %d different results",
+				quorum, elems[i].Method, elems[i].Args, len(votes[i]))
+		default:
+			elems[i].Error = errs[i]
+		}
+	}
+	return nil
+}
+
+// call sends a single request with batchCall
+func (f *chainFetcher) call(ctx context.Context, result interface{}, digest digestFunc, method string, args ...interface{}) error {
+	elems := []rpc.BatchElem{{Method: method, Args: args, Result: result}}
+	if err := f.batchCall(ctx, elems, 1, digest); err != nil {
+		return err
+	}
+	return elems[0].Error
+}
+
+// failover runs fn with the endpoints in order until it succeeds
+func (f *chainFetcher) failover(ctx context.Context, fn func(ctx context.Context, e *rpcEndpoint) error) error {
+	err := errNoRPCEndpoint
+	for _, e := range f.endpoints {
+		err = f.scheduler.do(ctx, e, func(ctx context.Context) error {
+			ctx, cancel := withCallTimeout(ctx, f.callTimeout)
+			defer cancel()
+			return fn(ctx, e)
+		})
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Warnf("rpc %s failed, trying the next endpoint: %s", e.url, err)
+	}
+	return err
+}
+
+// withCallTimeout bounds one call to an endpoint, a call that times out fails
+// over to the next endpoint while the parent ctx is not done
+func withCallTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// receiptDigest covers the consensus fields of a receipt and its position
+func receiptDigest(raw json.RawMessage) (common.Hash, error) {
+	var r types.Receipt
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return common.Hash{}, err
+	}
+	return hashReceipt(&r)
+}
+
+func blockReceiptsDigest(raw json.RawMessage) (common.Hash, error) {
+	var receipts []*types.Receipt
+	if err := json.Unmarshal(raw, &receipts); err != nil {
+		return common.Hash{}, err
+	}
+	var hashes [][]byte
+	for _, r := range receipts {
+		h, err := hashReceipt(r)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		hashes = append(hashes, h.Bytes())
+	}
+	return crypto.Keccak256Hash(hashes...), nil
+}
+
+func hashReceipt(r *types.Receipt) (common.Hash, error) {
+	bin, err := r.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	index := new(big.Int).SetUint64(uint64(r.TransactionIndex))
+	return crypto.Keccak256Hash(bin, r.TxHash.Bytes(), r.BlockHash.Bytes(), index.Bytes()), nil
+}
+
+func storageDigest(raw json.RawMessage) (common.Hash, error) {
+	var value hexutil.Bytes
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(value), nil
+}
+
+// rateLimiter is a token bucket of burst tokens refilled at rate tokens per
+// second. It tracks when the bucket is next full instead of the tokens, i.e.
+// the requests are spaced 1/rate seconds apart once the burst is used up.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	// the bucket has room for burst-1 more requests before next
+	tolerance time.Duration
+	next      time.Time
+}
+
+// newRateLimiter returns a limiter of rate requests per second in bursts of up
+// to burst requests, or nil if rate is not positive. A nil limiter doesn't
+// limit.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	return &rateLimiter{interval: interval, tolerance: time.Duration(max(burst-1, 0)) * interval}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at.Add(-l.tolerance))
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
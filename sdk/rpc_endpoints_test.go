@@ -0,0 +1,180 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func newTestEndpoint(url string, rate float64) *rpcEndpoint {
+	client, err := rpc.Dial(url)
+	check(err)
+	return &rpcEndpoint{url: url, client: client, ec: ethclient.NewClient(client), limiter: newRateLimiter(rate, 1)}
+}
+
+func TestRPCFailover(t *testing.T) {
+	c := newTestChain()
+	slot := common.BigToHash(big.NewInt(1))
+
+	down := newFakeRPC(t, nil)
+	down.Close()
+	// a lagging endpoint doesn't have the receipt and fails to get storage
+	lagging := c.handlers(true)
+	lagging["eth_getTransactionReceipt"] = func(params []json.RawMessage) (interface{}, error) {
+		return nil, nil
+	}
+	lagging["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		return nil, fmt.Errorf("header not found")
+	}
+	laggingRPC := newFakeRPC(t, lagging)
+	good := newFakeRPC(t, c.handlers(true))
+
+	f := newChainFetcher([]*rpcEndpoint{
+		newTestEndpoint(down.URL, 0), newTestEndpoint(laggingRPC.URL, 0), newTestEndpoint(good.URL, 0),
+	}, 0)
+	r, err := f.receipt(context.Background(), c.txHash(1))
+	check(err)
+	if r.TxHash != c.txHash(1) || r.TransactionIndex != 1 {
+		t.Errorf("unexpected receipt %+v", r)
+	}
+	value, err := f.storageAt(context.Background(), big.NewInt(100), testAccount, slot)
+	check(err)
+	if value != c.storage[slot] {
+		t.Errorf("expected storage value %x, got %x", c.storage[slot], value)
+	}
+	// headers are served by the lagging endpoint
+	_, _, err = f.header(context.Background(), big.NewInt(100))
+	check(err)
+	if laggingRPC.count("eth_getBlockByNumber") != 1 || good.count("eth_getBlockByNumber") != 0 {
+		t.Errorf("expected the header from the first reachable endpoint")
+	}
+
+	// the receipts the lagging endpoint doesn't have are fetched from the next
+	// one in one batch
+	hashes := []common.Hash{c.txHash(0), c.txHash(2)}
+	check(f.prefetchReceipts(context.Background(), hashes, nil))
+	if n := good.count("eth_getTransactionReceipt"); n != 3 {
+		t.Errorf("expected 3 eth_getTransactionReceipt from the good endpoint, got %d", n)
+	}
+
+	f = newChainFetcher([]*rpcEndpoint{newTestEndpoint(down.URL, 0)}, 0)
+	if _, err = f.receipt(context.Background(), c.txHash(1)); err == nil {
+		t.Error("expected an error when no endpoint is reachable")
+	}
+}
+
+func TestRPCQuorum(t *testing.T) {
+	c := newTestChain()
+	// a faulty endpoint returns a wrong value for slot 1
+	faulty := c.handlers(true)
+	faulty["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		var slot common.Hash
+		check(json.Unmarshal(params[1], &slot))
+		if slot == common.BigToHash(big.NewInt(1)) {
+			return common.BigToHash(big.NewInt(99)), nil
+		}
+		return c.storage[slot], nil
+	}
+	faultyRPC := newFakeRPC(t, faulty)
+	good1 := newFakeRPC(t, c.handlers(true))
+	good2 := newFakeRPC(t, c.handlers(true))
+
+	f := newChainFetcher([]*rpcEndpoint{
+		newTestEndpoint(faultyRPC.URL, 0), newTestEndpoint(good1.URL, 0), newTestEndpoint(good2.URL, 0),
+	}, 2)
+	var slots []StorageData
+	for i := 0; i < 3; i++ {
+		slots = append(slots, StorageData{BlockNum: big.NewInt(100), Address: testAccount, Slot: common.BigToHash(big.NewInt(int64(i)))})
+	}
+	check(f.prefetchStorage(context.Background(), slots))
+	for _, s := range slots {
+		value, err := f.storageAt(context.Background(), s.BlockNum, testAccount, s.Slot)
+		check(err)
+		if value != c.storage[s.Slot] {
+			t.Errorf("expected storage value %x of slot %x, got %x", c.storage[s.Slot], s.Slot, value)
+		}
+	}
+	// only the disputed slot is sent to the third endpoint
+	if n := good2.count("eth_getStorageAt"); n != 1 {
+		t.Errorf("expected 1 eth_getStorageAt from the third endpoint, got %d", n)
+	}
+	// receipts are cross-checked, headers are not
+	_, err := f.receipt(context.Background(), c.txHash(0))
+	check(err)
+	_, _, err = f.header(context.Background(), big.NewInt(100))
+	check(err)
+	if faultyRPC.count("eth_getTransactionReceipt") != 1 || good1.count("eth_getTransactionReceipt") != 1 {
+		t.Error("expected the receipt from two endpoints")
+	}
+	if n := faultyRPC.count("eth_getBlockByNumber") + good1.count("eth_getBlockByNumber"); n != 1 {
+		t.Errorf("expected the header from one endpoint, got %d requests", n)
+	}
+
+	// no quorum without the third endpoint
+	f = newChainFetcher([]*rpcEndpoint{newTestEndpoint(faultyRPC.URL, 0), newTestEndpoint(good1.URL, 0)}, 2)
+	if _, err = f.storageAt(context.Background(), big.NewInt(100), testAccount, common.BigToHash(big.NewInt(1))); err == nil {
+		t.Error("expected an error without quorum")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(20, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		check(l.wait(context.Background()))
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected 3 requests at 20/s to take at least 100ms, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); err == nil {
+		t.Error("expected a canceled wait to fail")
+	}
+	var unlimited *rateLimiter
+	check(unlimited.wait(context.Background()))
+}
+
+func TestRPCCallTimeout(t *testing.T) {
+	c := newTestChain()
+	slow := c.handlers(true)
+	slow["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		time.Sleep(500 * time.Millisecond)
+		return common.Hash{}, nil
+	}
+	slowRPC := newFakeRPC(t, slow)
+	good := newFakeRPC(t, c.handlers(true))
+
+	f := newChainFetcher([]*rpcEndpoint{newTestEndpoint(slowRPC.URL, 0), newTestEndpoint(good.URL, 0)}, 0)
+	f.callTimeout = 50 * time.Millisecond
+	slot := common.BigToHash(big.NewInt(1))
+	start := time.Now()
+	value, err := f.storageAt(context.Background(), big.NewInt(100), testAccount, slot)
+	check(err)
+	if value != c.storage[slot] {
+		t.Errorf("expected storage value %x from the next endpoint, got %x", c.storage[slot], value)
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("expected the slow call to time out, took %s", elapsed)
+	}
+
+	// the parent ctx isn't failed over
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	f = newChainFetcher([]*rpcEndpoint{newTestEndpoint(slowRPC.URL, 0), newTestEndpoint(good.URL, 0)}, 0)
+	if _, err = f.storageAt(ctx, big.NewInt(100), testAccount, common.BigToHash(big.NewInt(2))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline of the parent ctx, got %v", err)
+	}
+	if n := good.count("eth_getStorageAt"); n != 1 {
+		t.Errorf("expected 1 eth_getStorageAt from the next endpoint, got %d", n)
+	}
+}
@@ -0,0 +1,590 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/celer-network/goutils/log"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultRPCBatchSize = 100
+	// full blocks are large, so fewer of them are fetched in one batch
+	maxBlocksPerBatch = 10
+	// chainHeadTTL is how long the final block and the headers of the blocks
+	// after it are cached, so that a long-lived app sees the chain advance and
+	// the reorgs of the blocks that are not final yet
+	chainHeadTTL = time.Minute
+)
+
+// chainFetcher fetches on-chain data with JSON-RPC batch requests and caches
+// the results, so that data shared by several queries (e.g. the header of a
+// block) is fetched only once. The prefetch* methods fetch in batches and never
+// fail the queries: data that can't be prefetched is fetched individually by
+// the single item getters when it is used.
+//
+// The requests are sent to the endpoints in order with failover, receipts and
+// storage values are cross-checked by quorum endpoints, see batchCall.
+type chainFetcher struct {
+	endpoints []*rpcEndpoint
+	quorum    int
+	batchSize int
+	group     singleflight.Group
+	// bounds each request sent to an endpoint if not 0
+	callTimeout time.Duration
+	// schedules the requests to the endpoints, nil if not limited
+	scheduler *fetchScheduler
+
+	mu                       sync.Mutex
+	headers                  map[uint64]fetchedHeader
+	receipts                 map[common.Hash]*types.Receipt
+	blocks                   map[uint64]*types.Block
+	storage                  map[storageKey]common.Hash
+	accounts                 map[accountKey]*accountState
+	blockReceiptsUnsupported bool
+	// the latest final block and when it was fetched, see finalBlock
+	final   uint64
+	finalAt time.Time
+}
+
+type fetchedHeader struct {
+	header    *types.Header
+	hash      common.Hash
+	fetchedAt time.Time
+	// whether the block was known final when the header was cached
+	final bool
+}
+
+type storageKey struct {
+	blockNum uint64
+	address  common.Address
+	slot     common.Hash
+}
+
+type accountKey struct {
+	blockNum uint64
+	address  common.Address
+}
+
+// accountState is the account part of an eth_getProof response
+type accountState struct {
+	Balance     *big.Int
+	CodeHash    common.Hash
+	Nonce       uint64
+	StorageHash common.Hash
+}
+
+func newChainFetcher(endpoints []*rpcEndpoint, quorum int) *chainFetcher {
+	// known unsupported if none of the endpoints is found to support it
+	blockReceiptsUnsupported := true
+	for _, e := range endpoints {
+		if e.caps == nil || e.caps.BlockReceipts {
+			blockReceiptsUnsupported = false
+		}
+	}
+	return &chainFetcher{
+		blockReceiptsUnsupported: blockReceiptsUnsupported,
+		endpoints:                endpoints,
+		quorum:                   quorum,
+		batchSize:                defaultRPCBatchSize,
+		headers:                  make(map[uint64]fetchedHeader),
+		receipts:                 make(map[common.Hash]*types.Receipt),
+		blocks:                   make(map[uint64]*types.Block),
+		storage:                  make(map[storageKey]common.Hash),
+		accounts:                 make(map[accountKey]*accountState),
+	}
+}
+
+// cachedHeader returns the cached header of the block. The header of a block
+// that was not known final when it was cached expires after chainHeadTTL, as
+// the block may be reorged. f.mu must be held.
+func (f *chainFetcher) cachedHeader(num uint64) (fetchedHeader, bool) {
+	h, ok := f.headers[num]
+	if ok && !h.final && time.Since(h.fetchedAt) >= chainHeadTTL {
+		return fetchedHeader{}, false
+	}
+	return h, ok
+}
+
+// cacheHeader caches the header of the block. If the block is reorged, the
+// data cached from it is dropped. f.mu must be held.
+func (f *chainFetcher) cacheHeader(num uint64, h fetchedHeader) {
+	if old, ok := f.headers[num]; ok && old.hash != h.hash {
+		delete(f.blocks, num)
+		for k := range f.storage {
+			if k.blockNum == num {
+				delete(f.storage, k)
+			}
+		}
+		for k := range f.accounts {
+			if k.blockNum == num {
+				delete(f.accounts, k)
+			}
+		}
+		for k, r := range f.receipts {
+			if r.BlockNumber != nil && r.BlockNumber.Uint64() == num {
+				delete(f.receipts, k)
+			}
+		}
+	}
+	h.final = !f.finalAt.IsZero() && num <= f.final
+	f.headers[num] = h
+}
+
+// header returns the header of the block and the block hash reported by the
+// RPC. The hash is not derived from the header since some chains hash their
+// headers differently.
+func (f *chainFetcher) header(ctx context.Context, blkNum *big.Int) (*types.Header, common.Hash, error) {
+	f.mu.Lock()
+	h, ok := f.cachedHeader(blkNum.Uint64())
+	f.mu.Unlock()
+	if ok {
+		return h.header, h.hash, nil
+	}
+	v, err, _ := f.group.Do(fmt.Sprintf("header-%d", blkNum), func() (interface{}, error) {
+		var raw json.RawMessage
+		err := f.call(ctx, &raw, nil, "eth_getBlockByNumber", hexutil.EncodeBig(blkNum), false)
+		if err != nil {
+			return nil, err
+		}
+		h, err := decodeHeader(raw)
+		if err != nil {
+			return nil, err
+		}
+		f.mu.Lock()
+		f.cacheHeader(blkNum.Uint64(), h)
+		f.mu.Unlock()
+		return h, nil
+	})
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	h = v.(fetchedHeader)
+	return h.header, h.hash, nil
+}
+
+func (f *chainFetcher) receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	r, ok := f.receipts[txHash]
+	f.mu.Unlock()
+	if ok {
+		return r, nil
+	}
+	v, err, _ := f.group.Do("receipt-"+txHash.Hex(), func() (interface{}, error) {
+		var r *types.Receipt
+		err := f.call(ctx, &r, receiptDigest, "eth_getTransactionReceipt", txHash)
+		if err != nil {
+			return nil, err
+		}
+		f.mu.Lock()
+		f.receipts[txHash] = r
+		f.mu.Unlock()
+		return r, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*types.Receipt), nil
+}
+
+func (f *chainFetcher) block(ctx context.Context, blkNum *big.Int) (*types.Block, error) {
+	f.mu.Lock()
+	b, ok := f.blocks[blkNum.Uint64()]
+	f.mu.Unlock()
+	if ok {
+		return b, nil
+	}
+	v, err, _ := f.group.Do(fmt.Sprintf("block-%d", blkNum), func() (interface{}, error) {
+		var raw json.RawMessage
+		err := f.call(ctx, &raw, nil, "eth_getBlockByNumber", hexutil.EncodeBig(blkNum), true)
+		if err != nil {
+			return nil, err
+		}
+		h, b, err := decodeBlock(raw)
+		if err != nil {
+			return nil, err
+		}
+		f.mu.Lock()
+		f.cacheHeader(blkNum.Uint64(), h)
+		f.blocks[blkNum.Uint64()] = b
+		f.mu.Unlock()
+		return b, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*types.Block), nil
+}
+
+func (f *chainFetcher) storageAt(ctx context.Context, blkNum *big.Int, address common.Address, slot common.Hash) (common.Hash, error) {
+	key := storageKey{blockNum: blkNum.Uint64(), address: address, slot: slot}
+	f.mu.Lock()
+	value, ok := f.storage[key]
+	f.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	v, err, _ := f.group.Do(fmt.Sprintf("storage-%d-%s-%s", blkNum, address.Hex(), slot.Hex()), func() (interface{}, error) {
+		var bs hexutil.Bytes
+		err := f.call(ctx, &bs, storageDigest, "eth_getStorageAt", address, slot, hexutil.EncodeBig(blkNum))
+		if err != nil {
+			return nil, err
+		}
+		value := common.BytesToHash(bs)
+		f.mu.Lock()
+		f.storage[key] = value
+		f.mu.Unlock()
+		return value, nil
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return v.(common.Hash), nil
+}
+
+func (f *chainFetcher) account(ctx context.Context, blkNum *big.Int, address common.Address) (*accountState, error) {
+	key := accountKey{blockNum: blkNum.Uint64(), address: address}
+	f.mu.Lock()
+	state, ok := f.accounts[key]
+	f.mu.Unlock()
+	if ok {
+		return state, nil
+	}
+	v, err, _ := f.group.Do(fmt.Sprintf("account-%d-%s", blkNum, address.Hex()), func() (interface{}, error) {
+		var res proofResult
+		err := f.call(ctx, &res, nil, "eth_getProof", address, []string{}, hexutil.EncodeBig(blkNum))
+		if err != nil {
+			return nil, err
+		}
+		state := res.state()
+		f.mu.Lock()
+		f.accounts[key] = state
+		f.mu.Unlock()
+		return state, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*accountState), nil
+}
+
+// prefetchHeaders fetches the headers of the blocks that are not cached yet
+func (f *chainFetcher) prefetchHeaders(ctx context.Context, blkNums []*big.Int) error {
+	var nums []uint64
+	var elems []rpc.BatchElem
+	f.mu.Lock()
+	seen := make(map[uint64]bool)
+	for _, n := range blkNums {
+		num := n.Uint64()
+		if _, ok := f.cachedHeader(num); ok || seen[num] {
+			continue
+		}
+		seen[num] = true
+		nums = append(nums, num)
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(num), false},
+			Result: new(json.RawMessage),
+		})
+	}
+	f.mu.Unlock()
+	if err := f.batchCall(ctx, elems, f.batchSize, nil); err != nil {
+		return fmt.Errorf("cannot batch get block headers: %w", err)
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			log.Warnf("cannot prefetch header of block %d: %s", nums[i], elem.Error)
+			continue
+		}
+		h, err := decodeHeader(*elem.Result.(*json.RawMessage))
+		if err != nil {
+			log.Warnf("cannot prefetch header of block %d: %s", nums[i], err)
+			continue
+		}
+		f.mu.Lock()
+		f.cacheHeader(nums[i], h)
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// prefetchReceipts fetches the receipts of the txs and the headers of their
+// blocks. If the block of a tx is known, the receipts of the blocks with more
+// than one tx are fetched with eth_getBlockReceipts where the RPC supports it.
+func (f *chainFetcher) prefetchReceipts(ctx context.Context, txHashes []common.Hash, blkNums map[common.Hash]*big.Int) error {
+	f.mu.Lock()
+	var missing []common.Hash
+	seen := make(map[common.Hash]bool)
+	for _, hash := range txHashes {
+		if _, ok := f.receipts[hash]; ok || seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		missing = append(missing, hash)
+	}
+	useBlockReceipts := !f.blockReceiptsUnsupported
+	f.mu.Unlock()
+
+	if useBlockReceipts {
+		txsPerBlock := make(map[uint64]int)
+		for _, hash := range missing {
+			if n, ok := blkNums[hash]; ok && n != nil {
+				txsPerBlock[n.Uint64()]++
+			}
+		}
+		var blocks []uint64
+		var elems []rpc.BatchElem
+		for num, count := range txsPerBlock {
+			if count < 2 {
+				continue
+			}
+			blocks = append(blocks, num)
+			elems = append(elems, rpc.BatchElem{
+				Method: "eth_getBlockReceipts",
+				Args:   []interface{}{hexutil.EncodeUint64(num)},
+				Result: new([]*types.Receipt),
+			})
+		}
+		if err := f.batchCall(ctx, elems, f.batchSize, blockReceiptsDigest); err != nil {
+			return fmt.Errorf("cannot batch get block receipts: %w", err)
+		}
+		for i, elem := range elems {
+			if elem.Error != nil {
+				if isMethodNotFound(elem.Error) {
+					log.Infof("eth_getBlockReceipts is not supported by the RPC, falling back to eth_getTransactionReceipt")
+					f.mu.Lock()
+					f.blockReceiptsUnsupported = true
+					f.mu.Unlock()
+					break
+				}
+				log.Warnf("cannot prefetch receipts of block %d: %s", blocks[i], elem.Error)
+				continue
+			}
+			f.mu.Lock()
+			for _, r := range *elem.Result.(*[]*types.Receipt) {
+				f.receipts[r.TxHash] = r
+			}
+			f.mu.Unlock()
+		}
+	}
+
+	var elems []rpc.BatchElem
+	f.mu.Lock()
+	for _, hash := range missing {
+		if _, ok := f.receipts[hash]; ok {
+			continue
+		}
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: new(*types.Receipt),
+		})
+	}
+	f.mu.Unlock()
+	if err := f.batchCall(ctx, elems, f.batchSize, receiptDigest); err != nil {
+		return fmt.Errorf("cannot batch get receipts: %w", err)
+	}
+	var receiptBlocks []*big.Int
+	f.mu.Lock()
+	for _, elem := range elems {
+		r := *elem.Result.(**types.Receipt)
+		if elem.Error != nil || r == nil {
+			log.Warnf("cannot prefetch receipt of tx %s: %v", elem.Args[0], elem.Error)
+			continue
+		}
+		f.receipts[r.TxHash] = r
+	}
+	for _, hash := range missing {
+		if r, ok := f.receipts[hash]; ok {
+			receiptBlocks = append(receiptBlocks, r.BlockNumber)
+		}
+	}
+	f.mu.Unlock()
+	return f.prefetchHeaders(ctx, receiptBlocks)
+}
+
+// prefetchBlocks fetches the blocks with their txs
+func (f *chainFetcher) prefetchBlocks(ctx context.Context, blkNums []*big.Int) error {
+	var nums []uint64
+	var elems []rpc.BatchElem
+	f.mu.Lock()
+	seen := make(map[uint64]bool)
+	for _, n := range blkNums {
+		num := n.Uint64()
+		if _, ok := f.blocks[num]; ok || seen[num] {
+			continue
+		}
+		seen[num] = true
+		nums = append(nums, num)
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(num), true},
+			Result: new(json.RawMessage),
+		})
+	}
+	f.mu.Unlock()
+	if err := f.batchCall(ctx, elems, min(f.batchSize, maxBlocksPerBatch), nil); err != nil {
+		return fmt.Errorf("cannot batch get blocks: %w", err)
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			log.Warnf("cannot prefetch block %d: %s", nums[i], elem.Error)
+			continue
+		}
+		h, block, err := decodeBlock(*elem.Result.(*json.RawMessage))
+		if err != nil {
+			log.Warnf("cannot prefetch block %d: %s", nums[i], err)
+			continue
+		}
+		f.mu.Lock()
+		f.cacheHeader(nums[i], h)
+		f.blocks[nums[i]] = block
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// prefetchStorage fetches the values of the storage slots and the headers of
+// their blocks
+func (f *chainFetcher) prefetchStorage(ctx context.Context, slots []StorageData) error {
+	var keys []storageKey
+	var elems []rpc.BatchElem
+	var blkNums []*big.Int
+	f.mu.Lock()
+	for _, s := range slots {
+		key := storageKey{blockNum: s.BlockNum.Uint64(), address: s.Address, slot: s.Slot}
+		blkNums = append(blkNums, s.BlockNum)
+		if _, ok := f.storage[key]; ok {
+			continue
+		}
+		keys = append(keys, key)
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{s.Address, s.Slot, hexutil.EncodeBig(s.BlockNum)},
+			Result: new(hexutil.Bytes),
+		})
+	}
+	f.mu.Unlock()
+	if err := f.batchCall(ctx, elems, f.batchSize, storageDigest); err != nil {
+		return fmt.Errorf("cannot batch get storage values: %w", err)
+	}
+	f.mu.Lock()
+	for i, elem := range elems {
+		if elem.Error != nil {
+			log.Warnf("cannot prefetch storage value of %x slot %x at block %d: %s", keys[i].address, keys[i].slot, keys[i].blockNum, elem.Error)
+			continue
+		}
+		f.storage[keys[i]] = common.BytesToHash(*elem.Result.(*hexutil.Bytes))
+	}
+	f.mu.Unlock()
+	return f.prefetchHeaders(ctx, blkNums)
+}
+
+// prefetchAccounts fetches the states of the accounts and the headers of their
+// blocks
+func (f *chainFetcher) prefetchAccounts(ctx context.Context, accounts []AccountData) error {
+	var keys []accountKey
+	var elems []rpc.BatchElem
+	var blkNums []*big.Int
+	f.mu.Lock()
+	for _, a := range accounts {
+		key := accountKey{blockNum: a.BlockNum.Uint64(), address: a.Address}
+		blkNums = append(blkNums, a.BlockNum)
+		if _, ok := f.accounts[key]; ok {
+			continue
+		}
+		keys = append(keys, key)
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getProof",
+			Args:   []interface{}{a.Address, []string{}, hexutil.EncodeBig(a.BlockNum)},
+			Result: new(proofResult),
+		})
+	}
+	f.mu.Unlock()
+	if err := f.batchCall(ctx, elems, f.batchSize, nil); err != nil {
+		return fmt.Errorf("cannot batch get account states: %w", err)
+	}
+	f.mu.Lock()
+	for i, elem := range elems {
+		if elem.Error != nil {
+			log.Warnf("cannot prefetch account state of %x at block %d: %s", keys[i].address, keys[i].blockNum, elem.Error)
+			continue
+		}
+		f.accounts[keys[i]] = elem.Result.(*proofResult).state()
+	}
+	f.mu.Unlock()
+	return f.prefetchHeaders(ctx, blkNums)
+}
+
+type proofResult struct {
+	Balance     *hexutil.Big   `json:"balance"`
+	CodeHash    common.Hash    `json:"codeHash"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	StorageHash common.Hash    `json:"storageHash"`
+}
+
+func (r *proofResult) state() *accountState {
+	return &accountState{
+		Balance:     (*big.Int)(r.Balance),
+		CodeHash:    r.CodeHash,
+		Nonce:       uint64(r.Nonce),
+		StorageHash: r.StorageHash,
+	}
+}
+
+func decodeHeader(raw json.RawMessage) (fetchedHeader, error) {
+	var head *types.Header
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return fetchedHeader{}, err
+	}
+	// When the block is not found, the API returns JSON null.
+	if head == nil {
+		return fetchedHeader{}, ethereum.NotFound
+	}
+	var block struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return fetchedHeader{}, err
+	}
+	return fetchedHeader{header: head, hash: block.Hash, fetchedAt: time.Now()}, nil
+}
+
+// decodeBlock decodes a block with full txs
+func decodeBlock(raw json.RawMessage) (fetchedHeader, *types.Block, error) {
+	h, err := decodeHeader(raw)
+	if err != nil {
+		return fetchedHeader{}, nil, err
+	}
+	var body struct {
+		Transactions []*types.Transaction `json:"transactions"`
+		Withdrawals  []*types.Withdrawal  `json:"withdrawals,omitempty"`
+	}
+	if err = json.Unmarshal(raw, &body); err != nil {
+		return fetchedHeader{}, nil, err
+	}
+	block := types.NewBlockWithHeader(h.header).WithBody(types.Body{Transactions: body.Transactions, Withdrawals: body.Withdrawals})
+	return h, block, nil
+}
+
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "method") &&
+		(strings.Contains(msg, "not found") || strings.Contains(msg, "not supported") || strings.Contains(msg, "does not exist"))
+}
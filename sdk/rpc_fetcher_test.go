@@ -0,0 +1,449 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brevis-network/brevis-sdk/store"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// testChain is a fake chain of two blocks served by a fakeRPC. Block 100 has
+// three txs and block 101 has none. Both blocks have the same state, in which
+// testAccount has slots 0 to 2 set to 10 to 12.
+type testChain struct {
+	blocks   map[uint64]*types.Block
+	receipts map[common.Hash]*types.Receipt
+	storage  map[common.Hash]common.Hash
+	state    *trie.Trie
+	account  *types.StateAccount
+	storageT *trie.Trie
+}
+
+var testAccount = common.HexToAddress("0x01")
+
+func newTestChain() *testChain {
+	c := &testChain{
+		blocks:   make(map[uint64]*types.Block),
+		receipts: make(map[common.Hash]*types.Receipt),
+		storage:  make(map[common.Hash]common.Hash),
+	}
+	c.storageT = trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	for i := 0; i < 3; i++ {
+		slot, value := common.BigToHash(big.NewInt(int64(i))), common.BigToHash(big.NewInt(int64(i+10)))
+		c.storage[slot] = value
+		enc, err := rlp.EncodeToBytes(common.TrimLeftZeroes(value.Bytes()))
+		check(err)
+		check(c.storageT.Update(crypto.Keccak256(slot.Bytes()), enc))
+	}
+	c.account = types.NewEmptyStateAccount()
+	c.account.Nonce = 1
+	c.account.Balance.SetUint64(5)
+	c.account.Root = c.storageT.Hash()
+	c.state = trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	enc, err := rlp.EncodeToBytes(c.account)
+	check(err)
+	check(c.state.Update(crypto.Keccak256(testAccount.Bytes()), enc))
+
+	var txs []*types.Transaction
+	var receipts []*types.Receipt
+	for i := 0; i < 3; i++ {
+		// calldata keeps the trie nodes over 32 bytes so that none is embedded
+		tx := types.NewTx(&types.LegacyTx{Nonce: uint64(i), Gas: 21000, GasPrice: big.NewInt(1), Value: big.NewInt(int64(i)), Data: make([]byte, 64)})
+		txs = append(txs, tx)
+		log := &types.Log{
+			Address: testAccount,
+			Topics:  []common.Hash{crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))},
+			Data:    common.BigToHash(big.NewInt(int64(i))).Bytes(),
+		}
+		receipts = append(receipts, &types.Receipt{Status: 1, CumulativeGasUsed: uint64(21000 * (i + 1)), Logs: []*types.Log{log}})
+	}
+	for _, num := range []int64{100, 101} {
+		header := &types.Header{Number: big.NewInt(num), BaseFee: big.NewInt(7), Time: uint64(1000 + num), Difficulty: big.NewInt(0), Root: c.state.Hash()}
+		var block *types.Block
+		if num == 100 {
+			block = types.NewBlock(header, &types.Body{Transactions: txs}, receipts, trie.NewStackTrie(nil))
+		} else {
+			block = types.NewBlock(header, &types.Body{}, nil, trie.NewStackTrie(nil))
+		}
+		c.blocks[uint64(num)] = block
+	}
+	for i, r := range receipts {
+		r.TxHash = txs[i].Hash()
+		r.BlockNumber = big.NewInt(100)
+		r.BlockHash = c.blocks[100].Hash()
+		r.TransactionIndex = uint(i)
+		c.receipts[r.TxHash] = r
+	}
+	return c
+}
+
+func (c *testChain) txHash(i int) common.Hash {
+	return c.blocks[100].Transactions()[i].Hash()
+}
+
+func (c *testChain) handlers(blockReceipts bool) map[string]func(params []json.RawMessage) (interface{}, error) {
+	blockByNumber := func(raw json.RawMessage) (*types.Block, error) {
+		var num hexutil.Uint64
+		if err := json.Unmarshal(raw, &num); err != nil {
+			return nil, err
+		}
+		return c.blocks[uint64(num)], nil
+	}
+	handlers := map[string]func(params []json.RawMessage) (interface{}, error){
+		"eth_getBlockByNumber": func(params []json.RawMessage) (interface{}, error) {
+			block, err := blockByNumber(params[0])
+			if err != nil || block == nil {
+				return nil, err
+			}
+			var fullTx bool
+			check(json.Unmarshal(params[1], &fullTx))
+			fields := make(map[string]interface{})
+			bs, err := json.Marshal(block.Header())
+			check(err)
+			check(json.Unmarshal(bs, &fields))
+			if fullTx {
+				fields["transactions"] = block.Transactions()
+			} else {
+				var hashes []common.Hash
+				for _, tx := range block.Transactions() {
+					hashes = append(hashes, tx.Hash())
+				}
+				fields["transactions"] = hashes
+			}
+			return fields, nil
+		},
+		"eth_getTransactionReceipt": func(params []json.RawMessage) (interface{}, error) {
+			var hash common.Hash
+			check(json.Unmarshal(params[0], &hash))
+			return c.receipts[hash], nil
+		},
+		"eth_getStorageAt": func(params []json.RawMessage) (interface{}, error) {
+			var slot common.Hash
+			check(json.Unmarshal(params[1], &slot))
+			return c.storage[slot], nil
+		},
+		"eth_getProof": func(params []json.RawMessage) (interface{}, error) {
+			var address common.Address
+			var slots []common.Hash
+			check(json.Unmarshal(params[0], &address))
+			check(json.Unmarshal(params[1], &slots))
+			if address != testAccount {
+				return nil, fmt.Errorf("unknown account %x", address)
+			}
+			var storageProof []interface{}
+			for _, slot := range slots {
+				storageProof = append(storageProof, map[string]interface{}{
+					"key":   slot,
+					"value": (*hexutil.Big)(c.storage[slot].Big()),
+					"proof": proveTrie(c.storageT, slot.Bytes()),
+				})
+			}
+			return map[string]interface{}{
+				"balance":      (*hexutil.Big)(c.account.Balance.ToBig()),
+				"codeHash":     common.BytesToHash(c.account.CodeHash),
+				"nonce":        hexutil.Uint64(c.account.Nonce),
+				"storageHash":  c.account.Root,
+				"accountProof": proveTrie(c.state, address.Bytes()),
+				"storageProof": storageProof,
+			}, nil
+		},
+	}
+	if blockReceipts {
+		handlers["eth_getBlockReceipts"] = func(params []json.RawMessage) (interface{}, error) {
+			block, err := blockByNumber(params[0])
+			if err != nil || block == nil {
+				return nil, err
+			}
+			var receipts []*types.Receipt
+			for _, tx := range block.Transactions() {
+				receipts = append(receipts, c.receipts[tx.Hash()])
+			}
+			return receipts, nil
+		}
+	}
+	return handlers
+}
+
+func proveTrie(t *trie.Trie, key []byte) []hexutil.Bytes {
+	w := &ProofWriter{}
+	check(t.Prove(crypto.Keccak256(key), w))
+	var proof []hexutil.Bytes
+	for _, node := range w.Values {
+		proof = append(proof, node)
+	}
+	return proof
+}
+
+func newTestFetchApp(t *testing.T, c *testChain, blockReceipts bool) (*BrevisApp, *fakeRPC) {
+	rpc := newFakeRPC(t, c.handlers(blockReceipts))
+	ec, err := ethclient.Dial(rpc.URL)
+	check(err)
+	dataStore, err := store.InitStore("syncmap", "")
+	check(err)
+	q := &BrevisApp{ec: ec, dataStore: dataStore, concurrentFetchLimit: 4, srcChainId: 1}
+	// receipts of the same block, tx 2 without the block num
+	q.AddReceipt(ReceiptData{TxHash: c.txHash(0), BlockNum: big.NewInt(100), Fields: []LogFieldData{{}}})
+	q.AddReceipt(ReceiptData{TxHash: c.txHash(1), BlockNum: big.NewInt(100), Fields: []LogFieldData{{}}})
+	q.AddReceipt(ReceiptData{TxHash: c.txHash(2), Fields: []LogFieldData{{}}})
+	q.AddTransaction(TransactionData{Hash: c.txHash(1)})
+	for i := 0; i < 3; i++ {
+		q.AddStorage(StorageData{BlockNum: big.NewInt(int64(100 + i%2)), Address: testAccount, Slot: common.BigToHash(big.NewInt(int64(i)))})
+	}
+	return q, rpc
+}
+
+func buildTestFetchApp(q *BrevisApp) {
+	for _, r := range q.receipts.ordered {
+		_, _, _, _, _, err := q.getReceiptInfos(context.Background(), r.TxHash)
+		check(err)
+	}
+	for _, tx := range q.txs.ordered {
+		_, err := q.buildTx(context.Background(), tx)
+		check(err)
+	}
+	for _, s := range q.storageVals.ordered {
+		slot, err := q.buildStorageSlot(context.Background(), s)
+		check(err)
+		if fromInterface(slot.BlockTimestamp.Val).Uint64() != 1000+s.BlockNum.Uint64() {
+			panic(fmt.Sprintf("unexpected timestamp %s of block %d", slot.BlockTimestamp.Val, s.BlockNum))
+		}
+	}
+}
+
+func TestPrefetchQueryData(t *testing.T) {
+	c := newTestChain()
+	q, rpc := newTestFetchApp(t, c, true)
+	q.prefetchQueryData(context.Background())
+	buildTestFetchApp(q)
+
+	// all receipts are in one eth_getBlockReceipts, which also covers tx 2
+	if n := rpc.count("eth_getBlockReceipts"); n != 1 {
+		t.Errorf("expected 1 eth_getBlockReceipts, got %d", n)
+	}
+	if n := rpc.count("eth_getTransactionReceipt"); n != 0 {
+		t.Errorf("expected no eth_getTransactionReceipt, got %d", n)
+	}
+	// header of block 100, full block 100, header of block 101
+	if n := rpc.count("eth_getBlockByNumber"); n != 3 {
+		t.Errorf("expected 3 eth_getBlockByNumber, got %d", n)
+	}
+	if n := rpc.count("eth_getStorageAt"); n != 3 {
+		t.Errorf("expected 3 eth_getStorageAt, got %d", n)
+	}
+	// block receipts, headers, blocks, storage values, headers of storage
+	if n := rpc.httpRequests(); n != 5 {
+		t.Errorf("expected 5 http requests, got %d", n)
+	}
+}
+
+func TestPrefetchQueryDataFallback(t *testing.T) {
+	c := newTestChain()
+	q, rpc := newTestFetchApp(t, c, false)
+	q.prefetchQueryData(context.Background())
+	buildTestFetchApp(q)
+
+	if n := rpc.count("eth_getTransactionReceipt"); n != 3 {
+		t.Errorf("expected 3 eth_getTransactionReceipt, got %d", n)
+	}
+	if n := rpc.count("eth_getBlockByNumber"); n != 3 {
+		t.Errorf("expected 3 eth_getBlockByNumber, got %d", n)
+	}
+	// block receipts (unsupported), receipts, headers, blocks, storage values, headers of storage
+	if n := rpc.httpRequests(); n != 6 {
+		t.Errorf("expected 6 http requests, got %d", n)
+	}
+	if !q.chain().blockReceiptsUnsupported {
+		t.Error("expected eth_getBlockReceipts to be marked unsupported")
+	}
+}
+
+func TestFetchWithoutPrefetch(t *testing.T) {
+	c := newTestChain()
+	q, rpc := newTestFetchApp(t, c, true)
+	buildTestFetchApp(q)
+
+	// receipts are fetched individually and shared by the receipt and tx
+	// queries, the header of each block is fetched once
+	if n := rpc.count("eth_getTransactionReceipt"); n != 3 {
+		t.Errorf("expected 3 eth_getTransactionReceipt, got %d", n)
+	}
+	if n := rpc.count("eth_getBlockByNumber"); n != 3 {
+		t.Errorf("expected 3 eth_getBlockByNumber, got %d", n)
+	}
+}
+
+func TestFetchStateOnce(t *testing.T) {
+	c := newTestChain()
+	handlers := c.handlers(true)
+	for _, method := range []string{"eth_getStorageAt", "eth_getProof"} {
+		handler := handlers[method]
+		handlers[method] = func(params []json.RawMessage) (interface{}, error) {
+			// slow enough for all calls to wait on the first one
+			time.Sleep(100 * time.Millisecond)
+			return handler(params)
+		}
+	}
+	rpc := newFakeRPC(t, handlers)
+	ec, err := ethclient.Dial(rpc.URL)
+	check(err)
+	q := &BrevisApp{ec: ec, concurrentFetchLimit: 4, srcChainId: 1}
+	f := q.chain()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := f.storageAt(ctx, big.NewInt(100), testAccount, common.Hash{})
+			check(err)
+			if value.Big().Uint64() != 10 {
+				panic(fmt.Sprintf("unexpected storage value %s", value))
+			}
+			state, err := f.account(ctx, big.NewInt(101), testAccount)
+			check(err)
+			if state.Nonce != 1 {
+				panic(fmt.Sprintf("unexpected account state %+v", state))
+			}
+		}()
+	}
+	wg.Wait()
+	// concurrent calls share one request and later calls are cached
+	if _, err := f.storageAt(ctx, big.NewInt(100), testAccount, common.Hash{}); err != nil {
+		t.Error(err)
+	}
+	if _, err := f.account(ctx, big.NewInt(101), testAccount); err != nil {
+		t.Error(err)
+	}
+	if n := rpc.count("eth_getStorageAt"); n != 1 {
+		t.Errorf("expected 1 eth_getStorageAt, got %d", n)
+	}
+	if n := rpc.count("eth_getProof"); n != 1 {
+		t.Errorf("expected 1 eth_getProof, got %d", n)
+	}
+}
+
+// fakeRPC is a JSON-RPC server serving canned responses by method
+type fakeRPC struct {
+	*httptest.Server
+	mu       sync.Mutex
+	counts   map[string]int
+	requests int
+}
+
+// count returns the number of calls of method, including the calls in batches
+func (f *fakeRPC) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[method]
+}
+
+// httpRequests returns the number of HTTP requests, i.e. a batch counts as one
+func (f *fakeRPC) httpRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func newFakeRPC(t *testing.T, handlers map[string]func(params []json.RawMessage) (interface{}, error)) *fakeRPC {
+	type request struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	type rpcError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	type response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  interface{}     `json:"result,omitempty"`
+		Error   *rpcError       `json:"error,omitempty"`
+	}
+	f := &fakeRPC{counts: make(map[string]int)}
+	serve := func(req request) response {
+		f.mu.Lock()
+		f.counts[req.Method]++
+		f.mu.Unlock()
+		handler, ok := handlers[req.Method]
+		if !ok {
+			return response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{Code: -32601, Message: "method not found: " + req.Method}}
+		}
+		result, err := handler(req.Params)
+		if err != nil {
+			return response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{Code: -32000, Message: err.Error()}}
+		}
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		return response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests++
+		f.mu.Unlock()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("fake rpc: %s", err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+			var reqs []request
+			if err = json.Unmarshal(body, &reqs); err != nil {
+				t.Errorf("fake rpc: %s", err.Error())
+				return
+			}
+			var resps []response
+			for _, req := range reqs {
+				resps = append(resps, serve(req))
+			}
+			check(json.NewEncoder(w).Encode(resps))
+			return
+		}
+		var req request
+		if err = json.Unmarshal(body, &req); err != nil {
+			t.Errorf("fake rpc: %s", err.Error())
+			return
+		}
+		check(json.NewEncoder(w).Encode(serve(req)))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestBuildCanceled(t *testing.T) {
+	c := newTestChain()
+	q, rpc := newTestFetchApp(t, c, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.BuildReceiptCtx(ctx, ReceiptData{TxHash: c.txHash(0), Fields: []LogFieldData{{}}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the receipt to be canceled, got %v", err)
+	}
+	in := defaultCircuitInput(4, 4, 4, 0, 0, 16)
+	if err := q.assignStorageSlots(ctx, &in); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the storage slots to be canceled, got %v", err)
+	}
+	if n := rpc.httpRequests(); n != 0 {
+		t.Errorf("expected no requests after cancellation, got %d", n)
+	}
+}
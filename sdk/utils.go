@@ -260,6 +260,73 @@ func Cmp(api frontend.API, i1, i2 frontend.Variable, nbBits int) frontend.Variab
 	return result
 }
 
+// splitBits splits an nbBits-bit v into v = hi * 2^n + lo where lo has n bits
+// and hi has nbBits - n bits. Uses QuoRemHint. Proving fails if v does not fit
+// in nbBits bits. Requires 0 < n < nbBits.
+func splitBits(api frontend.API, v frontend.Variable, n, nbBits int) (hi, lo frontend.Variable) {
+	base := new(big.Int).Lsh(big.NewInt(1), uint(n))
+	out, err := api.Compiler().NewHint(QuoRemHint, 2, v, base)
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize QuoRemHint instance: %s", err.Error()))
+	}
+	hi, lo = out[0], out[1]
+	rangeChecker := rangecheck.New(api)
+	rangeChecker.Check(hi, nbBits-n)
+	rangeChecker.Check(lo, n)
+	api.AssertIsEqual(api.Add(api.Mul(hi, base), lo), v)
+	return hi, lo
+}
+
+// bitwise applies op to each pair of bits of the nbBits-bit a and b
+func bitwise(api frontend.API, a, b frontend.Variable, nbBits int, op func(x, y frontend.Variable) frontend.Variable) frontend.Variable {
+	aBits := api.ToBinary(a, nbBits)
+	bBits := api.ToBinary(b, nbBits)
+	res := make([]frontend.Variable, nbBits)
+	for i := range res {
+		res[i] = op(aBits[i], bBits[i])
+	}
+	return api.FromBinary(res...)
+}
+
+// shiftLeft returns (v << n) mod 2^nbBits for an nbBits-bit v
+func shiftLeft(api frontend.API, v frontend.Variable, n, nbBits int) frontend.Variable {
+	if n <= 0 {
+		return v
+	}
+	if n >= nbBits {
+		rangecheck.New(api).Check(v, nbBits)
+		return 0
+	}
+	_, lo := splitBits(api, v, nbBits-n, nbBits)
+	return api.Mul(lo, new(big.Int).Lsh(big.NewInt(1), uint(n)))
+}
+
+// shiftRight returns v >> n for an nbBits-bit v
+func shiftRight(api frontend.API, v frontend.Variable, n, nbBits int) frontend.Variable {
+	if n <= 0 {
+		return v
+	}
+	if n >= nbBits {
+		rangecheck.New(api).Check(v, nbBits)
+		return 0
+	}
+	hi, _ := splitBits(api, v, n, nbBits)
+	return hi
+}
+
+// mask returns the lowest n bits of an nbBits-bit v
+func mask(api frontend.API, v frontend.Variable, n, nbBits int) frontend.Variable {
+	if n <= 0 {
+		rangecheck.New(api).Check(v, nbBits)
+		return 0
+	}
+	if n >= nbBits {
+		return v
+	}
+	_, lo := splitBits(api, v, n, nbBits)
+	return lo
+}
+
 // CmpHint is a hint function that compares two values and returns -1, 0, or 1
 func CmpHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
 	// Set result based on comparison: -1 if inputs[0] < inputs[1], 0 if equal, 1 if greater
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/brevis-network/brevis-sdk/sdk"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+)
+
+type groupByStrategy int
+
+const (
+	groupByFiltered groupByStrategy = iota
+	groupBySorted
+)
+
+type groupByRow = sdk.Tuple2[sdk.Uint248, sdk.Uint248]
+
+type GroupByCircuit struct {
+	In sdk.DataPoints[groupByRow]
+
+	strategy groupByStrategy
+}
+
+func newGroupByCircuit(n int, strategy groupByStrategy) *GroupByCircuit {
+	in := sdk.DataPoints[groupByRow]{
+		Raw:     make([]groupByRow, n),
+		Toggles: make([]frontend.Variable, n),
+	}
+	for i := range in.Raw {
+		in.Raw[i] = groupByRow{F0: sdk.ConstUint248(i % 7), F1: sdk.ConstUint248(i)}
+		in.Toggles[i] = 1
+	}
+	return &GroupByCircuit{In: in, strategy: strategy}
+}
+
+func (c *GroupByCircuit) Define(g frontend.API) error {
+	api := sdk.NewCircuitAPI(g)
+	ds := sdk.NewDataStream(api, c.In)
+	reducer := func(acc sdk.Uint248, curr groupByRow) sdk.Uint248 { return api.Uint248.Add(acc, curr.F1) }
+	field := func(curr groupByRow) sdk.Uint248 { return curr.F0 }
+
+	var res *sdk.DataStream[sdk.Uint248]
+	var err error
+	switch c.strategy {
+	case groupByFiltered:
+		res, err = sdk.GroupByFiltered(ds, reducer, sdk.ConstUint248(0), field)
+	case groupBySorted:
+		res, err = sdk.GroupBySorted(ds, reducer, sdk.ConstUint248(0), field)
+	}
+	if err != nil {
+		return err
+	}
+	api.Uint248.AssertIsLessOrEqual(sdk.Count(res), sdk.ConstUint248(len(c.In.Raw)))
+	return nil
+}
+
+func compileGroupBy(t testing.TB, n int, strategy groupByStrategy) int {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, newGroupByCircuit(n, strategy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ccs.GetNbConstraints()
+}
+
+func TestGroupByConstraints(t *testing.T) {
+	for _, n := range []int{32, 128} {
+		filtered := compileGroupBy(t, n, groupByFiltered)
+		sorted := compileGroupBy(t, n, groupBySorted)
+		t.Logf("GroupBy over %d elements: filtered %d constraints, sorted %d constraints", n, filtered, sorted)
+		if sorted >= filtered {
+			t.Errorf("sorted GroupBy (%d constraints) should be cheaper than filtered GroupBy (%d constraints) for %d elements",
+				sorted, filtered, n)
+		}
+	}
+}
+
+func benchmarkGroupBy(b *testing.B, strategy groupByStrategy) {
+	for _, n := range []int{32, 64, 128, 256} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			var constraints int
+			for i := 0; i < b.N; i++ {
+				constraints = compileGroupBy(b, n, strategy)
+			}
+			b.ReportMetric(float64(constraints), "constraints")
+		})
+	}
+}
+
+func BenchmarkGroupByFiltered(b *testing.B) { benchmarkGroupBy(b, groupByFiltered) }
+
+func BenchmarkGroupBySorted(b *testing.B) { benchmarkGroupBy(b, groupBySorted) }